@@ -23,10 +23,8 @@ export default function ShortenerPage() {
 
     try {
       const data = await shortenMutation.mutateAsync(url);
-      const origin =
-        typeof window !== "undefined" ? window.location.origin : "";
 
-      setShortUrl(`${origin}/${data.short_code}`);
+      setShortUrl(data.short_url);
     } catch {
       // Error handled by mutation state, can add toast if needed
     }
@@ -1,18 +1,57 @@
 import apiClient from "@/lib/axios";
 
+// 201 for a new link, 200 when an existing link was reused
 export interface ShortenResponse {
   short_code: string;
+  short_url: string;
+  domain: string;
+  original_url: string;
+  created_at: string;
+  expires_at: string | null;
+  max_clicks: number | null;
+  owner_id: string | null;
+  reused: boolean;
+  qr_url: string;
+  stats_url?: string;
 }
 
 const API_URL = process.env.NEXT_PUBLIC_API_URL;
 
+export type QRFormat = "png" | "svg" | "pdf" | "eps";
+
+export type QRModuleShape = "square" | "circle" | "rounded" | "diamond";
+
+export type QRFinderShape = "square" | "rounded" | "circle";
+
+export type QRGradientType = "linear" | "radial";
+
 export interface QROptions {
+  format?: QRFormat;
+  /** Name of a saved QR template; the other options override its fields. */
+  template?: string;
   logoSize?: number;
   borderRadius?: number;
   fgColor?: string;
   bgColor?: string;
   gradientStart?: string;
   gradientEnd?: string;
+  /** Two or more hex colors with optional positions, e.g. "#F00, #0F0 30%, #00F". */
+  gradientStops?: string;
+  gradientType?: QRGradientType;
+  /** Degrees as in CSS: 180 (default) runs top to bottom. */
+  gradientAngle?: number;
+  /** Radial center as a share of the width and height (0-1). */
+  gradientCenterX?: number;
+  gradientCenterY?: number;
+  errorCorrection?: "L" | "M" | "Q" | "H";
+  quietZone?: number;
+  size?: number;
+  dpi?: number;
+  moduleShape?: QRModuleShape;
+  finderOuterShape?: QRFinderShape;
+  finderInnerShape?: QRFinderShape;
+  finderOuterColor?: string;
+  finderInnerColor?: string;
 }
 
 export const shortenerService = {
@@ -33,6 +72,14 @@ export const shortenerService = {
       formData.append("logo", logo);
     }
 
+    if (options?.format) {
+      formData.append("format", options.format);
+    }
+
+    if (options?.template) {
+      formData.append("template", options.template);
+    }
+
     if (options?.logoSize) {
       formData.append("logo_size", options.logoSize.toString());
     }
@@ -57,6 +104,62 @@ export const shortenerService = {
       formData.append("gradient_end", options.gradientEnd);
     }
 
+    if (options?.gradientStops) {
+      formData.append("gradient_stops", options.gradientStops);
+    }
+
+    if (options?.gradientType) {
+      formData.append("gradient_type", options.gradientType);
+    }
+
+    if (options?.gradientAngle !== undefined) {
+      formData.append("gradient_angle", options.gradientAngle.toString());
+    }
+
+    if (options?.gradientCenterX !== undefined) {
+      formData.append("gradient_center_x", options.gradientCenterX.toString());
+    }
+
+    if (options?.gradientCenterY !== undefined) {
+      formData.append("gradient_center_y", options.gradientCenterY.toString());
+    }
+
+    if (options?.errorCorrection) {
+      formData.append("error_correction", options.errorCorrection);
+    }
+
+    if (options?.quietZone !== undefined) {
+      formData.append("quiet_zone", options.quietZone.toString());
+    }
+
+    if (options?.size) {
+      formData.append("size", options.size.toString());
+    }
+
+    if (options?.dpi) {
+      formData.append("dpi", options.dpi.toString());
+    }
+
+    if (options?.moduleShape) {
+      formData.append("module_shape", options.moduleShape);
+    }
+
+    if (options?.finderOuterShape) {
+      formData.append("finder_outer_shape", options.finderOuterShape);
+    }
+
+    if (options?.finderInnerShape) {
+      formData.append("finder_inner_shape", options.finderInnerShape);
+    }
+
+    if (options?.finderOuterColor) {
+      formData.append("finder_outer_color", options.finderOuterColor);
+    }
+
+    if (options?.finderInnerColor) {
+      formData.append("finder_inner_color", options.finderInnerColor);
+    }
+
     const res = await apiClient.post(`${API_URL}/${code}/qr`, formData, {
       headers: {
         "Content-Type": "multipart/form-data",
@@ -13,8 +13,10 @@ import (
 	"github.com/redis/go-redis/v9"
 
 	"go-shortener-sqlc/internal/api"
+	"go-shortener-sqlc/internal/cache"
 	"go-shortener-sqlc/internal/config"
 	"go-shortener-sqlc/internal/database"
+	"go-shortener-sqlc/internal/geoip"
 )
 
 func main() {
@@ -43,29 +45,59 @@ func main() {
 	}
 	slog.Info("Upload directory ready", "path", cfg.UploadDir)
 
-	// 4. Connect to Redis (optional — server works without it)
-	rdb := redis.NewClient(&redis.Options{
-		Addr: cfg.RedisAddr,
-	})
-	if err := rdb.Ping(context.Background()).Err(); err != nil {
-		slog.Warn("Redis not available, running without cache", "addr", cfg.RedisAddr, "error", err)
-		rdb.Close()
-		rdb = nil
-	} else {
-		defer rdb.Close()
-		slog.Info("Redis connected", "addr", cfg.RedisAddr)
+	// 4. Set up the cache (optional — server works without it)
+	var c cache.Cache
+	switch cfg.CacheBackend {
+	case cache.BackendRedis:
+		rdb := redis.NewClient(&redis.Options{
+			Addr: cfg.RedisAddr,
+		})
+		if err := rdb.Ping(context.Background()).Err(); err != nil {
+			slog.Warn("Redis not available, using in-memory cache", "addr", cfg.RedisAddr, "error", err)
+			rdb.Close()
+			c = cache.NewMemory(cfg.CacheSize)
+		} else {
+			defer rdb.Close()
+			slog.Info("Redis connected", "addr", cfg.RedisAddr)
+			c = cache.NewRedis(rdb)
+		}
+	case cache.BackendMemory:
+		slog.Info("Using in-memory cache", "size", cfg.CacheSize)
+		c = cache.NewMemory(cfg.CacheSize)
+	case cache.BackendNone:
+		slog.Info("Cache disabled")
+		c = cache.Nop{}
+	default:
+		slog.Error("Unknown CACHE_BACKEND", "value", cfg.CacheBackend)
+		os.Exit(1)
 	}
 
-	// 5. Initialize Server
-	srv := api.NewServer(db, cfg, rdb)
+	// 5. Load GeoIP database (optional — clicks are recorded without country)
+	var geo geoip.Lookup = geoip.Nop{}
+	if cfg.GeoIPDBPath != "" {
+		geoDB, err := geoip.Open(cfg.GeoIPDBPath)
+		if err != nil {
+			slog.Warn("GeoIP database not loaded", "path", cfg.GeoIPDBPath, "error", err)
+		} else {
+			geo = geoDB
+			slog.Info("GeoIP database loaded", "path", cfg.GeoIPDBPath, "ranges", geoDB.Len())
+		}
+	}
 
-	// 6. Create HTTP Server
+	// 6. Initialize Server
+	srv, err := api.NewServer(db, cfg, c, geo)
+	if err != nil {
+		slog.Error("Failed to initialize server", "error", err)
+		os.Exit(1)
+	}
+
+	// 7. Create HTTP Server
 	httpServer := &http.Server{
 		Addr:    ":" + cfg.Port,
 		Handler: srv.Routes(),
 	}
 
-	// 7. Start Server in goroutine
+	// 8. Start Server in goroutine
 	go func() {
 		slog.Info("Server starting", "port", cfg.Port)
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -74,14 +106,14 @@ func main() {
 		}
 	}()
 
-	// 8. Wait for interrupt signal (Ctrl+C or Docker stop)
+	// 9. Wait for interrupt signal (Ctrl+C or Docker stop)
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	slog.Info("Shutting down server gracefully...")
 
-	// 9. Graceful shutdown with 10s timeout
+	// 10. Graceful shutdown with 10s timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -90,5 +122,8 @@ func main() {
 		os.Exit(1)
 	}
 
+	// 11. Flush buffered click events
+	srv.Close()
+
 	slog.Info("Server exited cleanly")
 }
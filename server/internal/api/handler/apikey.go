@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-shortener-sqlc/internal/auth"
+	"go-shortener-sqlc/internal/service"
+)
+
+type APIKeyHandler struct {
+	Service *service.APIKeyService
+}
+
+func NewAPIKeyHandler(s *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{Service: s}
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// Create handles POST /api/me/api-keys. The secret is only ever returned in this response.
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 10<<10)
+
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.Service.Create(r.Context(), auth.FromContext(r.Context()).UserID, req.Name, req.Scopes)
+	if err != nil {
+		if errors.Is(err, service.ErrAPIKeyNameRequired) || errors.Is(err, service.ErrAPIKeyScopes) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+}
+
+// List handles GET /api/me/api-keys
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.Service.List(r.Context(), auth.FromContext(r.Context()).UserID)
+	if err != nil {
+		http.Error(w, "Failed to list API keys", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// Revoke handles DELETE /api/me/api-keys/{id}
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	err := h.Service.Revoke(r.Context(), auth.FromContext(r.Context()).UserID, chi.URLParam(r, "id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "API key not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "API key revoked successfully"})
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-shortener-sqlc/internal/auth"
+	"go-shortener-sqlc/internal/db"
+	"go-shortener-sqlc/internal/service"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	queries := db.New(mockDB)
+	handler := NewAPIKeyHandler(service.NewAPIKeyService(queries))
+
+	tests := []struct {
+		name           string
+		body           CreateAPIKeyRequest
+		mockBehavior   func()
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			body: CreateAPIKeyRequest{Name: "release pipeline", Scopes: []string{"urls:write", "urls:read", "urls:write"}},
+			mockBehavior: func() {
+				mock.ExpectExec("INSERT INTO api_keys").
+					WithArgs(sqlmock.AnyArg(), "user-1", "release pipeline", sqlmock.AnyArg(), sqlmock.AnyArg(), "urls:read,urls:write").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Missing Name",
+			body:           CreateAPIKeyRequest{Scopes: []string{"urls:write"}},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unknown Scope",
+			body:           CreateAPIKeyRequest{Name: "ci", Scopes: []string{"everything"}},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "No Scopes",
+			body:           CreateAPIKeyRequest{Name: "ci"},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockBehavior()
+
+			reqBody, _ := json.Marshal(tc.body)
+			req, _ := http.NewRequest("POST", "/api/me/api-keys", bytes.NewBuffer(reqBody))
+			req = req.WithContext(auth.NewContext(req.Context(), &auth.Claims{UserID: "user-1", Role: "user"}))
+			rr := httptest.NewRecorder()
+
+			handler.Create(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					rr.Code, tc.expectedStatus)
+			}
+
+			if rr.Code == http.StatusCreated {
+				var resp service.CreatedAPIKey
+				if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+					t.Fatalf("invalid response body: %v", err)
+				}
+				if !strings.HasPrefix(resp.Key, "gs_") || !strings.HasPrefix(resp.Key, resp.Prefix) {
+					t.Errorf("unexpected key %q with prefix %q", resp.Key, resp.Prefix)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
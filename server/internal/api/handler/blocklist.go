@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-shortener-sqlc/internal/service"
+)
+
+type BlocklistHandler struct {
+	Service *service.Blocklist
+}
+
+func NewBlocklistHandler(s *service.Blocklist) *BlocklistHandler {
+	return &BlocklistHandler{Service: s}
+}
+
+// List handles GET /api/admin/blocklist. Entries from the feed file are not listed.
+func (h *BlocklistHandler) List(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.Service.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list blocklist", http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []service.BlocklistEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// Create handles POST /api/admin/blocklist
+func (h *BlocklistHandler) Create(w http.ResponseWriter, r *http.Request) {
+	entry, ok := decodeBlocklistEntry(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.Service.Create(r.Context(), entry)
+	if err != nil {
+		writeBlocklistError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// Update handles PUT /api/admin/blocklist/{id}
+func (h *BlocklistHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid blocklist entry ID", http.StatusBadRequest)
+		return
+	}
+	entry, ok := decodeBlocklistEntry(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.Service.Update(r.Context(), int32(id), entry)
+	if err != nil {
+		writeBlocklistError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// Delete handles DELETE /api/admin/blocklist/{id}
+func (h *BlocklistHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid blocklist entry ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.Delete(r.Context(), int32(id)); err != nil {
+		writeBlocklistError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Blocklist entry deleted successfully"})
+}
+
+func decodeBlocklistEntry(w http.ResponseWriter, r *http.Request) (service.BlocklistEntry, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, 4<<10)
+
+	var entry service.BlocklistEntry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return entry, false
+	}
+	return entry, true
+}
+
+func writeBlocklistError(w http.ResponseWriter, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		http.Error(w, "Blocklist entry not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrBlocklistConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case isBadRequest(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-shortener-sqlc/internal/auth"
+	"go-shortener-sqlc/internal/service"
+)
+
+const (
+	maxBulkRows     = 1000
+	maxBulkBodySize = 2 << 20 // 2MB
+	// Passwords are hashed one after another at a high bcrypt cost, so few rows may carry one
+	maxBulkPasswords = 10
+)
+
+// bulkCSVColumns are the column names a CSV header row may use.
+var bulkCSVColumns = map[string]bool{
+	"url": true, "alias": true, "expires_at": true, "max_clicks": true, "redirect_mode": true, "domain": true,
+}
+
+// BulkShortenResult is the outcome of one input row. Row is 1-based and
+// excludes the CSV header, so it can be matched back to the input.
+type BulkShortenResult struct {
+	Row       int        `json:"row"`
+	URL       string     `json:"url"`
+	Alias     string     `json:"alias,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	ShortCode string     `json:"short_code,omitempty"`
+	ShortURL  string     `json:"short_url,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+type BulkShortenResponse struct {
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+	Results   []BulkShortenResult `json:"results"`
+}
+
+// bulkRow is one parsed input row; err is set when the row itself could not be parsed.
+type bulkRow struct {
+	req ShortenRequest
+	err error
+}
+
+// ShortenBulk handles POST /api/shorten/bulk.
+// Accepts a JSON array of ShortenRequest, a text/csv body, or a multipart upload in
+// form field "file". Each row is shortened independently and the response uses the
+// same format as the input.
+func (h *URLHandler) ShortenBulk(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkBodySize)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var (
+		rows  []bulkRow
+		isCSV bool
+		err   error
+	)
+	switch mediaType {
+	case "text/csv":
+		isCSV = true
+		rows, err = parseBulkCSV(r.Body)
+	case "multipart/form-data":
+		isCSV = true
+		if err = r.ParseMultipartForm(maxBulkBodySize); err != nil {
+			http.Error(w, "Invalid multipart form (max 2MB)", http.StatusBadRequest)
+			return
+		}
+		file, _, ferr := r.FormFile("file")
+		if ferr != nil {
+			http.Error(w, "Missing CSV file. Use form field 'file'.", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		rows, err = parseBulkCSV(file)
+	default:
+		rows, err = parseBulkJSON(r.Body)
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "Request body too large (max 2MB)", http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+	if len(rows) == 0 {
+		http.Error(w, "No rows to shorten", http.StatusBadRequest)
+		return
+	}
+	if len(rows) > maxBulkRows {
+		http.Error(w, fmt.Sprintf("Too many rows (max %d)", maxBulkRows), http.StatusBadRequest)
+		return
+	}
+	protected := 0
+	for _, row := range rows {
+		if row.req.Password != "" {
+			protected++
+		}
+	}
+	if protected > maxBulkPasswords {
+		http.Error(w, fmt.Sprintf("Too many password-protected rows (max %d)", maxBulkPasswords), http.StatusBadRequest)
+		return
+	}
+
+	var userID string
+	if claims := auth.FromContext(r.Context()); claims != nil {
+		userID = claims.UserID
+	}
+
+	resp := BulkShortenResponse{Results: make([]BulkShortenResult, len(rows))}
+	for i, row := range rows {
+		result := BulkShortenResult{
+			Row:       i + 1,
+			URL:       row.req.URL,
+			Alias:     row.req.Alias,
+			ExpiresAt: row.req.ExpiresAt,
+		}
+
+		err := row.err
+		if err == nil {
+			var link *service.ShortenResult
+			link, err = h.Service.Shorten(r.Context(), service.ShortenParams{
+				URL:          row.req.URL,
+				Alias:        row.req.Alias,
+				ExpiresAt:    row.req.ExpiresAt,
+				MaxClicks:    row.req.MaxClicks,
+				Password:     row.req.Password,
+				RedirectMode: row.req.RedirectMode,
+				Variants:     row.req.Variants,
+				Sticky:       row.req.Sticky,
+				UTM:          row.req.utm(),
+				ForwardQuery: row.req.ForwardQuery,
+				Domain:       row.req.Domain,
+				UserID:       userID,
+			})
+			if err == nil {
+				result.ShortCode, result.ShortURL = link.ShortCode, link.ShortURL
+			}
+		}
+		if err != nil {
+			result.Error = bulkErrorMessage(err)
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+		resp.Results[i] = result
+	}
+
+	if isCSV {
+		writeBulkCSV(w, resp.Results)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// bulkErrorMessage exposes validation errors to the caller and hides internal ones.
+func bulkErrorMessage(err error) string {
+	var parseErr *bulkParseError
+	switch {
+	case errors.As(err, &parseErr), isBadRequest(err), errors.Is(err, service.ErrAliasTaken):
+		return err.Error()
+	default:
+		return "internal server error"
+	}
+}
+
+type bulkParseError struct {
+	msg string
+}
+
+func (e *bulkParseError) Error() string { return e.msg }
+
+func parseBulkJSON(r io.Reader) ([]bulkRow, error) {
+	var reqs []ShortenRequest
+	if err := json.NewDecoder(r).Decode(&reqs); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, err
+		}
+		return nil, errors.New("invalid request body: expected a JSON array of {url, alias, expires_at, max_clicks}")
+	}
+	rows := make([]bulkRow, len(reqs))
+	for i, req := range reqs {
+		rows[i] = bulkRow{req: req}
+	}
+	return rows, nil
+}
+
+// parseBulkCSV reads "url,alias,expires_at" rows. A header row is optional; it is
+// recognized by any known column name, columns are then matched by name, may appear
+// in any order and may include max_clicks, redirect_mode and domain.
+func parseBulkCSV(r io.Reader) ([]bulkRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+
+	cols := map[string]int{"url": 0, "alias": 1, "expires_at": 2}
+	if len(records) > 0 && isBulkCSVHeader(records[0]) {
+		cols = map[string]int{}
+		for i, name := range records[0] {
+			cols[csvHeader(name)] = i
+		}
+		if _, ok := cols["url"]; !ok {
+			return nil, errors.New("invalid CSV: header has no url column")
+		}
+		records = records[1:]
+	}
+
+	field := func(rec []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	var rows []bulkRow
+	for _, rec := range records {
+		if len(rec) == 1 && strings.TrimSpace(rec[0]) == "" {
+			continue // blank line
+		}
+
+		row := bulkRow{req: ShortenRequest{
+			URL:          field(rec, "url"),
+			Alias:        field(rec, "alias"),
+			RedirectMode: field(rec, "redirect_mode"),
+			Domain:       field(rec, "domain"),
+		}}
+		if val := field(rec, "expires_at"); val != "" {
+			t, err := time.Parse(time.RFC3339, val)
+			if err != nil {
+				row.err = &bulkParseError{msg: "invalid expires_at (expected RFC 3339)"}
+			} else {
+				row.req.ExpiresAt = &t
+			}
+		}
+		if val := field(rec, "max_clicks"); val != "" && row.err == nil {
+			n, err := strconv.ParseUint(val, 10, 32)
+			if err != nil {
+				row.err = &bulkParseError{msg: "invalid max_clicks"}
+			} else {
+				maxClicks := uint32(n)
+				row.req.MaxClicks = &maxClicks
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func isBulkCSVHeader(rec []string) bool {
+	for _, cell := range rec {
+		if bulkCSVColumns[csvHeader(cell)] {
+			return true
+		}
+	}
+	return false
+}
+
+// csvHeader normalizes a header cell, dropping the BOM that spreadsheet exports prepend.
+func csvHeader(s string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(s, "\ufeff")))
+}
+
+func writeBulkCSV(w http.ResponseWriter, results []BulkShortenResult) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="shortened.csv"`)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"row", "url", "alias", "expires_at", "short_code", "short_url", "error"})
+	for _, res := range results {
+		var expiresAt string
+		if res.ExpiresAt != nil {
+			expiresAt = res.ExpiresAt.Format(time.RFC3339)
+		}
+		cw.Write([]string{strconv.Itoa(res.Row), res.URL, res.Alias, expiresAt, res.ShortCode, res.ShortURL, res.Error})
+	}
+	cw.Flush()
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-shortener-sqlc/internal/db"
+	"go-shortener-sqlc/internal/service"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestShortenBulkJSON(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	handler := NewURLHandler(service.NewURLService(mockDB, db.New(mockDB), nil, nil), nil)
+
+	// Row 1: new link. Row 2: unsafe destination, rejected before touching the DB.
+	mock.ExpectQuery("SELECT (.+) FROM urls WHERE url_hash").
+		WithArgs(sqlmock.AnyArg(), nil, "").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+		WithArgs("", sqlmock.AnyArg()).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO urls").
+		WithArgs(sqlmock.AnyArg(), testURL, sqlmock.AnyArg(), false, nil, nil, nil, nil, "302", false, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+		WithArgs("", sqlmock.AnyArg()).
+		WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+
+	body, _ := json.Marshal([]ShortenRequest{{URL: testURL}, {URL: "http://127.0.0.1/"}})
+	req, _ := http.NewRequest("POST", "/api/shorten/bulk", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.ShortenBulk(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	var resp BulkShortenResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("invalid response body: %v", err)
+	}
+	if resp.Succeeded != 1 || resp.Failed != 1 {
+		t.Errorf("got succeeded=%d failed=%d, want 1 and 1", resp.Succeeded, resp.Failed)
+	}
+	if resp.Results[0].ShortCode == "" || resp.Results[1].Error != service.ErrUnsafeURL.Error() {
+		t.Errorf("unexpected results: %+v", resp.Results)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestShortenBulkCSV(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	urlService := service.NewURLService(mockDB, db.New(mockDB), nil, nil)
+	urlService.BaseURL = "https://sho.rt"
+	handler := NewURLHandler(urlService, nil)
+
+	// Row 1 is deduplicated against an existing link; row 2 has a bad expiry.
+	mock.ExpectQuery("SELECT (.+) FROM urls WHERE url_hash").
+		WithArgs(sqlmock.AnyArg(), nil, "").
+		WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+
+	input := "\ufeffURL,Expires_At\n" + testURL + ",\n" + testURL + "/x,tomorrow\n"
+	req, _ := http.NewRequest("POST", "/api/shorten/bulk", strings.NewReader(input))
+	req.Header.Set("Content-Type", "text/csv")
+	rr := httptest.NewRecorder()
+
+	handler.ShortenBulk(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("expected CSV response, got %q", ct)
+	}
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV response: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected header + 2 rows, got %d", len(records))
+	}
+	if records[1][4] != "abcdef" || records[1][5] != "https://sho.rt/abcdef" || records[1][6] != "" {
+		t.Errorf("row 1: got %v", records[1])
+	}
+	if records[2][4] != "" || !strings.Contains(records[2][6], "expires_at") {
+		t.Errorf("row 2: got %v", records[2])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestParseBulkCSVHeader(t *testing.T) {
+	rows, err := parseBulkCSV(strings.NewReader("alias,URL,max_clicks\nlaunch,https://example.com,5\n"))
+	if err != nil {
+		t.Fatalf("parseBulkCSV: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(rows))
+	}
+	req := rows[0].req
+	if rows[0].err != nil || req.URL != "https://example.com" || req.Alias != "launch" || req.MaxClicks == nil || *req.MaxClicks != 5 {
+		t.Errorf("row = %+v (err %v)", req, rows[0].err)
+	}
+
+	if _, err := parseBulkCSV(strings.NewReader("alias,domain\nlaunch,go.example.com\n")); err == nil {
+		t.Error("header without a url column: expected an error")
+	}
+}
+
+func TestShortenBulkPasswordLimit(t *testing.T) {
+	handler := NewURLHandler(service.NewURLService(nil, nil, nil, nil), nil)
+
+	reqs := make([]ShortenRequest, maxBulkPasswords+1)
+	for i := range reqs {
+		reqs[i] = ShortenRequest{URL: testURL, Password: "letmein"}
+	}
+	body, _ := json.Marshal(reqs)
+	req, _ := http.NewRequest("POST", "/api/shorten/bulk", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.ShortenBulk(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-shortener-sqlc/internal/service"
+)
+
+type ClickHandler struct {
+	Service *service.ClickService
+}
+
+func NewClickHandler(s *service.ClickService) *ClickHandler {
+	return &ClickHandler{Service: s}
+}
+
+// Stats handles GET /api/admin/urls/{code}/stats?interval=hour|day|week&from=RFC3339&to=RFC3339
+func (h *ClickHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = service.IntervalDay
+	}
+
+	to := time.Now()
+	if val := r.URL.Query().Get("to"); val != "" {
+		t, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			http.Error(w, "Invalid 'to' (expected RFC 3339)", http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+
+	from := to.Add(-service.DefaultStatsRange(interval))
+	if val := r.URL.Query().Get("from"); val != "" {
+		t, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			http.Error(w, "Invalid 'from' (expected RFC 3339)", http.StatusBadRequest)
+			return
+		}
+		from = t
+	}
+
+	stats, err := h.Service.Stats(r.Context(), code, interval, from, to)
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			http.Error(w, "URL not found", http.StatusNotFound)
+		case errors.Is(err, service.ErrInvalidInterval), errors.Is(err, service.ErrInvalidRange):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to load stats", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
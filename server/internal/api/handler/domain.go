@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-shortener-sqlc/internal/service"
+)
+
+type DomainHandler struct {
+	Service *service.DomainService
+}
+
+func NewDomainHandler(s *service.DomainService) *DomainHandler {
+	return &DomainHandler{Service: s}
+}
+
+// List handles GET /api/domains. The default domain is not listed.
+func (h *DomainHandler) List(w http.ResponseWriter, r *http.Request) {
+	domains, err := h.Service.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list domains", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(domains)
+}
+
+// Create handles POST /api/admin/domains with {"host": "go.example.com"}
+func (h *DomainHandler) Create(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<10)
+
+	var req struct {
+		Host string `json:"host"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	domain, err := h.Service.Create(r.Context(), req.Host)
+	if err != nil {
+		writeDomainError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(domain)
+}
+
+// Delete handles DELETE /api/admin/domains/{id}. Domains that still have links are kept.
+func (h *DomainHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid domain ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.Delete(r.Context(), int32(id)); err != nil {
+		writeDomainError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Domain deleted successfully"})
+}
+
+func writeDomainError(w http.ResponseWriter, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		http.Error(w, "Domain not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrDomainConflict), errors.Is(err, service.ErrDomainInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case isBadRequest(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-shortener-sqlc/internal/service"
+)
+
+type HealthHandler struct {
+	Service *service.HealthService
+}
+
+func NewHealthHandler(s *service.HealthService) *HealthHandler {
+	return &HealthHandler{Service: s}
+}
+
+// ListBroken handles GET /api/admin/urls/broken?page=&limit=
+func (h *HealthHandler) ListBroken(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+
+	result, err := h.Service.ListBroken(r.Context(), page, limit)
+	if err != nil {
+		http.Error(w, "Failed to list broken URLs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// Get handles GET /api/admin/urls/{code}/health. Links that were never checked return 404.
+func (h *HealthHandler) Get(w http.ResponseWriter, r *http.Request) {
+	health, err := h.Service.Get(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		writeURLError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(health)
+}
+
+// Check handles POST /api/admin/urls/{code}/health, checking the destination now.
+// A failed check is not an HTTP error: the response carries the status and error.
+func (h *HealthHandler) Check(w http.ResponseWriter, r *http.Request) {
+	health, err := h.Service.Check(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		writeURLError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(health)
+}
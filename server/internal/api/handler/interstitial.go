@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+)
+
+// previewPageTmpl shows the destination and waits for the visitor to continue.
+var previewPageTmpl = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>You are leaving for {{.Host}}</title>
+<style>
+body{font-family:system-ui,sans-serif;background:#f5f5f5;display:flex;align-items:center;justify-content:center;min-height:100vh;margin:0}
+main{background:#fff;padding:2rem;border-radius:8px;box-shadow:0 1px 4px rgba(0,0,0,.1);width:100%;max-width:480px}
+h1{font-size:1.1rem;margin:0 0 1rem}
+code{display:block;word-break:break-all;background:#f0f0f0;padding:.6rem;border-radius:4px}
+a.button{display:inline-block;margin-top:1rem;padding:.6rem 1.2rem;background:#1a73e8;color:#fff;border-radius:4px;text-decoration:none}
+</style>
+{{.Tracking}}
+</head>
+<body>
+<main>
+<h1>This link goes to {{.Host}}</h1>
+<code>{{.URL}}</code>
+<a class="button" href="{{.URL}}" rel="noopener noreferrer">Continue</a>
+</main>
+</body>
+</html>
+`))
+
+// metaRefreshPageTmpl redirects once the page has loaded, so tracking snippets can fire first.
+// The meta refresh is the fallback for clients without JavaScript.
+var metaRefreshPageTmpl = template.Must(template.New("meta").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<meta http-equiv="refresh" content="1;url={{.URL}}">
+<title>Redirecting…</title>
+{{.Tracking}}
+<script>window.addEventListener("load", function () { window.location.replace({{.URL}}); });</script>
+</head>
+<body>
+<p>Redirecting to <a href="{{.URL}}">{{.Host}}</a>…</p>
+</body>
+</html>
+`))
+
+type redirectPage struct {
+	URL      string
+	Host     string
+	Tracking template.HTML
+}
+
+func renderRedirectPage(w http.ResponseWriter, tmpl *template.Template, destination string, tracking template.HTML) {
+	page := redirectPage{URL: destination, Host: destination, Tracking: tracking}
+	if u, err := url.Parse(destination); err == nil && u.Host != "" {
+		page.Host = u.Host
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	tmpl.Execute(w, page)
+}
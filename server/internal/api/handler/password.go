@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// passwordFormTmpl is the interstitial shown instead of redirecting to a protected link.
+// The form posts back to the same /{code} URL.
+var passwordFormTmpl = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Password required</title>
+<style>
+body{font-family:system-ui,sans-serif;background:#f5f5f5;display:flex;align-items:center;justify-content:center;min-height:100vh;margin:0}
+form{background:#fff;padding:2rem;border-radius:8px;box-shadow:0 1px 4px rgba(0,0,0,.1);width:100%;max-width:320px}
+h1{font-size:1.1rem;margin:0 0 1rem}
+input,button{width:100%;box-sizing:border-box;padding:.6rem;margin-top:.5rem;font-size:1rem}
+.error{color:#b00020;margin:.5rem 0 0}
+</style>
+</head>
+<body>
+<form method="post">
+<h1>This link is password protected</h1>
+<input type="password" name="password" placeholder="Password" autocomplete="current-password" required autofocus>
+{{if .}}<p class="error">{{.}}</p>{{end}}
+<button type="submit">Continue</button>
+</form>
+</body>
+</html>
+`))
+
+func renderPasswordForm(w http.ResponseWriter, status int, errMsg string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	w.WriteHeader(status)
+	passwordFormTmpl.Execute(w, errMsg)
+}
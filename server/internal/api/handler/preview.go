@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-shortener-sqlc/internal/service"
+)
+
+type PreviewHandler struct {
+	Service *service.PreviewService
+}
+
+func NewPreviewHandler(s *service.PreviewService) *PreviewHandler {
+	return &PreviewHandler{Service: s}
+}
+
+// Get handles GET /api/admin/urls/{code}/preview
+func (h *PreviewHandler) Get(w http.ResponseWriter, r *http.Request) {
+	preview, err := h.Service.Get(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		writeURLError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+// Refresh handles POST /api/admin/urls/{code}/preview, fetching the destination again now.
+// A failed fetch is not an HTTP error: the response has status "failed" and the reason.
+func (h *PreviewHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	preview, err := h.Service.Refresh(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		writeURLError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"image"
-	"image/png"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -15,6 +18,10 @@ import (
 
 type QRHandler struct {
 	Service *service.QRService
+	// Domains resolves the optional "domain" form field; the request Host is used otherwise
+	Domains *service.DomainService
+	// Templates resolves the optional "template" field; nil treats every name as unknown
+	Templates *service.QRTemplateService
 }
 
 func NewQRHandler(s *service.QRService) *QRHandler {
@@ -43,13 +50,65 @@ func (h *QRHandler) GenerateQR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse options
-	opts := service.QROptions{
-		LogoSize:      100,
-		FgColor:       r.FormValue("fg_color"),
-		BgColor:       r.FormValue("bg_color"),
-		GradientStart: r.FormValue("gradient_start"),
-		GradientEnd:   r.FormValue("gradient_end"),
+	// An explicit domain lets the admin UI render QR codes of branded links from the API host
+	if val := r.FormValue("domain"); val != "" {
+		domain, err := h.Domains.Canonical(val)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx = service.WithDomain(ctx, domain)
+	}
+
+	format, err := qrFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Parse options; a saved template is the starting point the other fields override
+	opts := service.QROptions{LogoSize: 100}
+	if name := r.FormValue("template"); name != "" {
+		if h.Templates == nil {
+			http.Error(w, service.ErrQRTemplateNotFound.Error(), http.StatusBadRequest)
+			return
+		}
+		opts, err = h.Templates.Options(ctx, name)
+		if err != nil {
+			if isBadRequest(err) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			slog.Error("Failed to load QR template", "template", name, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	for _, f := range []struct {
+		name string
+		dst  *string
+	}{
+		{"fg_color", &opts.FgColor},
+		{"bg_color", &opts.BgColor},
+		{"gradient_start", &opts.GradientStart},
+		{"gradient_end", &opts.GradientEnd},
+		{"gradient_stops", &opts.GradientStops},
+		{"gradient_type", &opts.GradientType},
+		{"error_correction", &opts.ErrorCorrection},
+		{"module_shape", &opts.ModuleShape},
+		{"finder_outer_shape", &opts.FinderOuterShape},
+		{"finder_inner_shape", &opts.FinderInnerShape},
+		{"finder_outer_color", &opts.FinderOuterColor},
+		{"finder_inner_color", &opts.FinderInnerColor},
+	} {
+		if val := r.FormValue(f.name); val != "" {
+			*f.dst = val
+		}
+	}
+	// A two-color gradient from the form replaces the template's stops
+	if opts.GradientStart != "" && opts.GradientEnd != "" && r.FormValue("gradient_stops") == "" {
+		opts.GradientStops = ""
 	}
 
 	if val := r.FormValue("logo_size"); val != "" {
@@ -64,6 +123,52 @@ func (h *QRHandler) GenerateQR(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Layout fields are validated by the service; non-numbers fail the same way
+	for _, f := range []struct {
+		name string
+		dst  *int
+		err  error
+	}{
+		{"size", &opts.Size, service.ErrQRSize},
+		{"dpi", &opts.DPI, service.ErrQRDPI},
+	} {
+		if val := r.FormValue(f.name); val != "" {
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				http.Error(w, f.err.Error(), http.StatusBadRequest)
+				return
+			}
+			*f.dst = n
+		}
+	}
+	if val := r.FormValue("quiet_zone"); val != "" {
+		quiet, err := strconv.Atoi(val)
+		if err != nil {
+			http.Error(w, service.ErrQRQuietZone.Error(), http.StatusBadRequest)
+			return
+		}
+		opts.QuietZone = &quiet
+	}
+
+	for _, f := range []struct {
+		name string
+		dst  **float64
+		err  error
+	}{
+		{"gradient_angle", &opts.GradientAngle, service.ErrQRGradientAngle},
+		{"gradient_center_x", &opts.GradientCenterX, service.ErrQRGradientCenter},
+		{"gradient_center_y", &opts.GradientCenterY, service.ErrQRGradientCenter},
+	} {
+		if val := r.FormValue(f.name); val != "" {
+			n, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				http.Error(w, f.err.Error(), http.StatusBadRequest)
+				return
+			}
+			*f.dst = &n
+		}
+	}
+
 	file, _, err := r.FormFile("logo")
 	if err == nil {
 		defer file.Close()
@@ -75,7 +180,7 @@ func (h *QRHandler) GenerateQR(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if cfg.Width > 2048 || cfg.Height > 2048 {
+		if cfg.Width > service.MaxQRLogoDimension || cfg.Height > service.MaxQRLogoDimension {
 			http.Error(w, "Image dimensions too large (max 2048x2048)", http.StatusBadRequest)
 			return
 		}
@@ -88,15 +193,58 @@ func (h *QRHandler) GenerateQR(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call Service
-	qrImage, err := h.Service.GenerateQR(ctx, code, opts)
-	if err != nil {
+	var buf bytes.Buffer
+	if err := h.Service.WriteQR(ctx, &buf, code, format, opts); err != nil {
+		if isBadRequest(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "image/png")
-	pngEncoder := png.Encoder{CompressionLevel: png.BestSpeed}
-	if err := pngEncoder.Encode(w, qrImage); err != nil {
-		http.Error(w, "Error encoding PNG", http.StatusInternalServerError)
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", code+"."+string(format)))
+	w.Header().Set("Vary", "Accept")
+	w.Write(buf.Bytes())
+}
+
+// qrFormat returns the "format" field, or else the format the Accept header prefers.
+func qrFormat(r *http.Request) (service.QRFormat, error) {
+	if val := r.FormValue("format"); val != "" {
+		return service.ParseQRFormat(val)
+	}
+	return acceptedQRFormat(r.Header.Get("Accept")), nil
+}
+
+// acceptedQRFormat returns the highest-weighted format of an Accept header. Wildcards
+// and headers without a supported type get PNG.
+func acceptedQRFormat(header string) service.QRFormat {
+	best, bestQ := service.QRFormatPNG, 0.0
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		q := 1.0
+		if val, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= bestQ {
+			continue
+		}
+		if mediaType == "*/*" || mediaType == "image/*" {
+			best, bestQ = service.QRFormatPNG, q
+			continue
+		}
+		for _, f := range service.QRFormats {
+			if mediaType == f.ContentType {
+				best, bestQ = f.Format, q
+				break
+			}
+		}
 	}
+	return best
 }
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-shortener-sqlc/internal/service"
+)
+
+type QRTemplateHandler struct {
+	Service *service.QRTemplateService
+}
+
+func NewQRTemplateHandler(s *service.QRTemplateService) *QRTemplateHandler {
+	return &QRTemplateHandler{Service: s}
+}
+
+// List handles GET /api/admin/qr-templates
+func (h *QRTemplateHandler) List(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.Service.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list QR templates", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// Get handles GET /api/admin/qr-templates/{id}
+func (h *QRTemplateHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := qrTemplateID(w, r)
+	if !ok {
+		return
+	}
+
+	t, err := h.Service.Get(r.Context(), id)
+	if err != nil {
+		writeQRTemplateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+// Create handles POST /api/admin/qr-templates
+func (h *QRTemplateHandler) Create(w http.ResponseWriter, r *http.Request) {
+	t, ok := decodeQRTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.Service.Create(r.Context(), t)
+	if err != nil {
+		writeQRTemplateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// Update handles PUT /api/admin/qr-templates/{id}
+func (h *QRTemplateHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := qrTemplateID(w, r)
+	if !ok {
+		return
+	}
+	t, ok := decodeQRTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.Service.Update(r.Context(), id, t)
+	if err != nil {
+		writeQRTemplateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// Delete handles DELETE /api/admin/qr-templates/{id}
+func (h *QRTemplateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := qrTemplateID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.Delete(r.Context(), id); err != nil {
+		writeQRTemplateError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "QR template deleted successfully"})
+}
+
+func qrTemplateID(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid QR template ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func decodeQRTemplate(w http.ResponseWriter, r *http.Request) (service.QRTemplate, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, 8<<10)
+
+	var t service.QRTemplate
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return t, false
+	}
+	return t, true
+}
+
+func writeQRTemplateError(w http.ResponseWriter, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		http.Error(w, "QR template not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrQRTemplateConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case isBadRequest(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-shortener-sqlc/internal/service"
+)
+
+func TestGenerateQR(t *testing.T) {
+	handler := NewQRHandler(service.NewQRService("https://sho.rt"))
+
+	tests := []struct {
+		name           string
+		fields         map[string]string
+		accept         string
+		expectedStatus int
+		expectedType   string
+	}{
+		{"Default PNG", nil, "", http.StatusOK, "image/png"},
+		{"Format Field", map[string]string{"format": "svg"}, "image/png", http.StatusOK, "image/svg+xml"},
+		{"Accept PDF", nil, "application/pdf", http.StatusOK, "application/pdf"},
+		{"Accept Weighted", nil, "image/svg+xml;q=0.5, application/postscript", http.StatusOK, "application/postscript"},
+		{"Unknown Format", map[string]string{"format": "gif"}, "", http.StatusBadRequest, ""},
+		{"Invalid Color", map[string]string{"format": "svg", "fg_color": "navy"}, "", http.StatusBadRequest, ""},
+		{"Layout", map[string]string{"error_correction": "H", "quiet_zone": "4", "size": "512", "dpi": "600"}, "", http.StatusOK, "image/png"},
+		{"Invalid Level", map[string]string{"error_correction": "Z"}, "", http.StatusBadRequest, ""},
+		{"Invalid Size", map[string]string{"size": "big"}, "", http.StatusBadRequest, ""},
+		{"Size Out Of Range", map[string]string{"size": "10000"}, "", http.StatusBadRequest, ""},
+		{"Invalid Quiet Zone", map[string]string{"quiet_zone": "-1"}, "", http.StatusBadRequest, ""},
+		{"Shapes", map[string]string{"module_shape": "circle", "finder_outer_shape": "rounded", "finder_inner_color": "#e11d48"}, "", http.StatusOK, "image/png"},
+		{"Invalid Module Shape", map[string]string{"module_shape": "star"}, "", http.StatusBadRequest, ""},
+		{"Invalid Finder Shape", map[string]string{"finder_inner_shape": "diamond"}, "", http.StatusBadRequest, ""},
+		{"Gradient", map[string]string{"format": "svg", "gradient_stops": "#f00, #0f0 40%, #00f", "gradient_angle": "45", "bg_color": "transparent"}, "", http.StatusOK, "image/svg+xml"},
+		{"Radial Gradient", map[string]string{"gradient_stops": "#f00, #00f", "gradient_type": "radial", "gradient_center_x": "0.2"}, "", http.StatusOK, "image/png"},
+		{"Invalid Gradient Stops", map[string]string{"gradient_stops": "#f00"}, "", http.StatusBadRequest, ""},
+		{"Unknown Template", map[string]string{"template": "brand"}, "", http.StatusBadRequest, ""},
+		{"Invalid Gradient Angle", map[string]string{"gradient_stops": "#f00, #00f", "gradient_angle": "left"}, "", http.StatusBadRequest, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			body := new(bytes.Buffer)
+			mw := multipart.NewWriter(body)
+			for k, v := range tc.fields {
+				mw.WriteField(k, v)
+			}
+			mw.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/abc123/qr", body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("code", "abc123")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := httptest.NewRecorder()
+
+			handler.GenerateQR(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tc.expectedType != "" && rr.Header().Get("Content-Type") != tc.expectedType {
+				t.Errorf("expected Content-Type %q, got %q", tc.expectedType, rr.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestAcceptedQRFormat(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected service.QRFormat
+	}{
+		{"", service.QRFormatPNG},
+		{"*/*", service.QRFormatPNG},
+		{"image/svg+xml", service.QRFormatSVG},
+		{"text/html, application/pdf;q=0.9", service.QRFormatPDF},
+		{"application/pdf;q=0.5, */*", service.QRFormatPNG},
+		{"image/svg+xml;q=0.4, image/png;q=0.8", service.QRFormatPNG},
+		{"text/html", service.QRFormatPNG},
+	}
+	for _, tc := range tests {
+		if got := acceptedQRFormat(tc.header); got != tc.expected {
+			t.Errorf("acceptedQRFormat(%q) = %q, want %q", tc.header, got, tc.expected)
+		}
+	}
+}
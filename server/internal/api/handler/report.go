@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-shortener-sqlc/internal/service"
+)
+
+type ReportHandler struct {
+	Service *service.ReportService
+}
+
+func NewReportHandler(s *service.ReportService) *ReportHandler {
+	return &ReportHandler{Service: s}
+}
+
+// ReportRequest is the body of a public link report.
+type ReportRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Report handles POST /{code}/report, queueing the link for admin review.
+func (h *ReportHandler) Report(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 8<<10)
+
+	var req ReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.Report(r.Context(), chi.URLParam(r, "code"), req.Reason, clientIP(r)); err != nil {
+		switch {
+		case errors.Is(err, service.ErrAlreadyReported):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			writeURLError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Thanks, the link will be reviewed"})
+}
+
+// List handles GET /api/admin/reports?status=open|dismissed|disabled&page=&limit=
+func (h *ReportHandler) List(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+
+	result, err := h.Service.List(r.Context(), r.URL.Query().Get("status"), page, limit)
+	if err != nil {
+		if isBadRequest(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to list reports", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// Dismiss handles POST /api/admin/reports/{id}/dismiss
+func (h *ReportHandler) Dismiss(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.Service.Dismiss, "Report dismissed")
+}
+
+// DisableLink handles POST /api/admin/reports/{id}/disable, disabling the reported link.
+func (h *ReportHandler) DisableLink(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.Service.DisableLink, "Link disabled")
+}
+
+func (h *ReportHandler) review(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, id int32) error, message string) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid report ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := action(r.Context(), int32(id)); err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			http.Error(w, "Report not found", http.StatusNotFound)
+		case errors.Is(err, service.ErrReportReviewed):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-shortener-sqlc/internal/db"
+	"go-shortener-sqlc/internal/service"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestReportLink(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	queries := db.New(mockDB)
+	urlService := service.NewURLService(mockDB, queries, nil, nil)
+	handler := NewReportHandler(service.NewReportService(queries, urlService))
+
+	expectURL := func() {
+		mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+			WithArgs("", "abc123").
+			WillReturnRows(urlRows().AddRow(1, "abc123", testURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		mockBehavior   func()
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			body: `{"reason":"phishing page"}`,
+			mockBehavior: func() {
+				expectURL()
+				mock.ExpectQuery("SELECT COUNT(.+) FROM link_reports").
+					WithArgs(1, "192.0.2.1").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				mock.ExpectExec("INSERT INTO link_reports").
+					WithArgs(1, "phishing page", "192.0.2.1").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name: "Already Reported",
+			body: `{"reason":"again"}`,
+			mockBehavior: func() {
+				expectURL()
+				mock.ExpectQuery("SELECT COUNT(.+) FROM link_reports").
+					WithArgs(1, "192.0.2.1").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "Unknown Code",
+			body: `{}`,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "abc123").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid Body",
+			body:           `not json`,
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockBehavior()
+
+			req, _ := http.NewRequest("POST", "/abc123/report", bytes.NewBufferString(tc.body))
+			req.RemoteAddr = "192.0.2.1:1234"
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("code", "abc123")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := httptest.NewRecorder()
+
+			handler.Report(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tc.expectedStatus)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestReviewReport(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	queries := db.New(mockDB)
+	urlService := service.NewURLService(mockDB, queries, nil, nil)
+	handler := NewReportHandler(service.NewReportService(queries, urlService))
+
+	reportRows := func(status string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "url_id", "domain", "short_code", "status"}).AddRow(7, 1, "", "abc123", status)
+	}
+
+	tests := []struct {
+		name           string
+		action         http.HandlerFunc
+		mockBehavior   func()
+		expectedStatus int
+	}{
+		{
+			name:   "Dismiss",
+			action: handler.Dismiss,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM link_reports r").WithArgs(7).WillReturnRows(reportRows("open"))
+				mock.ExpectExec("UPDATE link_reports SET status").
+					WithArgs("dismissed", sqlmock.AnyArg(), 7).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "Disable Link",
+			action: handler.DisableLink,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM link_reports r").WithArgs(7).WillReturnRows(reportRows("open"))
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "abc123").
+					WillReturnRows(urlRows().AddRow(1, "abc123", testURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+				mock.ExpectExec("UPDATE urls SET disabled").
+					WithArgs(true, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "abc123").
+					WillReturnRows(urlRows().AddRow(1, "abc123", testURL, "hash", false, nil, nil, 0, true, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+				mock.ExpectExec("UPDATE link_reports SET status").
+					WithArgs("disabled", sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "Already Reviewed",
+			action: handler.Dismiss,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM link_reports r").WithArgs(7).WillReturnRows(reportRows("dismissed"))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "Not Found",
+			action: handler.DisableLink,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM link_reports r").WithArgs(7).WillReturnError(sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockBehavior()
+
+			req, _ := http.NewRequest("POST", "/api/admin/reports/7/dismiss", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "7")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := httptest.NewRecorder()
+
+			tc.action(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tc.expectedStatus)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
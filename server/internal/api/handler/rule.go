@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-shortener-sqlc/internal/service"
+)
+
+// ListRules handles GET /api/admin/urls/{code}/rules
+func (h *URLHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.Service.ListRules(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		writeURLError(w, err)
+		return
+	}
+	if rules == nil {
+		rules = []service.RedirectRule{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// CreateRule handles POST /api/admin/urls/{code}/rules
+func (h *URLHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := decodeRule(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.Service.CreateRule(r.Context(), chi.URLParam(r, "code"), rule)
+	if err != nil {
+		writeURLError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// UpdateRule handles PUT /api/admin/urls/{code}/rules/{id}
+func (h *URLHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid rule ID", http.StatusBadRequest)
+		return
+	}
+	rule, ok := decodeRule(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.Service.UpdateRule(r.Context(), chi.URLParam(r, "code"), int32(id), rule)
+	if err != nil {
+		writeURLError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteRule handles DELETE /api/admin/urls/{code}/rules/{id}
+func (h *URLHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid rule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.DeleteRule(r.Context(), chi.URLParam(r, "code"), int32(id)); err != nil {
+		writeURLError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Rule deleted successfully"})
+}
+
+func decodeRule(w http.ResponseWriter, r *http.Request) (service.RedirectRule, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, 10<<10)
+
+	var rule service.RedirectRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return rule, false
+	}
+	return rule, true
+}
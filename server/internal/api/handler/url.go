@@ -3,29 +3,160 @@ package handler
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"html/template"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"go-shortener-sqlc/internal/auth"
 	"go-shortener-sqlc/internal/service"
-	"go-shortener-sqlc/internal/utils"
 )
 
+// badRequestErrors are service errors caused by invalid client input.
+var badRequestErrors = []error{
+	service.ErrURLRequired,
+	service.ErrURLTooLong,
+	service.ErrInvalidURL,
+	service.ErrUnsafeURL,
+	service.ErrInvalidAlias,
+	service.ErrReservedAlias,
+	service.ErrExpiryInPast,
+	service.ErrInvalidMaxClicks,
+	service.ErrInvalidPassword,
+	service.ErrInvalidRedirectMode,
+	service.ErrRuleNoConditions,
+	service.ErrRuleOS,
+	service.ErrRuleDevice,
+	service.ErrRuleLanguage,
+	service.ErrRuleCountry,
+	service.ErrRuleTimeWindow,
+	service.ErrTooManyVariants,
+	service.ErrVariantLabel,
+	service.ErrVariantWeight,
+	service.ErrInvalidUTM,
+	service.ErrURLBlocked,
+	service.ErrBlocklistKind,
+	service.ErrBlocklistPattern,
+	service.ErrBlocklistReason,
+	service.ErrReportReason,
+	service.ErrReportStatus,
+	service.ErrInvalidDomain,
+	service.ErrUnknownDomain,
+	service.ErrQRFormat,
+	service.ErrQRColor,
+	service.ErrQRErrorCorrection,
+	service.ErrQRQuietZone,
+	service.ErrQRSize,
+	service.ErrQRDPI,
+	service.ErrQRLogoTooLarge,
+	service.ErrQRModuleShape,
+	service.ErrQRFinderShape,
+	service.ErrQRGradient,
+	service.ErrQRGradientType,
+	service.ErrQRGradientAngle,
+	service.ErrQRGradientCenter,
+	service.ErrQRTemplateName,
+	service.ErrQRTemplateLogo,
+	service.ErrQRTemplateLogoSize,
+	service.ErrQRTemplateNotFound,
+}
+
+func isBadRequest(err error) bool {
+	for _, target := range badRequestErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
 type URLHandler struct {
 	Service *service.URLService
+	Clicks  *service.ClickService
+
+	// TrackingHTML is injected into the preview and meta-refresh pages (e.g. analytics pixels)
+	TrackingHTML template.HTML
 }
 
-func NewURLHandler(s *service.URLService) *URLHandler {
-	return &URLHandler{Service: s}
+func NewURLHandler(s *service.URLService, clicks *service.ClickService) *URLHandler {
+	return &URLHandler{Service: s, Clicks: clicks}
 }
 
 type ShortenRequest struct {
-	URL string `json:"url"`
+	URL          string     `json:"url"`
+	Alias        string     `json:"alias,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"` // RFC 3339
+	MaxClicks    *uint32    `json:"max_clicks,omitempty"`
+	Password     string     `json:"password,omitempty"`
+	RedirectMode string     `json:"redirect_mode,omitempty"` // 301, 302 (default), 307, 308, preview, meta
+
+	Variants []service.Variant `json:"variants,omitempty"` // weighted destinations replacing url on redirect
+	Sticky   bool              `json:"sticky,omitempty"`
+
+	// Campaign tags merged into url as utm_* parameters
+	UTMSource   string `json:"utm_source,omitempty"`
+	UTMMedium   string `json:"utm_medium,omitempty"`
+	UTMCampaign string `json:"utm_campaign,omitempty"`
+	UTMTerm     string `json:"utm_term,omitempty"`
+	UTMContent  string `json:"utm_content,omitempty"`
+
+	ForwardQuery bool `json:"forward_query,omitempty"` // append /{code}?... parameters to the destination
+
+	Domain string `json:"domain,omitempty"` // registered branded domain; default domain when empty
+}
+
+func (req ShortenRequest) utm() service.UTM {
+	return service.UTM{
+		Source:   req.UTMSource,
+		Medium:   req.UTMMedium,
+		Campaign: req.UTMCampaign,
+		Term:     req.UTMTerm,
+		Content:  req.UTMContent,
+	}
 }
 
+// ShortenResponse describes the new link (201 Created) or the existing plain link it
+// was deduplicated against (200 OK, reused).
 type ShortenResponse struct {
-	ShortCode string `json:"short_code"`
+	ShortCode   string     `json:"short_code"`
+	ShortURL    string     `json:"short_url"`
+	Domain      string     `json:"domain"` // "" for the default domain
+	OriginalURL string     `json:"original_url"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	MaxClicks   *int32     `json:"max_clicks"`
+	OwnerID     *string    `json:"owner_id"`
+	Reused      bool       `json:"reused"`
+	QRURL       string     `json:"qr_url"`              // POST to render a QR code
+	StatsURL    string     `json:"stats_url,omitempty"` // owned links only; needs the owner's session or a urls:read key
+}
+
+func (h *URLHandler) newShortenResponse(link *service.ShortenResult) ShortenResponse {
+	resp := ShortenResponse{
+		ShortCode:   link.ShortCode,
+		ShortURL:    link.ShortURL,
+		Domain:      link.Domain,
+		OriginalURL: link.OriginalURL,
+		CreatedAt:   link.CreatedAt,
+		ExpiresAt:   link.ExpiresAt,
+		MaxClicks:   link.MaxClicks,
+		OwnerID:     link.OwnerID,
+		Reused:      link.Reused,
+		QRURL:       link.ShortURL + "/qr",
+	}
+	if link.OwnerID != nil {
+		resp.StatsURL = strings.TrimSuffix(h.Service.BaseURL, "/") + "/api/me/urls/" + link.ShortCode + "/stats"
+		if link.Domain != "" {
+			resp.StatsURL += "?domain=" + url.QueryEscape(link.Domain)
+		}
+	}
+	return resp
 }
 
 func (h *URLHandler) ShortenURL(w http.ResponseWriter, r *http.Request) {
@@ -42,57 +173,366 @@ func (h *URLHandler) ShortenURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.URL == "" {
-		http.Error(w, "URL is required", http.StatusBadRequest)
+	params := service.ShortenParams{
+		URL:          req.URL,
+		Alias:        req.Alias,
+		ExpiresAt:    req.ExpiresAt,
+		MaxClicks:    req.MaxClicks,
+		Password:     req.Password,
+		RedirectMode: req.RedirectMode,
+		Variants:     req.Variants,
+		Sticky:       req.Sticky,
+		UTM:          req.utm(),
+		ForwardQuery: req.ForwardQuery,
+		Domain:       req.Domain,
+	}
+	// Logged-in users own the links they create; anonymous links have no owner
+	if claims := auth.FromContext(r.Context()); claims != nil {
+		params.UserID = claims.UserID
+	}
+
+	link, err := h.Service.Shorten(r.Context(), params)
+	if err != nil {
+		switch {
+		case isBadRequest(err):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrAliasTaken):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
 		return
 	}
 
-	// Limit URL length to 2048 characters
-	if len(req.URL) > 2048 {
-		http.Error(w, "URL too long (max 2048 chars)", http.StatusBadRequest)
+	status := http.StatusCreated
+	if link.Reused {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(h.newShortenResponse(link))
+}
+
+func (h *URLHandler) RedirectURL(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	if code == "" {
+		http.Error(w, "Short code is required", http.StatusBadRequest)
 		return
 	}
 
-	// Basic URL validation
-	parsedURL, err := url.ParseRequestURI(req.URL)
-	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
-		http.Error(w, "Invalid URL format. Must start with http:// or https://", http.StatusBadRequest)
+	resolved, err := h.Service.GetOriginalURL(r.Context(), code, visitorFromRequest(r))
+	if err != nil {
+		if errors.Is(err, service.ErrPasswordRequired) {
+			renderPasswordForm(w, http.StatusOK, "")
+			return
+		}
+		writeRedirectError(w, err)
 		return
 	}
 
-	// SSRF Protection
-	if err := utils.ValidateTargetURL(req.URL); err != nil {
-		http.Error(w, "Unsafe URL destination (Private IPs not allowed)", http.StatusBadRequest)
+	h.redirect(w, r, resolved)
+}
+
+// UnlockURL handles POST /{code} from the password form of a protected link.
+func (h *URLHandler) UnlockURL(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 4<<10)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
 		return
 	}
 
-	shortCode, err := h.Service.Shorten(r.Context(), req.URL)
+	resolved, err := h.Service.UnlockURL(r.Context(), chi.URLParam(r, "code"), r.PostFormValue("password"), visitorFromRequest(r))
 	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrWrongPassword):
+			renderPasswordForm(w, http.StatusUnauthorized, "Incorrect password.")
+		case errors.Is(err, service.ErrTooManyAttempts):
+			renderPasswordForm(w, http.StatusTooManyRequests, "Too many incorrect attempts. Try again later.")
+		default:
+			writeRedirectError(w, err)
+		}
+		return
+	}
+
+	h.redirect(w, r, resolved)
+}
+
+// redirect records the click and sends the visitor to the destination using the link's redirect mode.
+func (h *URLHandler) redirect(w http.ResponseWriter, r *http.Request, resolved *service.ResolvedURL) {
+	// Queued in memory; the DB write happens in a background batch
+	h.Clicks.Record(service.ClickEvent{
+		URLID:     resolved.ID,
+		ClickedAt: time.Now(),
+		Referrer:  r.Referer(),
+		UserAgent: r.UserAgent(),
+		IP:        clientIP(r),
+		Variant:   resolved.Variant,
+	})
+
+	if resolved.Sticky && resolved.Variant != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     variantCookieName(chi.URLParam(r, "code")),
+			Value:    resolved.Variant,
+			Path:     r.URL.Path,
+			MaxAge:   int(variantCookieMaxAge / time.Second),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	dest := resolved.OriginalURL
+	if resolved.ForwardQuery && r.URL.RawQuery != "" {
+		dest = service.MergeQuery(dest, r.URL.Query())
+	}
+
+	switch resolved.RedirectMode {
+	case service.RedirectPreview:
+		renderRedirectPage(w, previewPageTmpl, dest, h.TrackingHTML)
+	case service.RedirectMeta:
+		renderRedirectPage(w, metaRefreshPageTmpl, dest, h.TrackingHTML)
+	default:
+		status := redirectStatus(resolved.RedirectMode)
+		if r.Method == http.MethodPost {
+			// After the password form: 303 so the browser follows with a GET instead of re-posting
+			status = http.StatusSeeOther
+		}
+		http.Redirect(w, r, dest, status)
+	}
+}
+
+// redirectStatus maps a numeric redirect mode to its HTTP status (302 for anything else).
+func redirectStatus(mode string) int {
+	switch mode {
+	case service.RedirectMovedPermanently:
+		return http.StatusMovedPermanently
+	case service.RedirectTemporary:
+		return http.StatusTemporaryRedirect
+	case service.RedirectPermanent:
+		return http.StatusPermanentRedirect
+	default:
+		return http.StatusFound
+	}
+}
+
+// writeRedirectError maps errors from resolving a short code to HTTP responses.
+func writeRedirectError(w http.ResponseWriter, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		http.Error(w, "URL not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrLinkExpired), errors.Is(err, service.ErrLinkExhausted),
+		errors.Is(err, service.ErrLinkDisabled), errors.Is(err, service.ErrURLBlocked):
+		http.Error(w, err.Error(), http.StatusGone)
+	default:
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// clientIP extracts the caller's IP from RemoteAddr (same source httprate.KeyByIP uses).
+func clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// variantCookieMaxAge is how long a visitor stays on the variant of a sticky link.
+const variantCookieMaxAge = 30 * 24 * time.Hour
+
+func variantCookieName(code string) string {
+	return "v_" + code
+}
+
+// visitorFromRequest collects the request data redirect rules and variants are chosen by.
+func visitorFromRequest(r *http.Request) service.Visitor {
+	v := service.Visitor{
+		UserAgent:      r.UserAgent(),
+		AcceptLanguage: r.Header.Get("Accept-Language"),
+		IP:             clientIP(r),
+	}
+	if cookie, err := r.Cookie(variantCookieName(chi.URLParam(r, "code"))); err == nil {
+		v.Variant = cookie.Value
+	}
+	return v
+}
+
+// --- Admin ---
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// CacheStats handles GET /api/admin/cache/stats: hit counters of the redirect cache
+// on this instance since startup.
+func (h *URLHandler) CacheStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Service.CacheStats())
+}
+
+// ListURLs handles GET /api/admin/urls?q=&page=&limit=
+func (h *URLHandler) ListURLs(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+
+	result, err := h.Service.ListURLs(r.Context(), r.URL.Query().Get("q"), page, limit)
+	if err != nil {
+		http.Error(w, "Failed to list URLs", http.StatusInternalServerError)
 		return
 	}
 
-	resp := ShortenResponse{ShortCode: shortCode}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	json.NewEncoder(w).Encode(result)
 }
 
-func (h *URLHandler) RedirectURL(w http.ResponseWriter, r *http.Request) {
+// GetURL handles GET /api/admin/urls/{code}
+func (h *URLHandler) GetURL(w http.ResponseWriter, r *http.Request) {
+	result, err := h.Service.GetURLDetails(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		writeURLError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// UpdateURLRequest is the request body for editing a short URL. Omitted fields are left unchanged.
+type UpdateURLRequest struct {
+	URL          string  `json:"url,omitempty"`
+	RedirectMode *string `json:"redirect_mode,omitempty"`
+	ForwardQuery *bool   `json:"forward_query,omitempty"`
+}
+
+// UpdateURL handles PUT /api/admin/urls/{code}
+func (h *URLHandler) UpdateURL(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 10<<10)
+
+	var req UpdateURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.URL == "" && req.RedirectMode == nil && req.ForwardQuery == nil {
+		http.Error(w, "url, redirect_mode or forward_query is required", http.StatusBadRequest)
+		return
+	}
+	// Validate everything before the first write so a bad field changes nothing
+	if req.RedirectMode != nil {
+		if err := service.ValidateRedirectMode(*req.RedirectMode); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	code := chi.URLParam(r, "code")
-	if code == "" {
-		http.Error(w, "Short code is required", http.StatusBadRequest)
+	var (
+		result *service.URLDetails
+		err    error
+	)
+	if req.URL != "" {
+		result, err = h.Service.UpdateDestination(r.Context(), code, req.URL)
+	}
+	if err == nil && req.RedirectMode != nil {
+		result, err = h.Service.SetRedirectMode(r.Context(), code, *req.RedirectMode)
+	}
+	if err == nil && req.ForwardQuery != nil {
+		result, err = h.Service.SetForwardQuery(r.Context(), code, *req.ForwardQuery)
+	}
+	if err != nil {
+		writeURLError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// DisableURL handles POST /api/admin/urls/{code}/disable
+func (h *URLHandler) DisableURL(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(w, r, true)
+}
+
+// EnableURL handles POST /api/admin/urls/{code}/enable
+func (h *URLHandler) EnableURL(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(w, r, false)
+}
+
+func (h *URLHandler) setDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	result, err := h.Service.SetDisabled(r.Context(), chi.URLParam(r, "code"), disabled)
+	if err != nil {
+		writeURLError(w, err)
 		return
 	}
 
-	originalURL, err := h.Service.GetOriginalURL(r.Context(), code)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// DeleteURL handles DELETE /api/admin/urls/{code}
+func (h *URLHandler) DeleteURL(w http.ResponseWriter, r *http.Request) {
+	if err := h.Service.DeleteURL(r.Context(), chi.URLParam(r, "code")); err != nil {
+		writeURLError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "URL deleted successfully"})
+}
+
+// --- My Links ---
+
+// ListMyURLs handles GET /api/me/urls?page=&limit=
+func (h *URLHandler) ListMyURLs(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+
+	result, err := h.Service.ListUserURLs(r.Context(), auth.FromContext(r.Context()).UserID, page, limit)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			http.Error(w, "URL not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
+		http.Error(w, "Failed to list URLs", http.StatusInternalServerError)
 		return
 	}
 
-	http.Redirect(w, r, originalURL, http.StatusFound)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// OwnerOnly lets the wrapped {code} handler run only for the link's owner.
+// Links owned by someone else are reported as not found.
+func (h *URLHandler) OwnerOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := auth.FromContext(r.Context())
+		if claims == nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err := h.Service.CheckOwner(r.Context(), chi.URLParam(r, "code"), claims.UserID); err != nil {
+			writeURLError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeURLError maps errors from URL management calls to HTTP responses.
+func writeURLError(w http.ResponseWriter, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		http.Error(w, "URL not found", http.StatusNotFound)
+	case isBadRequest(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// parsePagination reads ?page= (1-based) and ?limit=, applying defaults and bounds.
+func parsePagination(r *http.Request) (page, limit int) {
+	page, limit = 1, defaultPageSize
+	if val, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && val > 0 {
+		page = val
+	}
+	if val, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && val > 0 {
+		limit = min(val, maxPageSize)
+	}
+	return page, limit
 }
@@ -6,18 +6,57 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"go-shortener-sqlc/internal/auth"
 	"go-shortener-sqlc/internal/db"
 	"go-shortener-sqlc/internal/service"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-chi/chi/v5"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// testURL uses an IP literal so SSRF validation does not need DNS.
+const testURL = "https://93.184.215.14"
+
+func urlRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "short_code", "original_url", "url_hash", "is_custom",
+		"expires_at", "max_clicks", "click_count", "disabled", "user_id", "password_hash", "redirect_mode", "sticky", "forward_query", "domain", "created_at", "updated_at"})
+}
+
+func ruleRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "url_id", "position", "destination", "os", "devices",
+		"languages", "countries", "starts_at", "ends_at", "created_at"})
+}
+
+func variantRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "url_id", "position", "label", "destination", "weight", "created_at"})
+}
+
+// blockedURL is on the blocklist returned by newTestBlocklist.
+const blockedURL = "https://93.184.215.15/login"
+
+// newTestBlocklist returns a file-backed blocklist containing blockedURL's host.
+func newTestBlocklist(t *testing.T) *service.Blocklist {
+	t.Helper()
+	feed := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(feed, []byte("0.0.0.0 93.184.215.15\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	b := service.NewBlocklist(nil, feed)
+	t.Cleanup(b.Close)
+	return b
+}
+
 func TestShortenURL(t *testing.T) {
 	// Initialize mock db
 	mockDB, mock, err := sqlmock.New()
@@ -28,31 +67,78 @@ func TestShortenURL(t *testing.T) {
 
 	// Create dependencies
 	queries := db.New(mockDB)
-	urlService := service.NewURLService(queries, nil)
-	handler := NewURLHandler(urlService)
+	urlService := service.NewURLService(mockDB, queries, nil, nil)
+	urlService.Blocklist = newTestBlocklist(t)
+	handler := NewURLHandler(urlService, nil)
+
+	tomorrow := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	yesterday := time.Now().Add(-24 * time.Hour).UTC().Truncate(time.Second)
+	var zero uint32
+	tooMany := uint32(math.MaxInt32 + 1)
 
 	tests := []struct {
 		name           string
 		body           interface{}
+		claims         *auth.Claims
 		mockBehavior   func()
 		expectedStatus int
 	}{
 		{
 			name: "Success",
-			body: ShortenRequest{URL: "https://example.com"},
+			body: ShortenRequest{URL: testURL},
 			mockBehavior: func() {
+				// Expect dedup lookup (GetURLByHash returns no rows)
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE url_hash").
+					WithArgs(sqlmock.AnyArg(), nil, "").
+					WillReturnError(sql.ErrNoRows)
+
 				// Expect check for collision (GetURL returns no rows)
-				mock.ExpectQuery("SELECT id, short_code, original_url, created_at FROM urls").
-					WithArgs(sqlmock.AnyArg()).
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", sqlmock.AnyArg()).
 					WillReturnError(sql.ErrNoRows)
 
 				// Expect insertion
 				mock.ExpectExec("INSERT INTO urls").
-					WithArgs(sqlmock.AnyArg(), "https://example.com").
+					WithArgs(sqlmock.AnyArg(), testURL, sqlmock.AnyArg(), false, nil, nil, nil, nil, "302", false, "").
 					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				// The new link is read back for the response
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", sqlmock.AnyArg()).
+					WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Existing URL",
+			body: ShortenRequest{URL: testURL},
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE url_hash").
+					WithArgs(sqlmock.AnyArg(), nil, "").
+					WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
 			},
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name: "Existing URL With Rules Is Not Reused",
+			body: ShortenRequest{URL: testURL},
+			mockBehavior: func() {
+				// A link that gained redirect rules after creation is excluded from dedup
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE url_hash (.+) NOT EXISTS \\(SELECT 1 FROM url_rules").
+					WithArgs(sqlmock.AnyArg(), nil, "").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", sqlmock.AnyArg()).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectExec("INSERT INTO urls").
+					WithArgs(sqlmock.AnyArg(), testURL, sqlmock.AnyArg(), false, nil, nil, nil, nil, "302", false, "").
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", sqlmock.AnyArg()).
+					WillReturnRows(urlRows().AddRow(2, "ghijkl", testURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+			},
+			expectedStatus: http.StatusCreated,
+		},
 		{
 			name:           "Invalid Body",
 			body:           "invalid json",
@@ -73,19 +159,220 @@ func TestShortenURL(t *testing.T) {
 		},
 		{
 			name: "Database Error",
-			body: ShortenRequest{URL: "https://example.com"},
+			body: ShortenRequest{URL: testURL},
 			mockBehavior: func() {
-				// Expect collision check first
-				mock.ExpectQuery("SELECT id, short_code, original_url, created_at FROM urls").
-					WithArgs(sqlmock.AnyArg()).
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE url_hash").
+					WithArgs(sqlmock.AnyArg(), nil, "").
+					WillReturnError(sql.ErrNoRows)
+
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", sqlmock.AnyArg()).
 					WillReturnError(sql.ErrNoRows)
 
 				mock.ExpectExec("INSERT INTO urls").
-					WithArgs(sqlmock.AnyArg(), "https://example.com").
+					WithArgs(sqlmock.AnyArg(), testURL, sqlmock.AnyArg(), false, nil, nil, nil, nil, "302", false, "").
 					WillReturnError(errors.New("db error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name: "Custom Alias",
+			body: ShortenRequest{URL: testURL, Alias: "my-launch"},
+			mockBehavior: func() {
+				// No dedup lookup: an alias always gets its own row
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "my-launch").
+					WillReturnError(sql.ErrNoRows)
+
+				mock.ExpectExec("INSERT INTO urls").
+					WithArgs("my-launch", testURL, sqlmock.AnyArg(), true, nil, nil, nil, nil, "302", false, "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "my-launch").
+					WillReturnRows(urlRows().AddRow(1, "my-launch", testURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Alias Taken",
+			body: ShortenRequest{URL: testURL, Alias: "my-launch"},
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "my-launch").
+					WillReturnRows(urlRows().AddRow(1, "my-launch", "https://other.example", "hash", true, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "Logged In User Owns Link",
+			body:   ShortenRequest{URL: testURL},
+			claims: &auth.Claims{UserID: "user-1", Role: "user"},
+			mockBehavior: func() {
+				// Dedup only matches links of the same owner
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE url_hash").
+					WithArgs(sqlmock.AnyArg(), "user-1", "").
+					WillReturnError(sql.ErrNoRows)
+
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", sqlmock.AnyArg()).
+					WillReturnError(sql.ErrNoRows)
+
+				mock.ExpectExec("INSERT INTO urls").
+					WithArgs(sqlmock.AnyArg(), testURL, sqlmock.AnyArg(), false, nil, nil, "user-1", nil, "302", false, "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", sqlmock.AnyArg()).
+					WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, "user-1", nil, "302", false, false, "", time.Now(), time.Now()))
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Expiring Link Skips Dedup",
+			body: ShortenRequest{URL: testURL, ExpiresAt: &tomorrow},
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", sqlmock.AnyArg()).
+					WillReturnError(sql.ErrNoRows)
+
+				mock.ExpectExec("INSERT INTO urls").
+					WithArgs(sqlmock.AnyArg(), testURL, sqlmock.AnyArg(), false, tomorrow, nil, nil, nil, "302", false, "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", sqlmock.AnyArg()).
+					WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Expiry In Past",
+			body:           ShortenRequest{URL: testURL, ExpiresAt: &yesterday},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Zero Max Clicks",
+			body:           ShortenRequest{URL: testURL, MaxClicks: &zero},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Max Clicks Out Of Range",
+			body:           ShortenRequest{URL: testURL, MaxClicks: &tooMany},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Reserved Alias",
+			body:           ShortenRequest{URL: testURL, Alias: "Healthz"},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Blocklisted Destination",
+			body:           ShortenRequest{URL: blockedURL},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Blocklisted Variant",
+			body:           ShortenRequest{URL: testURL, Variants: []service.Variant{{URL: testURL, Weight: 1}, {URL: blockedURL, Weight: 1}}},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Redirect Mode",
+			body:           ShortenRequest{URL: testURL, RedirectMode: "303"},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "UTM Tags",
+			body: ShortenRequest{URL: testURL + "/page?ref=home&utm_source=old", UTMSource: "news", UTMCampaign: "spring"},
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE url_hash").
+					WithArgs(sqlmock.AnyArg(), nil, "").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", sqlmock.AnyArg()).
+					WillReturnError(sql.ErrNoRows)
+
+				// Tags are merged into the stored destination, replacing existing utm_source
+				mock.ExpectExec("INSERT INTO urls").
+					WithArgs(sqlmock.AnyArg(), testURL+"/page?ref=home&utm_campaign=spring&utm_source=news",
+						sqlmock.AnyArg(), false, nil, nil, nil, nil, "302", false, "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", sqlmock.AnyArg()).
+					WillReturnRows(urlRows().AddRow(1, "abcdef", testURL + "/page?ref=home&utm_campaign=spring&utm_source=news", "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Weighted Variants",
+			body: ShortenRequest{URL: testURL, Sticky: true, Variants: []service.Variant{
+				{URL: testURL + "/a", Weight: 70},
+				{URL: testURL + "/b", Weight: 30},
+			}},
+			mockBehavior: func() {
+				// Never deduplicated
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", sqlmock.AnyArg()).
+					WillReturnError(sql.ErrNoRows)
+
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO urls").
+					WillReturnResult(sqlmock.NewResult(7, 1))
+				mock.ExpectExec("DELETE FROM url_variants").
+					WithArgs(7).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO url_variants").
+					WithArgs(7, 0, "A", testURL+"/a", 70).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO url_variants").
+					WithArgs(7, 1, "B", testURL+"/b", 30).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectExec("UPDATE urls SET sticky").
+					WithArgs(true, 7).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", sqlmock.AnyArg()).
+					WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Duplicate Variant Labels",
+			body: ShortenRequest{URL: testURL, Variants: []service.Variant{
+				{Label: "x", URL: testURL + "/a", Weight: 1},
+				{Label: "X", URL: testURL + "/b", Weight: 1},
+			}},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Password Too Short",
+			body:           ShortenRequest{URL: testURL, Password: "abc"},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Alias",
+			body:           ShortenRequest{URL: testURL, Alias: "a/b"},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unknown Domain",
+			body:           ShortenRequest{URL: testURL, Domain: "go.example.com"},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, tc := range tests {
@@ -100,6 +387,9 @@ func TestShortenURL(t *testing.T) {
 			}
 
 			req, _ := http.NewRequest("POST", "/shorten", bytes.NewBuffer(reqBody))
+			if tc.claims != nil {
+				req = req.WithContext(auth.NewContext(req.Context(), tc.claims))
+			}
 			rr := httptest.NewRecorder()
 
 			handler.ShortenURL(rr, req)
@@ -116,6 +406,81 @@ func TestShortenURL(t *testing.T) {
 	}
 }
 
+func TestShortenResponse(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	urlService := service.NewURLService(mockDB, db.New(mockDB), nil, nil)
+	urlService.BaseURL = "https://sho.rt"
+	handler := NewURLHandler(urlService, nil)
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	shorten := func(claims *auth.Claims) (int, ShortenResponse) {
+		body, _ := json.Marshal(ShortenRequest{URL: testURL})
+		req, _ := http.NewRequest("POST", "/shorten", bytes.NewBuffer(body))
+		if claims != nil {
+			req = req.WithContext(auth.NewContext(req.Context(), claims))
+		}
+		rr := httptest.NewRecorder()
+		handler.ShortenURL(rr, req)
+
+		var resp ShortenResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("invalid response body: %v", err)
+		}
+		return rr.Code, resp
+	}
+
+	t.Run("Created", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM urls WHERE url_hash").
+			WithArgs(sqlmock.AnyArg(), "user-1", "").
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+			WithArgs("", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectExec("INSERT INTO urls").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+			WithArgs("", sqlmock.AnyArg()).
+			WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, "user-1", nil, "302", false, false, "", created, created))
+
+		status, resp := shorten(&auth.Claims{UserID: "user-1", Role: "user"})
+		if status != http.StatusCreated {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+		}
+		if resp.ShortURL != "https://sho.rt/abcdef" || resp.OriginalURL != testURL || !resp.CreatedAt.Equal(created) || resp.Reused {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+		if resp.OwnerID == nil || *resp.OwnerID != "user-1" {
+			t.Errorf("owner_id = %v, want user-1", resp.OwnerID)
+		}
+		if resp.QRURL != "https://sho.rt/abcdef/qr" || resp.StatsURL != "https://sho.rt/api/me/urls/abcdef/stats" {
+			t.Errorf("qr_url = %q, stats_url = %q", resp.QRURL, resp.StatsURL)
+		}
+	})
+
+	t.Run("Reused", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM urls WHERE url_hash").
+			WithArgs(sqlmock.AnyArg(), nil, "").
+			WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", created, created))
+
+		status, resp := shorten(nil)
+		if status != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		if !resp.Reused || resp.ShortCode != "abcdef" || resp.OwnerID != nil || resp.StatsURL != "" {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func TestRedirectURL(t *testing.T) {
 	// Initialize mock db
 	mockDB, mock, err := sqlmock.New()
@@ -125,35 +490,121 @@ func TestRedirectURL(t *testing.T) {
 	defer mockDB.Close()
 
 	queries := db.New(mockDB)
-	urlService := service.NewURLService(queries, nil)
-	handler := NewURLHandler(urlService)
+	urlService := service.NewURLService(mockDB, queries, nil, nil)
+	urlService.Blocklist = newTestBlocklist(t)
+	handler := NewURLHandler(urlService, nil)
 
 	tests := []struct {
 		name           string
 		shortCode      string
+		userAgent      string
+		acceptLanguage string
+		query          string
+		cookie         *http.Cookie
 		mockBehavior   func()
 		expectedStatus int
 		expectedLoc    string
+		expectedBody   string
 	}{
 		{
 			name:      "Success",
 			shortCode: "abcdef",
 			mockBehavior: func() {
-				rows := sqlmock.NewRows([]string{"id", "short_code", "original_url", "created_at"}).
-					AddRow(1, "abcdef", "https://example.com", time.Now())
-				mock.ExpectQuery("SELECT id, short_code, original_url, created_at FROM urls").
-					WithArgs("abcdef").
+				rows := urlRows().AddRow(1, "abcdef", "https://example.com", "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "abcdef").
+					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT (.+) FROM url_rules").
+					WithArgs(1).
+					WillReturnRows(ruleRows())
+				mock.ExpectQuery("SELECT (.+) FROM url_variants").
+					WithArgs(1).
+					WillReturnRows(variantRows())
+			},
+			expectedStatus: http.StatusFound,
+			expectedLoc:    "https://example.com",
+		},
+		{
+			name:      "Expired",
+			shortCode: "expired",
+			mockBehavior: func() {
+				rows := urlRows().AddRow(2, "expired", "https://example.com", "hash", false,
+					time.Now().Add(-time.Hour), nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "expired").
+					WillReturnRows(rows)
+			},
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:      "Click Limited",
+			shortCode: "limited",
+			mockBehavior: func() {
+				rows := urlRows().AddRow(3, "limited", "https://example.com", "hash", false, nil, 5, 4, false, nil, nil, "302", false, false, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "limited").
 					WillReturnRows(rows)
+				mock.ExpectExec("UPDATE urls SET click_count").
+					WithArgs(3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT (.+) FROM url_rules").
+					WithArgs(3).
+					WillReturnRows(ruleRows())
+				mock.ExpectQuery("SELECT (.+) FROM url_variants").
+					WithArgs(3).
+					WillReturnRows(variantRows())
 			},
 			expectedStatus: http.StatusFound,
 			expectedLoc:    "https://example.com",
 		},
+		{
+			name:      "Click Limit Reached",
+			shortCode: "exhausted",
+			mockBehavior: func() {
+				rows := urlRows().AddRow(4, "exhausted", "https://example.com", "hash", false, nil, 5, 5, false, nil, nil, "302", false, false, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "exhausted").
+					WillReturnRows(rows)
+				mock.ExpectExec("UPDATE urls SET click_count").
+					WithArgs(4).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:      "Blocklisted Destination",
+			shortCode: "blocked",
+			mockBehavior: func() {
+				rows := urlRows().AddRow(6, "blocked", blockedURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "blocked").
+					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT (.+) FROM url_rules").
+					WithArgs(6).
+					WillReturnRows(ruleRows())
+				mock.ExpectQuery("SELECT (.+) FROM url_variants").
+					WithArgs(6).
+					WillReturnRows(variantRows())
+			},
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:      "Disabled",
+			shortCode: "disabled",
+			mockBehavior: func() {
+				rows := urlRows().AddRow(5, "disabled", "https://example.com", "hash", false, nil, nil, 0, true, nil, nil, "302", false, false, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "disabled").
+					WillReturnRows(rows)
+			},
+			expectedStatus: http.StatusGone,
+		},
 		{
 			name:      "Not Found",
 			shortCode: "notfound",
 			mockBehavior: func() {
-				mock.ExpectQuery("SELECT id, short_code, original_url, created_at FROM urls").
-					WithArgs("notfound").
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "notfound").
 					WillReturnError(sql.ErrNoRows)
 			},
 			expectedStatus: http.StatusNotFound,
@@ -162,19 +613,195 @@ func TestRedirectURL(t *testing.T) {
 			name:      "Database Error",
 			shortCode: "dberror",
 			mockBehavior: func() {
-				mock.ExpectQuery("SELECT id, short_code, original_url, created_at FROM urls").
-					WithArgs("dberror").
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "dberror").
 					WillReturnError(errors.New("db error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name:      "Password Protected",
+			shortCode: "secret",
+			mockBehavior: func() {
+				rows := urlRows().AddRow(6, "secret", "https://example.com", "hash", false, nil, nil, 0, false, nil, "$2a$04$hash", "302", false, false, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "secret").
+					WillReturnRows(rows)
+			},
+			// Interstitial form instead of a redirect
+			expectedStatus: http.StatusOK,
+			expectedBody:   `name="password"`,
+		},
+		{
+			name:      "Permanent Redirect",
+			shortCode: "moved",
+			mockBehavior: func() {
+				rows := urlRows().AddRow(7, "moved", "https://example.com", "hash", false, nil, nil, 0, false, nil, nil, "308", false, false, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "moved").
+					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT (.+) FROM url_rules").
+					WithArgs(7).
+					WillReturnRows(ruleRows())
+				mock.ExpectQuery("SELECT (.+) FROM url_variants").
+					WithArgs(7).
+					WillReturnRows(variantRows())
+			},
+			expectedStatus: http.StatusPermanentRedirect,
+			expectedLoc:    "https://example.com",
+		},
+		{
+			name:      "Preview Page",
+			shortCode: "peek",
+			mockBehavior: func() {
+				rows := urlRows().AddRow(8, "peek", "https://example.com/doc?a=1", "hash", false, nil, nil, 0, false, nil, nil, "preview", false, false, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "peek").
+					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT (.+) FROM url_rules").
+					WithArgs(8).
+					WillReturnRows(ruleRows())
+				mock.ExpectQuery("SELECT (.+) FROM url_variants").
+					WithArgs(8).
+					WillReturnRows(variantRows())
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `href="https://example.com/doc?a=1"`,
+		},
+		{
+			name:      "Meta Refresh Page",
+			shortCode: "pixel",
+			mockBehavior: func() {
+				rows := urlRows().AddRow(9, "pixel", "https://example.com", "hash", false, nil, nil, 0, false, nil, nil, "meta", false, false, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "pixel").
+					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT (.+) FROM url_rules").
+					WithArgs(9).
+					WillReturnRows(ruleRows())
+				mock.ExpectQuery("SELECT (.+) FROM url_variants").
+					WithArgs(9).
+					WillReturnRows(variantRows())
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `http-equiv="refresh" content="1;url=https://example.com"`,
+		},
+		{
+			name:      "Rule Match",
+			shortCode: "app",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+			mockBehavior: func() {
+				rows := urlRows().AddRow(10, "app", "https://example.com", "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "app").
+					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT (.+) FROM url_rules").
+					WithArgs(10).
+					WillReturnRows(ruleRows().
+						AddRow(1, 10, 0, "https://example.com/th", "", "", "th", "", nil, nil, time.Now()).
+						AddRow(2, 10, 1, "https://apps.apple.com/app", "iOS,Android", "mobile", "", "", nil, nil, time.Now()))
+				mock.ExpectQuery("SELECT (.+) FROM url_variants").
+					WithArgs(10).
+					WillReturnRows(variantRows())
+			},
+			expectedStatus: http.StatusFound,
+			expectedLoc:    "https://apps.apple.com/app",
+		},
+		{
+			name:      "Forward Query",
+			shortCode: "fwd",
+			query:     "utm_source=y&b=2",
+			mockBehavior: func() {
+				rows := urlRows().AddRow(14, "fwd", "https://example.com/p?a=1&utm_source=x", "hash", false, nil, nil, 0, false, nil, nil, "302", false, true, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "fwd").
+					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT (.+) FROM url_rules").
+					WithArgs(14).
+					WillReturnRows(ruleRows())
+				mock.ExpectQuery("SELECT (.+) FROM url_variants").
+					WithArgs(14).
+					WillReturnRows(variantRows())
+			},
+			expectedStatus: http.StatusFound,
+			expectedLoc:    "https://example.com/p?a=1&b=2&utm_source=y",
+		},
+		{
+			name:      "Sticky Variant",
+			shortCode: "ab",
+			cookie:    &http.Cookie{Name: "v_ab", Value: "B"},
+			mockBehavior: func() {
+				rows := urlRows().AddRow(12, "ab", "https://example.com", "hash", false, nil, nil, 0, false, nil, nil, "302", true, false, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "ab").
+					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT (.+) FROM url_rules").
+					WithArgs(12).
+					WillReturnRows(ruleRows())
+				mock.ExpectQuery("SELECT (.+) FROM url_variants").
+					WithArgs(12).
+					WillReturnRows(variantRows().
+						AddRow(1, 12, 0, "A", "https://example.com/a", 70, time.Now()).
+						AddRow(2, 12, 1, "B", "https://example.com/b", 30, time.Now()))
+			},
+			expectedStatus: http.StatusFound,
+			expectedLoc:    "https://example.com/b",
+		},
+		{
+			name:      "Weighted Variant",
+			shortCode: "rot",
+			mockBehavior: func() {
+				rows := urlRows().AddRow(13, "rot", "https://example.com", "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "rot").
+					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT (.+) FROM url_rules").
+					WithArgs(13).
+					WillReturnRows(ruleRows())
+				// A paused variant (weight 0) is never served
+				mock.ExpectQuery("SELECT (.+) FROM url_variants").
+					WithArgs(13).
+					WillReturnRows(variantRows().
+						AddRow(1, 13, 0, "A", "https://example.com/a", 0, time.Now()).
+						AddRow(2, 13, 1, "B", "https://example.com/b", 1, time.Now()))
+			},
+			expectedStatus: http.StatusFound,
+			expectedLoc:    "https://example.com/b",
+		},
+		{
+			name:           "Rule Fallback",
+			shortCode:      "app2",
+			userAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			acceptLanguage: "en-US,en;q=0.9,th;q=0.8",
+			mockBehavior: func() {
+				rows := urlRows().AddRow(11, "app2", "https://example.com", "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "app2").
+					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT (.+) FROM url_rules").
+					WithArgs(11).
+					WillReturnRows(ruleRows().
+						AddRow(1, 11, 0, "https://example.com/th", "", "", "th", "", nil, nil, time.Now()).
+						AddRow(2, 11, 1, "https://example.com/sale", "", "", "", "", time.Now().Add(time.Hour), nil, time.Now()))
+				mock.ExpectQuery("SELECT (.+) FROM url_variants").
+					WithArgs(11).
+					WillReturnRows(variantRows())
+			},
+			expectedStatus: http.StatusFound,
+			expectedLoc:    "https://example.com",
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.mockBehavior()
 
-			req, _ := http.NewRequest("GET", "/"+tc.shortCode, nil)
+			req, _ := http.NewRequest("GET", "/"+tc.shortCode+"?"+tc.query, nil)
+			req.Header.Set("User-Agent", tc.userAgent)
+			req.Header.Set("Accept-Language", tc.acceptLanguage)
+			if tc.cookie != nil {
+				req.AddCookie(tc.cookie)
+			}
 
 			// Setup chi context for URL param
 			rctx := chi.NewRouteContext()
@@ -190,7 +817,7 @@ func TestRedirectURL(t *testing.T) {
 					rr.Code, tc.expectedStatus)
 			}
 
-			if tc.expectedStatus == http.StatusFound {
+			if tc.expectedLoc != "" {
 				loc := rr.Header().Get("Location")
 				if loc != tc.expectedLoc {
 					t.Errorf("handler returned wrong location: got %v want %v",
@@ -198,9 +825,303 @@ func TestRedirectURL(t *testing.T) {
 				}
 			}
 
+			if tc.expectedBody != "" && !strings.Contains(rr.Body.String(), tc.expectedBody) {
+				t.Errorf("response body does not contain %q:\n%s", tc.expectedBody, rr.Body.String())
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestUpdateURL(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	queries := db.New(mockDB)
+	urlService := service.NewURLService(mockDB, queries, nil, nil)
+	handler := NewURLHandler(urlService, nil)
+
+	previewMode, badMode := "preview", "teleport"
+
+	tests := []struct {
+		name           string
+		shortCode      string
+		body           UpdateURLRequest
+		userAgent      string
+		acceptLanguage string
+		mockBehavior   func()
+		expectedStatus int
+	}{
+		{
+			name:      "Success",
+			shortCode: "abcdef",
+			body:      UpdateURLRequest{URL: testURL + "/new"},
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "abcdef").
+					WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+				mock.ExpectExec("UPDATE urls SET original_url").
+					WithArgs(testURL+"/new", sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM url_health").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "abcdef").
+					WillReturnRows(urlRows().AddRow(1, "abcdef", testURL+"/new", "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:      "Not Found",
+			shortCode: "missing",
+			body:      UpdateURLRequest{URL: testURL},
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "missing").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:      "Change Redirect Mode Only",
+			shortCode: "abcdef",
+			body:      UpdateURLRequest{RedirectMode: &previewMode},
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "abcdef").
+					WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+				mock.ExpectExec("UPDATE urls SET redirect_mode").
+					WithArgs("preview", 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "abcdef").
+					WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, nil, nil, "preview", false, false, "", time.Now(), time.Now()))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid Redirect Mode",
+			shortCode:      "abcdef",
+			body:           UpdateURLRequest{URL: testURL, RedirectMode: &badMode},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Empty Update",
+			shortCode:      "abcdef",
+			body:           UpdateURLRequest{},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unsafe Destination",
+			shortCode:      "abcdef",
+			body:           UpdateURLRequest{URL: "http://127.0.0.1/admin"},
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockBehavior()
+
+			reqBody, _ := json.Marshal(tc.body)
+			req, _ := http.NewRequest("PUT", "/api/admin/urls/"+tc.shortCode, bytes.NewBuffer(reqBody))
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("code", tc.shortCode)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+
+			handler.UpdateURL(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					rr.Code, tc.expectedStatus)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestOwnerOnly(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	queries := db.New(mockDB)
+	urlService := service.NewURLService(mockDB, queries, nil, nil)
+	handler := NewURLHandler(urlService, nil)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name           string
+		claims         *auth.Claims
+		userAgent      string
+		acceptLanguage string
+		mockBehavior   func()
+		expectedStatus int
+	}{
+		{
+			name:   "Owner",
+			claims: &auth.Claims{UserID: "user-1"},
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "abcdef").
+					WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, "user-1", nil, "302", false, false, "", time.Now(), time.Now()))
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "Other User",
+			claims: &auth.Claims{UserID: "user-2"},
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "abcdef").
+					WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, "user-1", nil, "302", false, false, "", time.Now(), time.Now()))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "Anonymous Link",
+			claims: &auth.Claims{UserID: "user-1"},
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+					WithArgs("", "abcdef").
+					WillReturnRows(urlRows().AddRow(1, "abcdef", testURL, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Not Logged In",
+			mockBehavior:   func() {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockBehavior()
+
+			req, _ := http.NewRequest("GET", "/api/me/urls/abcdef", nil)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("code", "abcdef")
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			if tc.claims != nil {
+				ctx = auth.NewContext(ctx, tc.claims)
+			}
+			req = req.WithContext(ctx)
+
+			rr := httptest.NewRecorder()
+
+			handler.OwnerOnly(next).ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					rr.Code, tc.expectedStatus)
+			}
+
 			if err := mock.ExpectationsWereMet(); err != nil {
 				t.Errorf("there were unfulfilled expectations: %s", err)
 			}
 		})
 	}
 }
+
+func TestUnlockURL(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	queries := db.New(mockDB)
+	urlService := service.NewURLService(mockDB, queries, nil, nil)
+	handler := NewURLHandler(urlService, nil)
+
+	// Low cost keeps the test fast; CheckPasswordHash reads the cost from the hash
+	hash, _ := bcrypt.GenerateFromPassword([]byte("letmein"), bcrypt.MinCost)
+	expectLookup := func(domain string) {
+		mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+			WithArgs(domain, "secret").
+			WillReturnRows(urlRows().AddRow(6, "secret", "https://example.com", "hash", false, nil, nil, 0, false, nil, string(hash), "302", false, false, domain, time.Now(), time.Now()))
+	}
+	expectResolve := func() {
+		mock.ExpectQuery("SELECT (.+) FROM url_rules").
+			WithArgs(6).
+			WillReturnRows(ruleRows())
+		mock.ExpectQuery("SELECT (.+) FROM url_variants").
+			WithArgs(6).
+			WillReturnRows(variantRows())
+	}
+
+	unlock := func(domain, password, remoteAddr string) *httptest.ResponseRecorder {
+		form := url.Values{"password": {password}}
+		req, _ := http.NewRequest("POST", "/secret", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.RemoteAddr = remoteAddr
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("code", "secret")
+		ctx := service.WithDomain(context.WithValue(req.Context(), chi.RouteCtxKey, rctx), domain)
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		handler.UnlockURL(rr, req)
+		return rr
+	}
+
+	expectLookup("")
+	expectResolve()
+	if rr := unlock("", "letmein", "198.51.100.1:1234"); rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "https://example.com" {
+		t.Errorf("correct password: got %v to %q, want 303 to the destination", rr.Code, rr.Header().Get("Location"))
+	}
+
+	for i := 0; i < 5; i++ {
+		expectLookup("")
+		if rr := unlock("", "wrong", "198.51.100.2:1234"); rr.Code != http.StatusUnauthorized {
+			t.Errorf("wrong password #%d: got %v want %v", i+1, rr.Code, http.StatusUnauthorized)
+		}
+	}
+
+	// Locked out without touching the DB, even with the right password
+	if rr := unlock("", "letmein", "198.51.100.2:1234"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("after 5 failures: got %v want %v", rr.Code, http.StatusTooManyRequests)
+	}
+
+	// Other clients are unaffected
+	expectLookup("")
+	expectResolve()
+	if rr := unlock("", "letmein", "198.51.100.3:1234"); rr.Code != http.StatusSeeOther {
+		t.Errorf("other client: got %v want %v", rr.Code, http.StatusSeeOther)
+	}
+
+	// The same code on a branded domain is a different link with its own limit
+	expectLookup("go.example.com")
+	expectResolve()
+	if rr := unlock("go.example.com", "letmein", "198.51.100.2:1234"); rr.Code != http.StatusSeeOther {
+		t.Errorf("same code on another domain: got %v want %v", rr.Code, http.StatusSeeOther)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
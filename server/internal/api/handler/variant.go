@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-shortener-sqlc/internal/service"
+)
+
+// GetVariants handles GET /api/admin/urls/{code}/variants
+func (h *URLHandler) GetVariants(w http.ResponseWriter, r *http.Request) {
+	set, err := h.Service.GetVariants(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		writeURLError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(set)
+}
+
+// SetVariants handles PUT /api/admin/urls/{code}/variants, replacing the whole list.
+func (h *URLHandler) SetVariants(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 32<<10)
+
+	var req service.VariantSet
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	set, err := h.Service.SetVariants(r.Context(), chi.URLParam(r, "code"), req)
+	if err != nil {
+		writeURLError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(set)
+}
@@ -2,9 +2,12 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"go-shortener-sqlc/internal/auth"
+	"go-shortener-sqlc/internal/service"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -59,9 +62,16 @@ func (s *Server) Routes() http.Handler {
 	})
 
 	// Routes
-	r.Post("/shorten", s.URLHandler.ShortenURL)
-	r.Get("/{code}", s.URLHandler.RedirectURL)
-	r.Post("/{code}/qr", s.QRHandler.GenerateQR)
+	// NOTE: any new top-level path must also be added to service.ReservedAliases
+	r.With(s.OptionalAuthMiddleware, RequireScope(auth.ScopeURLsWrite)).Post("/shorten", s.URLHandler.ShortenURL)
+	r.Group(func(r chi.Router) {
+		r.Use(s.HostDomain) // the same code can exist on several branded domains
+
+		r.Get("/{code}", s.URLHandler.RedirectURL)
+		r.Post("/{code}", s.URLHandler.UnlockURL)
+		r.Post("/{code}/qr", s.QRHandler.GenerateQR)
+		r.With(httprate.LimitByIP(10, 1*time.Hour)).Post("/{code}/report", s.ReportHandler.Report)
+	})
 
 	// Blog Routes
 	r.Route("/api", func(r chi.Router) {
@@ -82,37 +92,172 @@ func (s *Server) Routes() http.Handler {
 		r.Get("/images", s.ImageHandler.List)
 		r.Get("/images/{id}", s.ImageHandler.Get)
 
+		// Branded domains links can be created on
+		r.Get("/domains", s.DomainHandler.List)
+
+		// Bulk shortening counts as one request against the rate limit
+		r.With(s.AuthMiddleware, RequireScope(auth.ScopeURLsWrite)).Post("/shorten/bulk", s.URLHandler.ShortenBulk)
+
+		// Links owned by the logged-in user
+		r.Route("/me/urls", func(r chi.Router) {
+			r.Use(s.AuthMiddleware, s.DomainParam)
+
+			r.With(RequireScope(auth.ScopeURLsRead)).Get("/", s.URLHandler.ListMyURLs)
+			r.Group(func(r chi.Router) {
+				r.Use(s.URLHandler.OwnerOnly)
+
+				r.With(RequireScope(auth.ScopeURLsRead)).Get("/{code}", s.URLHandler.GetURL)
+				r.With(RequireScope(auth.ScopeURLsRead)).Get("/{code}/stats", s.ClickHandler.Stats)
+				r.With(RequireScope(auth.ScopeURLsRead)).Get("/{code}/rules", s.URLHandler.ListRules)
+				r.With(RequireScope(auth.ScopeURLsRead)).Get("/{code}/variants", s.URLHandler.GetVariants)
+				r.With(RequireScope(auth.ScopeURLsRead)).Get("/{code}/preview", s.PreviewHandler.Get)
+				r.With(RequireScope(auth.ScopeURLsWrite)).Put("/{code}", s.URLHandler.UpdateURL)
+				r.With(RequireScope(auth.ScopeURLsWrite)).Post("/{code}/disable", s.URLHandler.DisableURL)
+				r.With(RequireScope(auth.ScopeURLsWrite)).Post("/{code}/enable", s.URLHandler.EnableURL)
+				r.With(RequireScope(auth.ScopeURLsWrite)).Delete("/{code}", s.URLHandler.DeleteURL)
+				r.With(RequireScope(auth.ScopeURLsWrite)).Post("/{code}/rules", s.URLHandler.CreateRule)
+				r.With(RequireScope(auth.ScopeURLsWrite)).Put("/{code}/rules/{id}", s.URLHandler.UpdateRule)
+				r.With(RequireScope(auth.ScopeURLsWrite)).Delete("/{code}/rules/{id}", s.URLHandler.DeleteRule)
+				r.With(RequireScope(auth.ScopeURLsWrite)).Put("/{code}/variants", s.URLHandler.SetVariants)
+				r.With(RequireScope(auth.ScopeURLsWrite)).Post("/{code}/preview", s.PreviewHandler.Refresh)
+			})
+		})
+
+		// API keys of the logged-in user (cookie session only; a key cannot mint keys)
+		r.Route("/me/api-keys", func(r chi.Router) {
+			r.Use(s.AuthMiddleware, SessionOnly)
+
+			r.Get("/", s.APIKeyHandler.List)
+			r.Post("/", s.APIKeyHandler.Create)
+			r.Delete("/{id}", s.APIKeyHandler.Revoke)
+		})
+
 		// Admin Endpoints
 		r.Group(func(r chi.Router) {
-			r.Use(AdminOnlyMiddleware) // Protect these routes
-			
-			r.Post("/categories", s.BlogHandler.CreateCategory)
-			r.Put("/categories/{id}", s.BlogHandler.UpdateCategory)
-			r.Delete("/categories/{id}", s.BlogHandler.DeleteCategory)
-
-			r.Post("/tags", s.BlogHandler.CreateTag)
-			r.Put("/tags/{id}", s.BlogHandler.UpdateTag)
-			r.Delete("/tags/{id}", s.BlogHandler.DeleteTag)
-
-			r.Get("/admin/posts", s.BlogHandler.ListPosts)
-			r.Get("/admin/posts/{id}", s.BlogHandler.GetPost)
-			r.Post("/admin/posts", s.BlogHandler.CreatePost)
-			r.Put("/admin/posts/{id}", s.BlogHandler.UpdatePost)
-			r.Patch("/admin/posts/{id}/views", s.BlogHandler.UpdatePostViews)
-			r.Delete("/admin/posts/{id}", s.BlogHandler.DeletePost)
+			r.Use(s.AdminOnlyMiddleware) // Protect these routes
+
+			r.Group(func(r chi.Router) {
+				r.Use(RequireScope(auth.ScopePostsWrite))
+
+				r.Post("/categories", s.BlogHandler.CreateCategory)
+				r.Put("/categories/{id}", s.BlogHandler.UpdateCategory)
+				r.Delete("/categories/{id}", s.BlogHandler.DeleteCategory)
+
+				r.Post("/tags", s.BlogHandler.CreateTag)
+				r.Put("/tags/{id}", s.BlogHandler.UpdateTag)
+				r.Delete("/tags/{id}", s.BlogHandler.DeleteTag)
+
+				r.Get("/admin/posts", s.BlogHandler.ListPosts)
+				r.Get("/admin/posts/{id}", s.BlogHandler.GetPost)
+				r.Post("/admin/posts", s.BlogHandler.CreatePost)
+				r.Put("/admin/posts/{id}", s.BlogHandler.UpdatePost)
+				r.Patch("/admin/posts/{id}/views", s.BlogHandler.UpdatePostViews)
+				r.Delete("/admin/posts/{id}", s.BlogHandler.DeletePost)
+			})
 
 			// Admin Image Endpoints
-			r.Post("/admin/images", s.ImageHandler.Upload)
-			r.Put("/admin/images/{id}", s.ImageHandler.Update)
-			r.Delete("/admin/images/{id}", s.ImageHandler.Delete)
+			r.Group(func(r chi.Router) {
+				r.Use(RequireScope(auth.ScopeImagesWrite))
+
+				r.Post("/admin/images", s.ImageHandler.Upload)
+				r.Put("/admin/images/{id}", s.ImageHandler.Update)
+				r.Delete("/admin/images/{id}", s.ImageHandler.Delete)
+			})
+
+			// Admin Blocklist & Abuse Reports
+			r.Group(func(r chi.Router) {
+				r.Use(RequireScope(auth.ScopeURLsWrite))
+
+				r.Get("/admin/blocklist", s.BlocklistHandler.List)
+				r.Post("/admin/blocklist", s.BlocklistHandler.Create)
+				r.Put("/admin/blocklist/{id}", s.BlocklistHandler.Update)
+				r.Delete("/admin/blocklist/{id}", s.BlocklistHandler.Delete)
+
+				r.Get("/admin/reports", s.ReportHandler.List)
+				r.Post("/admin/reports/{id}/dismiss", s.ReportHandler.Dismiss)
+				r.Post("/admin/reports/{id}/disable", s.ReportHandler.DisableLink)
+			})
+
+			// Admin Branded Domains
+			r.Group(func(r chi.Router) {
+				r.Use(RequireScope(auth.ScopeURLsWrite))
+
+				r.Post("/admin/domains", s.DomainHandler.Create)
+				r.Delete("/admin/domains/{id}", s.DomainHandler.Delete)
+			})
+
+			// Admin QR Code Templates
+			r.Group(func(r chi.Router) {
+				r.Use(RequireScope(auth.ScopeURLsWrite))
+
+				r.Get("/admin/qr-templates", s.QRTemplateHandler.List)
+				r.Get("/admin/qr-templates/{id}", s.QRTemplateHandler.Get)
+				r.Post("/admin/qr-templates", s.QRTemplateHandler.Create)
+				r.Put("/admin/qr-templates/{id}", s.QRTemplateHandler.Update)
+				r.Delete("/admin/qr-templates/{id}", s.QRTemplateHandler.Delete)
+			})
+
+			// Admin URL Management (?domain= selects a code on a branded domain)
+			r.Group(func(r chi.Router) {
+				r.Use(RequireScope(auth.ScopeURLsRead), s.DomainParam)
+
+				r.Get("/admin/urls", s.URLHandler.ListURLs)
+				r.Get("/admin/urls/broken", s.HealthHandler.ListBroken)
+				r.Get("/admin/urls/{code}", s.URLHandler.GetURL)
+				r.Get("/admin/urls/{code}/stats", s.ClickHandler.Stats)
+				r.Get("/admin/urls/{code}/rules", s.URLHandler.ListRules)
+				r.Get("/admin/urls/{code}/variants", s.URLHandler.GetVariants)
+				r.Get("/admin/urls/{code}/preview", s.PreviewHandler.Get)
+				r.Get("/admin/urls/{code}/health", s.HealthHandler.Get)
+				r.Get("/admin/cache/stats", s.URLHandler.CacheStats)
+			})
+			r.Group(func(r chi.Router) {
+				r.Use(RequireScope(auth.ScopeURLsWrite), s.DomainParam)
+
+				r.Put("/admin/urls/{code}", s.URLHandler.UpdateURL)
+				r.Post("/admin/urls/{code}/disable", s.URLHandler.DisableURL)
+				r.Post("/admin/urls/{code}/enable", s.URLHandler.EnableURL)
+				r.Delete("/admin/urls/{code}", s.URLHandler.DeleteURL)
+				r.Post("/admin/urls/{code}/rules", s.URLHandler.CreateRule)
+				r.Put("/admin/urls/{code}/rules/{id}", s.URLHandler.UpdateRule)
+				r.Delete("/admin/urls/{code}/rules/{id}", s.URLHandler.DeleteRule)
+				r.Put("/admin/urls/{code}/variants", s.URLHandler.SetVariants)
+				r.Post("/admin/urls/{code}/preview", s.PreviewHandler.Refresh)
+				r.Post("/admin/urls/{code}/health", s.HealthHandler.Check)
+			})
 		})
 	})
 
 	return r
 }
 
-func AdminOnlyMiddleware(next http.Handler) http.Handler {
+func (s *Server) AdminOnlyMiddleware(next http.Handler) http.Handler {
+	return s.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := auth.FromContext(r.Context())
+		if claims.Role != "admin" {
+			slog.Warn("Auth: forbidden access", "role", claims.Role)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// AuthMiddleware requires an API key (Authorization: Bearer) or a valid auth cookie
+// and stores the caller's claims in the request context.
+func (s *Server) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := bearerToken(r); ok {
+			claims, err := s.apiKeyService.Authenticate(r.Context(), token)
+			if err != nil {
+				writeAPIKeyError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), claims)))
+			return
+		}
+
 		cookie, err := r.Cookie("auth_token")
 		if err != nil {
 			slog.Warn("Auth: no cookie found", "error", err, "path", r.URL.Path)
@@ -127,13 +272,89 @@ func AdminOnlyMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		if claims.Role != "admin" {
-			slog.Warn("Auth: forbidden access", "role", claims.Role)
-			http.Error(w, "Forbidden", http.StatusForbidden)
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), claims)))
+	})
+}
+
+// OptionalAuthMiddleware attaches the claims when the caller is authenticated and
+// otherwise lets the request through anonymously. An invalid API key is still rejected,
+// so scripts with a revoked key fail instead of silently creating anonymous links.
+func (s *Server) OptionalAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := bearerToken(r); ok {
+			s.AuthMiddleware(next).ServeHTTP(w, r)
 			return
 		}
+		if cookie, err := r.Cookie("auth_token"); err == nil {
+			if claims, err := auth.ValidateToken(cookie.Value); err == nil {
+				r = r.WithContext(auth.NewContext(r.Context(), claims))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
 
+// RequireScope rejects API keys that were not granted scope. Cookie sessions and
+// anonymous requests pass through; role checks are left to the other middleware.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if claims := auth.FromContext(r.Context()); claims != nil && !claims.HasScope(scope) {
+				http.Error(w, "Forbidden - API key lacks scope "+scope, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SessionOnly rejects requests authenticated with an API key.
+func SessionOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims := auth.FromContext(r.Context()); claims != nil && claims.APIKeyID != "" {
+			http.Error(w, "Forbidden - requires a login session", http.StatusForbidden)
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
 
+// HostDomain scopes public link routes to the branded domain named by the Host header.
+func (s *Server) HostDomain(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := service.WithDomain(r.Context(), s.domains.Resolve(r.Host))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// DomainParam scopes API routes that address a link by code to the domain in the
+// ?domain= query parameter (the default domain when absent).
+func (s *Server) DomainParam(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		domain, err := s.domains.Canonical(r.URL.Query().Get("domain"))
+		if err != nil {
+			http.Error(w, "Domain not found", http.StatusNotFound)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(service.WithDomain(r.Context(), domain)))
+	})
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func writeAPIKeyError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrInvalidAPIKey) {
+		slog.Warn("Auth: invalid API key")
+		http.Error(w, "Unauthorized - Invalid API Key", http.StatusUnauthorized)
+		return
+	}
+	slog.Error("Auth: API key lookup failed", "error", err)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
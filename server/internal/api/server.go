@@ -2,48 +2,133 @@ package api
 
 import (
 	"database/sql"
+	"fmt"
 	"go-shortener-sqlc/internal/api/handler"
+	"go-shortener-sqlc/internal/cache"
 	"go-shortener-sqlc/internal/config"
 	"go-shortener-sqlc/internal/db"
+	"go-shortener-sqlc/internal/geoip"
 	"go-shortener-sqlc/internal/service"
-
-	"github.com/redis/go-redis/v9"
+	"html/template"
 )
 
 type Server struct {
-	DB           *sql.DB
-	Config       *config.Config
-	URLHandler   *handler.URLHandler
-	QRHandler    *handler.QRHandler
-	BlogHandler  *handler.BlogHandler
-	AuthHandler  *handler.AuthHandler
-	ImageHandler *handler.ImageHandler
+	DB                *sql.DB
+	Config            *config.Config
+	URLHandler        *handler.URLHandler
+	QRHandler         *handler.QRHandler
+	BlogHandler       *handler.BlogHandler
+	AuthHandler       *handler.AuthHandler
+	ImageHandler      *handler.ImageHandler
+	ClickHandler      *handler.ClickHandler
+	APIKeyHandler     *handler.APIKeyHandler
+	PreviewHandler    *handler.PreviewHandler
+	HealthHandler     *handler.HealthHandler
+	BlocklistHandler  *handler.BlocklistHandler
+	ReportHandler     *handler.ReportHandler
+	DomainHandler     *handler.DomainHandler
+	QRTemplateHandler *handler.QRTemplateHandler
+
+	clickService   *service.ClickService
+	apiKeyService  *service.APIKeyService
+	previewService *service.PreviewService
+	healthService  *service.HealthService
+	blocklist      *service.Blocklist
+	domains        *service.DomainService
 }
 
-func NewServer(conn *sql.DB, cfg *config.Config, rdb *redis.Client) *Server {
+// NewServer wires the services and handlers. It fails on settings that would
+// silently change how links behave, such as invalid short code settings.
+func NewServer(conn *sql.DB, cfg *config.Config, c cache.Cache, geo geoip.Lookup) (*Server, error) {
 	// Initialize Repositories (using sqlc directly for now)
 	queries := db.New(conn)
 
 	// Initialize Services
-	urlService := service.NewURLService(queries, rdb)
+	urlService := service.NewURLService(conn, queries, c, geo)
+	urlService.BaseURL = cfg.BaseURL
+	if c.Shared() {
+		// A local tier only pays off in front of a networked backend
+		urlService.EnableLocalCache(cfg.URLCacheSize)
+	}
+	domains := service.NewDomainService(queries, cfg.BaseURL)
+	urlService.Domains = domains
+	codes, err := service.NewCodeGenerator(queries, service.CodeConfig{
+		Strategy: cfg.CodeGenerator,
+		Alphabet: cfg.CodeAlphabet,
+		Length:   cfg.CodeLength,
+		Secret:   cfg.CodeSecret,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("short code settings: %w", err)
+	}
+	urlService.Codes = codes
 	qrService := service.NewQRService(cfg.BaseURL)
-	blogService := service.NewBlogService(queries)
-	imageService := service.NewImageService(queries, cfg.UploadDir)
+	qrTemplates := service.NewQRTemplateService(queries, cfg.UploadDir)
+	blogService := service.NewBlogService(queries, c)
+	imageService := service.NewImageService(queries, c, cfg.UploadDir)
+	clickService := service.NewClickService(conn, queries, geo)
+	apiKeyService := service.NewAPIKeyService(queries)
+	previewService := service.NewPreviewService(queries)
+	urlService.Previews = previewService
+	healthService := service.NewHealthService(queries, urlService, service.HealthConfig{
+		Interval:         cfg.HealthCheckInterval,
+		Concurrency:      cfg.HealthCheckConcurrency,
+		FailureThreshold: cfg.HealthFailureThreshold,
+		FallbackURL:      cfg.HealthFallbackURL,
+	})
+	urlService.Health = healthService
+	blocklist := service.NewBlocklist(queries, cfg.BlocklistFile)
+	urlService.Blocklist = blocklist
+	reportService := service.NewReportService(queries, urlService)
 
 	// Initialize Handlers
-	urlHandler := handler.NewURLHandler(urlService)
+	urlHandler := handler.NewURLHandler(urlService, clickService)
+	urlHandler.TrackingHTML = template.HTML(cfg.TrackingHTML)
 	qrHandler := handler.NewQRHandler(qrService)
+	qrHandler.Domains = domains
+	qrHandler.Templates = qrTemplates
 	blogHandler := handler.NewBlogHandler(blogService)
 	authHandler := handler.NewAuthHandler(queries)
 	imageHandler := handler.NewImageHandler(imageService)
+	clickHandler := handler.NewClickHandler(clickService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	previewHandler := handler.NewPreviewHandler(previewService)
+	healthHandler := handler.NewHealthHandler(healthService)
+	blocklistHandler := handler.NewBlocklistHandler(blocklist)
+	reportHandler := handler.NewReportHandler(reportService)
+	domainHandler := handler.NewDomainHandler(domains)
+	qrTemplateHandler := handler.NewQRTemplateHandler(qrTemplates)
 
 	return &Server{
-		DB:           conn,
-		Config:       cfg,
-		URLHandler:   urlHandler,
-		QRHandler:    qrHandler,
-		BlogHandler:  blogHandler,
-		AuthHandler:  authHandler,
-		ImageHandler: imageHandler,
-	}
+		DB:                conn,
+		Config:            cfg,
+		URLHandler:        urlHandler,
+		QRHandler:         qrHandler,
+		BlogHandler:       blogHandler,
+		AuthHandler:       authHandler,
+		ImageHandler:      imageHandler,
+		ClickHandler:      clickHandler,
+		APIKeyHandler:     apiKeyHandler,
+		PreviewHandler:    previewHandler,
+		HealthHandler:     healthHandler,
+		BlocklistHandler:  blocklistHandler,
+		ReportHandler:     reportHandler,
+		DomainHandler:     domainHandler,
+		QRTemplateHandler: qrTemplateHandler,
+		clickService:      clickService,
+		apiKeyService:     apiKeyService,
+		previewService:    previewService,
+		healthService:     healthService,
+		blocklist:         blocklist,
+		domains:           domains,
+	}, nil
+}
+
+// Close flushes background workers. Call it after the HTTP server has shut down.
+func (s *Server) Close() {
+	s.clickService.Close()
+	s.previewService.Close()
+	s.healthService.Close()
+	s.blocklist.Close()
+	s.domains.Close()
 }
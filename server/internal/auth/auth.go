@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -28,6 +29,39 @@ type Claims struct {
 	UserID string `json:"user_id"`
 	Role   string `json:"role"`
 	jwt.RegisteredClaims
+
+	// Set only when the request was authenticated with an API key
+	APIKeyID string   `json:"-"`
+	Scopes   []string `json:"-"`
+}
+
+// API key scopes. Cookie sessions are not scoped and may do everything their role allows.
+const (
+	ScopeURLsRead    = "urls:read"
+	ScopeURLsWrite   = "urls:write"
+	ScopePostsWrite  = "posts:write"
+	ScopeImagesWrite = "images:write"
+)
+
+// ValidScopes lists every scope an API key may be granted.
+var ValidScopes = map[string]bool{
+	ScopeURLsRead:    true,
+	ScopeURLsWrite:   true,
+	ScopePostsWrite:  true,
+	ScopeImagesWrite: true,
+}
+
+// HasScope reports whether the caller may use scope.
+func (c *Claims) HasScope(scope string) bool {
+	if c.APIKeyID == "" {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
 
 func GenerateToken(userID, role string) (string, error) {
@@ -60,3 +94,18 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+// --- Request Context ---
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the authenticated user's claims.
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// FromContext returns the claims stored by NewContext, or nil for anonymous requests.
+func FromContext(ctx context.Context) *Claims {
+	claims, _ := ctx.Value(contextKey{}).(*Claims)
+	return claims
+}
@@ -0,0 +1,70 @@
+// Package cache is the key/value cache the services share: Redis when several
+// instances run behind a load balancer, a size-bounded in-process LRU for a single
+// node, or Nop to turn caching off.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Backends (CACHE_BACKEND).
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+	BackendNone   = "none"
+)
+
+var (
+	// ErrMiss is returned for keys that are absent or expired.
+	ErrMiss = errors.New("cache: miss")
+	// ErrDisabled is returned by Nop for operations that need storage, such as Incr.
+	ErrDisabled = errors.New("cache: disabled")
+)
+
+// Cache stores byte values with a TTL. Implementations are safe for concurrent use.
+// Callers treat every error as a miss and fall back to the source of truth.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value for ttl, which must be positive.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// Incr adds one to the decimal counter at key and returns the new count. The first
+	// increment starts a window of ttl after which the counter disappears.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// TTL returns how long key has left.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// Shared reports whether entries are visible to every instance.
+	Shared() bool
+}
+
+// Nop caches nothing: every Get misses and writes are dropped.
+type Nop struct{}
+
+func (Nop) Get(context.Context, string) ([]byte, error)                { return nil, ErrMiss }
+func (Nop) Set(context.Context, string, []byte, time.Duration) error   { return nil }
+func (Nop) Delete(context.Context, ...string) error                    { return nil }
+func (Nop) Incr(context.Context, string, time.Duration) (int64, error) { return 0, ErrDisabled }
+func (Nop) TTL(context.Context, string) (time.Duration, error)         { return 0, ErrMiss }
+func (Nop) Shared() bool                                               { return false }
+
+// Fetch returns the JSON-encoded value cached under key, or calls load and caches its
+// result for ttl. Cache errors never fail the call; load errors are returned as is.
+func Fetch[T any](ctx context.Context, c Cache, key string, ttl time.Duration, load func(context.Context) (T, error)) (T, error) {
+	if data, err := c.Get(ctx, key); err == nil {
+		var v T
+		if json.Unmarshal(data, &v) == nil {
+			return v, nil
+		}
+	}
+	v, err := load(ctx)
+	if err != nil {
+		return v, err
+	}
+	if data, err := json.Marshal(v); err == nil {
+		c.Set(ctx, key, data, ttl)
+	}
+	return v, nil
+}
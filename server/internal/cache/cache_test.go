@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	c := NewLRU[*int](2)
+	a, b := new(int), new(int)
+
+	c.Set("a", a, time.Minute)
+	c.Set("b", b, time.Minute)
+	c.Get("a") // a is now the most recently used
+	c.Set("c", nil, time.Minute)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	if got, ok := c.Get("a"); !ok || got != a {
+		t.Errorf("Get(a) = %v, %v", got, ok)
+	}
+	if got, ok := c.Get("c"); !ok || got != nil {
+		t.Errorf("Get(c) = %v, %v, want cached nil", got, ok)
+	}
+
+	c.Set("a", a, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expired entry was returned")
+	}
+	c.Delete("c")
+	if n := c.Len(); n != 0 {
+		t.Errorf("Len = %d after expiry and delete, want 0", n)
+	}
+
+	c.Set("d", a, 0)
+	if _, ok := c.Get("d"); ok {
+		t.Error("entry with zero ttl was stored")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(10)
+
+	if _, err := m.Get(ctx, "k"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get on empty cache: err = %v, want ErrMiss", err)
+	}
+
+	value := []byte("v1")
+	m.Set(ctx, "k", value, time.Minute)
+	value[1] = '2'
+	if got, err := m.Get(ctx, "k"); err != nil || string(got) != "v1" {
+		t.Errorf("Get = %q, %v, want stored copy \"v1\"", got, err)
+	}
+	m.Delete(ctx, "k")
+	if _, err := m.Get(ctx, "k"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get after Delete: err = %v, want ErrMiss", err)
+	}
+
+	for want := int64(1); want <= 3; want++ {
+		n, err := m.Incr(ctx, "n", time.Minute)
+		if err != nil || n != want {
+			t.Fatalf("Incr = %d, %v, want %d", n, err, want)
+		}
+	}
+	if got, _ := m.Get(ctx, "n"); string(got) != "3" {
+		t.Errorf("counter = %q, want \"3\"", got)
+	}
+	ttl, err := m.TTL(ctx, "n")
+	if err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL = %v, %v, want within the first window", ttl, err)
+	}
+	if _, err := m.TTL(ctx, "missing"); !errors.Is(err, ErrMiss) {
+		t.Errorf("TTL of missing key: err = %v, want ErrMiss", err)
+	}
+
+	// The window starts at the first increment and is not extended by later ones
+	m.Incr(ctx, "w", 5*time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	if n, _ := m.Incr(ctx, "w", time.Minute); n != 1 {
+		t.Errorf("Incr after window = %d, want a fresh count of 1", n)
+	}
+
+	m.Set(ctx, "s", []byte("x"), time.Minute)
+	if _, err := m.Incr(ctx, "s", time.Minute); err == nil {
+		t.Error("Incr of a non-numeric value succeeded")
+	}
+}
+
+func TestNop(t *testing.T) {
+	ctx := context.Background()
+	var c Cache = Nop{}
+	c.Set(ctx, "k", []byte("v"), time.Minute)
+	if _, err := c.Get(ctx, "k"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get: err = %v, want ErrMiss", err)
+	}
+	if _, err := c.Incr(ctx, "n", time.Minute); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Incr: err = %v, want ErrDisabled", err)
+	}
+	if c.Shared() {
+		t.Error("Nop reports shared entries")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	ctx := context.Background()
+	type item struct{ Name string }
+
+	tests := []struct {
+		name      string
+		cache     Cache
+		wantLoads int
+	}{
+		{"Memory", NewMemory(10), 1},
+		{"Nop", Nop{}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loads := 0
+			load := func(context.Context) ([]item, error) {
+				loads++
+				return []item{{Name: "a"}}, nil
+			}
+			for range 2 {
+				got, err := Fetch(ctx, tt.cache, "items", time.Minute, load)
+				if err != nil || len(got) != 1 || got[0].Name != "a" {
+					t.Fatalf("Fetch = %v, %v", got, err)
+				}
+			}
+			if loads != tt.wantLoads {
+				t.Errorf("load called %d times, want %d", loads, tt.wantLoads)
+			}
+		})
+	}
+
+	errLoad := errors.New("db down")
+	c := NewMemory(10)
+	if _, err := Fetch(ctx, c, "bad", time.Minute, func(context.Context) (int, error) { return 0, errLoad }); err != errLoad {
+		t.Errorf("Fetch err = %v, want load error", err)
+	}
+	if _, err := c.Get(ctx, "bad"); !errors.Is(err, ErrMiss) {
+		t.Error("failed load was cached")
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is a size-bounded map whose entries also expire. When it is full, the least
+// recently used entry is evicted.
+type LRU[V any] struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // front is most recently used
+	items map[string]*list.Element
+}
+
+type lruEntry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+// NewLRU returns an LRU holding up to size entries.
+func NewLRU[V any](size int) *LRU[V] {
+	return &LRU[V]{size: max(size, 1), order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get returns the live value of key and marks it as recently used.
+func (c *LRU[V]) Get(key string) (V, bool) {
+	v, _, ok := c.get(key)
+	return v, ok
+}
+
+func (c *LRU[V]) get(key string) (V, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		return zero, time.Time{}, false
+	}
+	e := el.Value.(*lruEntry[V])
+	if !time.Now().Before(e.expires) {
+		c.order.Remove(el)
+		delete(c.items, key)
+		return zero, time.Time{}, false
+	}
+	c.order.MoveToFront(el)
+	return e.value, e.expires, true
+}
+
+// Set stores value for ttl; a non-positive ttl stores nothing.
+func (c *LRU[V]) Set(key string, value V, ttl time.Duration) {
+	if ttl > 0 {
+		c.set(key, value, time.Now().Add(ttl))
+	}
+}
+
+func (c *LRU[V]) set(key string, value V, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value = &lruEntry[V]{key: key, value: value, expires: expires}
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value, expires: expires})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry[V]).key)
+	}
+}
+
+// Delete removes key.
+func (c *LRU[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.order.Remove(el)
+		delete(c.items, key)
+	}
+}
+
+// Len returns the number of entries, including expired ones not yet evicted.
+func (c *LRU[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process Cache bounded by entry count. Entries are not shared with
+// other instances, so it suits single-node deployments and tests.
+type Memory struct {
+	mu  sync.Mutex // makes Incr atomic with respect to the other writes
+	lru *LRU[[]byte]
+}
+
+// NewMemory returns a Memory cache holding up to size entries.
+func NewMemory(size int) *Memory {
+	return &Memory{lru: NewLRU[[]byte](size)}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, error) {
+	v, ok := m.lru.Get(key)
+	if !ok {
+		return nil, ErrMiss
+	}
+	return v, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Copy so callers can't change the cached value afterwards
+	m.lru.Set(key, append([]byte(nil), value...), ttl)
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		m.lru.Delete(key)
+	}
+	return nil
+}
+
+func (m *Memory) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, expires, ok := m.lru.get(key)
+	var n int64
+	if ok {
+		var err error
+		if n, err = strconv.ParseInt(string(v), 10, 64); err != nil {
+			return 0, err
+		}
+	} else {
+		expires = time.Now().Add(ttl)
+	}
+	n++
+	m.lru.set(key, []byte(strconv.FormatInt(n, 10)), expires)
+	return n, nil
+}
+
+func (m *Memory) TTL(_ context.Context, key string) (time.Duration, error) {
+	_, expires, ok := m.lru.get(key)
+	if !ok {
+		return 0, ErrMiss
+	}
+	return time.Until(expires), nil
+}
+
+func (m *Memory) Shared() bool { return false }
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a Cache shared by every instance using the same server.
+type Redis struct {
+	rdb *redis.Client
+}
+
+func NewRedis(rdb *redis.Client) *Redis {
+	return &Redis{rdb: rdb}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	v, err := r.rdb.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrMiss
+	}
+	return v, err
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.rdb.Set(ctx, key, value, ttl).Err()
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	return r.rdb.Del(ctx, keys...).Err()
+}
+
+func (r *Redis) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	n, err := r.rdb.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if n == 1 {
+		r.rdb.Expire(ctx, key, ttl)
+	}
+	return n, nil
+}
+
+func (r *Redis) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.rdb.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// -2: no such key, -1: no expiry
+	if ttl == -2 {
+		return 0, ErrMiss
+	}
+	return ttl, nil
+}
+
+func (r *Redis) Shared() bool { return true }
@@ -3,7 +3,9 @@ package config
 import (
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +17,30 @@ type Config struct {
 	BaseURL        string
 	UploadDir      string
 	RedisAddr      string
+	GeoIPDBPath    string
+	TrackingHTML   string
+
+	// Destination health checker (see service.HealthConfig)
+	HealthCheckInterval    time.Duration
+	HealthCheckConcurrency int
+	HealthFailureThreshold int
+	HealthFallbackURL      string
+
+	// Optional local blocklist feed (hosts file, domain or URL list, see service.Blocklist)
+	BlocklistFile string
+
+	// Short code generator (see service.CodeConfig)
+	CodeGenerator string
+	CodeAlphabet  string
+	CodeLength    int
+	CodeSecret    string
+
+	// Cache backend (see cache.Backend*) and entry limit of the memory backend
+	CacheBackend string
+	CacheSize    int
+
+	// Codes kept in the in-process redirect cache in front of a shared backend; 0 disables it
+	URLCacheSize int
 }
 
 func Load() *Config {
@@ -55,6 +81,45 @@ func Load() *Config {
 		redisAddr = "localhost:6379"
 	}
 
+	// Optional offline GeoIP CSV (start_ip,end_ip,country)
+	geoIPDBPath := os.Getenv("GEOIP_DB_PATH")
+
+	// Optional raw HTML (e.g. analytics pixels) for the preview and meta-refresh redirect pages
+	trackingHTML := os.Getenv("TRACKING_HTML")
+
+	// How often each link's destination is checked; "0" turns the checker off
+	healthInterval, err := time.ParseDuration(getEnv("HEALTH_CHECK_INTERVAL", "24h"))
+	if err != nil {
+		slog.Warn("Invalid HEALTH_CHECK_INTERVAL, health checks disabled", "error", err)
+		healthInterval = 0
+	}
+	healthConcurrency, _ := strconv.Atoi(os.Getenv("HEALTH_CHECK_CONCURRENCY"))
+	healthThreshold, _ := strconv.Atoi(os.Getenv("HEALTH_FAILURE_THRESHOLD"))
+
+	// Optional redirect target for links whose destination failed HEALTH_FAILURE_THRESHOLD checks in a row
+	healthFallbackURL := os.Getenv("HEALTH_FALLBACK_URL")
+
+	blocklistFile := os.Getenv("BLOCKLIST_FILE")
+
+	// "random" (default) or "sequential"; alphabet and length default to 6 characters of
+	// Base62 without lookalikes. CODE_SECRET keys the permutation of sequential codes and
+	// must be set for them; startup fails on an invalid combination.
+	codeLength, _ := strconv.Atoi(os.Getenv("CODE_LENGTH"))
+
+	// "redis" (default), "memory" for a single instance, or "none"
+	cacheBackend := strings.ToLower(getEnv("CACHE_BACKEND", "redis"))
+	cacheSize, err := strconv.Atoi(getEnv("CACHE_SIZE", "100000"))
+	if err != nil || cacheSize <= 0 {
+		slog.Warn("Invalid CACHE_SIZE, using default", "value", os.Getenv("CACHE_SIZE"))
+		cacheSize = 100000
+	}
+
+	urlCacheSize, err := strconv.Atoi(getEnv("URL_CACHE_SIZE", "10000"))
+	if err != nil || urlCacheSize < 0 {
+		slog.Warn("Invalid URL_CACHE_SIZE, in-process cache disabled", "value", os.Getenv("URL_CACHE_SIZE"))
+		urlCacheSize = 0
+	}
+
 	return &Config{
 		Port:           port,
 		DatabaseURL:    dbURL,
@@ -62,6 +127,24 @@ func Load() *Config {
 		BaseURL:        baseURL,
 		UploadDir:      uploadDir,
 		RedisAddr:      redisAddr,
+		GeoIPDBPath:    geoIPDBPath,
+		TrackingHTML:   trackingHTML,
+
+		HealthCheckInterval:    healthInterval,
+		HealthCheckConcurrency: healthConcurrency,
+		HealthFailureThreshold: healthThreshold,
+		HealthFallbackURL:      healthFallbackURL,
+
+		BlocklistFile: blocklistFile,
+
+		CodeGenerator: os.Getenv("CODE_GENERATOR"),
+		CodeAlphabet:  os.Getenv("CODE_ALPHABET"),
+		CodeLength:    codeLength,
+		CodeSecret:    os.Getenv("CODE_SECRET"),
+
+		CacheBackend: cacheBackend,
+		CacheSize:    cacheSize,
+		URLCacheSize: urlCacheSize,
 	}
 }
 
@@ -11,6 +11,92 @@ import (
 	"time"
 )
 
+type BlocklistEntriesKind string
+
+const (
+	BlocklistEntriesKindHost   BlocklistEntriesKind = "host"
+	BlocklistEntriesKindSuffix BlocklistEntriesKind = "suffix"
+	BlocklistEntriesKindRegex  BlocklistEntriesKind = "regex"
+)
+
+func (e *BlocklistEntriesKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = BlocklistEntriesKind(s)
+	case string:
+		*e = BlocklistEntriesKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for BlocklistEntriesKind: %T", src)
+	}
+	return nil
+}
+
+type NullBlocklistEntriesKind struct {
+	BlocklistEntriesKind BlocklistEntriesKind `json:"blocklist_entries_kind"`
+	Valid                bool                 `json:"valid"` // Valid is true if BlocklistEntriesKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullBlocklistEntriesKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.BlocklistEntriesKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.BlocklistEntriesKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullBlocklistEntriesKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.BlocklistEntriesKind), nil
+}
+
+type LinkReportsStatus string
+
+const (
+	LinkReportsStatusOpen      LinkReportsStatus = "open"
+	LinkReportsStatusDismissed LinkReportsStatus = "dismissed"
+	LinkReportsStatusDisabled  LinkReportsStatus = "disabled"
+)
+
+func (e *LinkReportsStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LinkReportsStatus(s)
+	case string:
+		*e = LinkReportsStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LinkReportsStatus: %T", src)
+	}
+	return nil
+}
+
+type NullLinkReportsStatus struct {
+	LinkReportsStatus LinkReportsStatus `json:"link_reports_status"`
+	Valid             bool              `json:"valid"` // Valid is true if LinkReportsStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLinkReportsStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.LinkReportsStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LinkReportsStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLinkReportsStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LinkReportsStatus), nil
+}
+
 type PostsStatus string
 
 const (
@@ -54,12 +140,145 @@ func (ns NullPostsStatus) Value() (driver.Value, error) {
 	return string(ns.PostsStatus), nil
 }
 
+type UrlPreviewsStatus string
+
+const (
+	UrlPreviewsStatusPending UrlPreviewsStatus = "pending"
+	UrlPreviewsStatusOk      UrlPreviewsStatus = "ok"
+	UrlPreviewsStatusFailed  UrlPreviewsStatus = "failed"
+)
+
+func (e *UrlPreviewsStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = UrlPreviewsStatus(s)
+	case string:
+		*e = UrlPreviewsStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for UrlPreviewsStatus: %T", src)
+	}
+	return nil
+}
+
+type NullUrlPreviewsStatus struct {
+	UrlPreviewsStatus UrlPreviewsStatus `json:"url_previews_status"`
+	Valid             bool              `json:"valid"` // Valid is true if UrlPreviewsStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullUrlPreviewsStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.UrlPreviewsStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.UrlPreviewsStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullUrlPreviewsStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.UrlPreviewsStatus), nil
+}
+
+type UrlsRedirectMode string
+
+const (
+	UrlsRedirectMode301     UrlsRedirectMode = "301"
+	UrlsRedirectMode302     UrlsRedirectMode = "302"
+	UrlsRedirectMode307     UrlsRedirectMode = "307"
+	UrlsRedirectMode308     UrlsRedirectMode = "308"
+	UrlsRedirectModePreview UrlsRedirectMode = "preview"
+	UrlsRedirectModeMeta    UrlsRedirectMode = "meta"
+)
+
+func (e *UrlsRedirectMode) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = UrlsRedirectMode(s)
+	case string:
+		*e = UrlsRedirectMode(s)
+	default:
+		return fmt.Errorf("unsupported scan type for UrlsRedirectMode: %T", src)
+	}
+	return nil
+}
+
+type NullUrlsRedirectMode struct {
+	UrlsRedirectMode UrlsRedirectMode `json:"urls_redirect_mode"`
+	Valid            bool             `json:"valid"` // Valid is true if UrlsRedirectMode is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullUrlsRedirectMode) Scan(value interface{}) error {
+	if value == nil {
+		ns.UrlsRedirectMode, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.UrlsRedirectMode.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullUrlsRedirectMode) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.UrlsRedirectMode), nil
+}
+
+type ApiKey struct {
+	ID         string       `json:"id"`
+	UserID     string       `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"key_hash"`
+	Scopes     string       `json:"scopes"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type BlocklistEntry struct {
+	ID        int32                `json:"id"`
+	Kind      BlocklistEntriesKind `json:"kind"`
+	Pattern   string               `json:"pattern"`
+	Reason    string               `json:"reason"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
 type Category struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
 	Slug string `json:"slug"`
 }
 
+type Click struct {
+	ID        int64     `json:"id"`
+	UrlID     int32     `json:"url_id"`
+	ClickedAt time.Time `json:"clicked_at"`
+	Referrer  string    `json:"referrer"`
+	UserAgent string    `json:"user_agent"`
+	Browser   string    `json:"browser"`
+	Os        string    `json:"os"`
+	Device    string    `json:"device"`
+	Country   string    `json:"country"`
+	Variant   string    `json:"variant"`
+}
+
+type CodeSequence struct {
+	Name      string `json:"name"`
+	NextValue int64  `json:"next_value"`
+}
+
+type Domain struct {
+	ID        int32     `json:"id"`
+	Host      string    `json:"host"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Image struct {
 	ID           string    `json:"id"`
 	Filename     string    `json:"filename"`
@@ -74,6 +293,16 @@ type Image struct {
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+type LinkReport struct {
+	ID         int32             `json:"id"`
+	UrlID      int32             `json:"url_id"`
+	Reason     string            `json:"reason"`
+	ReporterIp string            `json:"reporter_ip"`
+	Status     LinkReportsStatus `json:"status"`
+	CreatedAt  time.Time         `json:"created_at"`
+	ReviewedAt sql.NullTime      `json:"reviewed_at"`
+}
+
 type Post struct {
 	ID              string         `json:"id"`
 	Title           string         `json:"title"`
@@ -96,6 +325,32 @@ type PostTag struct {
 	TagID  string `json:"tag_id"`
 }
 
+type QrTemplate struct {
+	ID               int32           `json:"id"`
+	Name             string          `json:"name"`
+	FgColor          string          `json:"fg_color"`
+	BgColor          string          `json:"bg_color"`
+	GradientStops    string          `json:"gradient_stops"`
+	GradientType     string          `json:"gradient_type"`
+	GradientAngle    sql.NullFloat64 `json:"gradient_angle"`
+	GradientCenterX  sql.NullFloat64 `json:"gradient_center_x"`
+	GradientCenterY  sql.NullFloat64 `json:"gradient_center_y"`
+	ModuleShape      string          `json:"module_shape"`
+	FinderOuterShape string          `json:"finder_outer_shape"`
+	FinderInnerShape string          `json:"finder_inner_shape"`
+	FinderOuterColor string          `json:"finder_outer_color"`
+	FinderInnerColor string          `json:"finder_inner_color"`
+	LogoImageID      sql.NullString  `json:"logo_image_id"`
+	LogoSize         int32           `json:"logo_size"`
+	BorderRadius     int32           `json:"border_radius"`
+	ErrorCorrection  string          `json:"error_correction"`
+	QuietZone        sql.NullInt32   `json:"quiet_zone"`
+	Size             int32           `json:"size"`
+	Dpi              int32           `json:"dpi"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
 type Tag struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
@@ -103,10 +358,66 @@ type Tag struct {
 }
 
 type Url struct {
+	ID           int32            `json:"id"`
+	ShortCode    string           `json:"short_code"`
+	OriginalUrl  string           `json:"original_url"`
+	UrlHash      string           `json:"url_hash"`
+	IsCustom     bool             `json:"is_custom"`
+	ExpiresAt    sql.NullTime     `json:"expires_at"`
+	MaxClicks    sql.NullInt32    `json:"max_clicks"`
+	ClickCount   uint32           `json:"click_count"`
+	Disabled     bool             `json:"disabled"`
+	UserID       sql.NullString   `json:"user_id"`
+	PasswordHash sql.NullString   `json:"password_hash"`
+	RedirectMode UrlsRedirectMode `json:"redirect_mode"`
+	Sticky       bool             `json:"sticky"`
+	ForwardQuery bool             `json:"forward_query"`
+	Domain       string           `json:"domain"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+type UrlHealth struct {
+	UrlID               int32         `json:"url_id"`
+	StatusCode          sql.NullInt32 `json:"status_code"`
+	LatencyMs           int32         `json:"latency_ms"`
+	Error               string        `json:"error"`
+	ConsecutiveFailures int32         `json:"consecutive_failures"`
+	LastCheckedAt       time.Time     `json:"last_checked_at"`
+}
+
+type UrlPreview struct {
+	UrlID       int32             `json:"url_id"`
+	Status      UrlPreviewsStatus `json:"status"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	ImageUrl    string            `json:"image_url"`
+	FaviconUrl  string            `json:"favicon_url"`
+	Error       string            `json:"error"`
+	FetchedAt   sql.NullTime      `json:"fetched_at"`
+}
+
+type UrlRule struct {
+	ID          int32        `json:"id"`
+	UrlID       int32        `json:"url_id"`
+	Position    int32        `json:"position"`
+	Destination string       `json:"destination"`
+	Os          string       `json:"os"`
+	Devices     string       `json:"devices"`
+	Languages   string       `json:"languages"`
+	Countries   string       `json:"countries"`
+	StartsAt    sql.NullTime `json:"starts_at"`
+	EndsAt      sql.NullTime `json:"ends_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type UrlVariant struct {
 	ID          int32     `json:"id"`
-	ShortCode   string    `json:"short_code"`
-	OriginalUrl string    `json:"original_url"`
-	UrlHash     string    `json:"url_hash"`
+	UrlID       int32     `json:"url_id"`
+	Position    int32     `json:"position"`
+	Label       string    `json:"label"`
+	Destination string    `json:"destination"`
+	Weight      uint32    `json:"weight"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
@@ -28,6 +28,196 @@ func (q *Queries) AddTagToPost(ctx context.Context, arg AddTagToPostParams) erro
 	return err
 }
 
+const allocateCodeBlock = `-- name: AllocateCodeBlock :execresult
+
+UPDATE code_sequences SET next_value = LAST_INSERT_ID(next_value + ?)
+WHERE name = ?
+`
+
+type AllocateCodeBlockParams struct {
+	Size int64  `json:"size"`
+	Name string `json:"name"`
+}
+
+// Short Code Sequence Queries
+func (q *Queries) AllocateCodeBlock(ctx context.Context, arg AllocateCodeBlockParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, allocateCodeBlock, arg.Size, arg.Name)
+}
+
+const closeLinkReportsForURL = `-- name: CloseLinkReportsForURL :exec
+UPDATE link_reports
+SET status = ?, reviewed_at = ?
+WHERE url_id = ? AND status = 'open'
+`
+
+type CloseLinkReportsForURLParams struct {
+	Status     LinkReportsStatus `json:"status"`
+	ReviewedAt sql.NullTime      `json:"reviewed_at"`
+	UrlID      int32             `json:"url_id"`
+}
+
+func (q *Queries) CloseLinkReportsForURL(ctx context.Context, arg CloseLinkReportsForURLParams) error {
+	_, err := q.db.ExecContext(ctx, closeLinkReportsForURL, arg.Status, arg.ReviewedAt, arg.UrlID)
+	return err
+}
+
+const countBrokenURLs = `-- name: CountBrokenURLs :one
+SELECT COUNT(*) FROM url_health
+WHERE consecutive_failures >= ?
+`
+
+func (q *Queries) CountBrokenURLs(ctx context.Context, consecutiveFailures int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countBrokenURLs, consecutiveFailures)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countClicks = `-- name: CountClicks :one
+SELECT COUNT(*) FROM clicks
+WHERE url_id = ? AND clicked_at >= ? AND clicked_at < ?
+`
+
+type CountClicksParams struct {
+	UrlID     int32     `json:"url_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+func (q *Queries) CountClicks(ctx context.Context, arg CountClicksParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countClicks, arg.UrlID, arg.StartTime, arg.EndTime)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countLinkReports = `-- name: CountLinkReports :one
+SELECT COUNT(*) FROM link_reports
+WHERE status = ?
+`
+
+func (q *Queries) CountLinkReports(ctx context.Context, status LinkReportsStatus) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countLinkReports, status)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countOpenLinkReportsByIP = `-- name: CountOpenLinkReportsByIP :one
+SELECT COUNT(*) FROM link_reports
+WHERE url_id = ? AND reporter_ip = ? AND status = 'open'
+`
+
+type CountOpenLinkReportsByIPParams struct {
+	UrlID      int32  `json:"url_id"`
+	ReporterIp string `json:"reporter_ip"`
+}
+
+func (q *Queries) CountOpenLinkReportsByIP(ctx context.Context, arg CountOpenLinkReportsByIPParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOpenLinkReportsByIP, arg.UrlID, arg.ReporterIp)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSearchURLs = `-- name: CountSearchURLs :one
+SELECT COUNT(*) FROM urls
+WHERE short_code LIKE ? OR original_url LIKE ?
+`
+
+type CountSearchURLsParams struct {
+	ShortCode   string `json:"short_code"`
+	OriginalUrl string `json:"original_url"`
+}
+
+func (q *Queries) CountSearchURLs(ctx context.Context, arg CountSearchURLsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSearchURLs, arg.ShortCode, arg.OriginalUrl)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countURLs = `-- name: CountURLs :one
+SELECT COUNT(*) FROM urls
+`
+
+func (q *Queries) CountURLs(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countURLs)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countURLsByDomain = `-- name: CountURLsByDomain :one
+SELECT COUNT(*) FROM urls
+WHERE domain = ?
+`
+
+func (q *Queries) CountURLsByDomain(ctx context.Context, domain string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countURLsByDomain, domain)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countURLsByUser = `-- name: CountURLsByUser :one
+SELECT COUNT(*) FROM urls
+WHERE user_id = ?
+`
+
+func (q *Queries) CountURLsByUser(ctx context.Context, userID sql.NullString) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countURLsByUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAPIKey = `-- name: CreateAPIKey :exec
+
+INSERT INTO api_keys (
+  id, user_id, name, prefix, key_hash, scopes
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+)
+`
+
+type CreateAPIKeyParams struct {
+	ID      string `json:"id"`
+	UserID  string `json:"user_id"`
+	Name    string `json:"name"`
+	Prefix  string `json:"prefix"`
+	KeyHash string `json:"key_hash"`
+	Scopes  string `json:"scopes"`
+}
+
+// API Key Queries
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) error {
+	_, err := q.db.ExecContext(ctx, createAPIKey,
+		arg.ID,
+		arg.UserID,
+		arg.Name,
+		arg.Prefix,
+		arg.KeyHash,
+		arg.Scopes,
+	)
+	return err
+}
+
+const createBlocklistEntry = `-- name: CreateBlocklistEntry :execresult
+INSERT INTO blocklist_entries (kind, pattern, reason)
+VALUES (?, ?, ?)
+`
+
+type CreateBlocklistEntryParams struct {
+	Kind    BlocklistEntriesKind `json:"kind"`
+	Pattern string               `json:"pattern"`
+	Reason  string               `json:"reason"`
+}
+
+func (q *Queries) CreateBlocklistEntry(ctx context.Context, arg CreateBlocklistEntryParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createBlocklistEntry, arg.Kind, arg.Pattern, arg.Reason)
+}
+
 const createCategory = `-- name: CreateCategory :exec
 
 
@@ -51,6 +241,52 @@ func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams)
 	return err
 }
 
+const createClick = `-- name: CreateClick :exec
+
+INSERT INTO clicks (
+  url_id, clicked_at, referrer, user_agent, browser, os, device, country, variant
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+`
+
+type CreateClickParams struct {
+	UrlID     int32     `json:"url_id"`
+	ClickedAt time.Time `json:"clicked_at"`
+	Referrer  string    `json:"referrer"`
+	UserAgent string    `json:"user_agent"`
+	Browser   string    `json:"browser"`
+	Os        string    `json:"os"`
+	Device    string    `json:"device"`
+	Country   string    `json:"country"`
+	Variant   string    `json:"variant"`
+}
+
+// Click Queries
+func (q *Queries) CreateClick(ctx context.Context, arg CreateClickParams) error {
+	_, err := q.db.ExecContext(ctx, createClick,
+		arg.UrlID,
+		arg.ClickedAt,
+		arg.Referrer,
+		arg.UserAgent,
+		arg.Browser,
+		arg.Os,
+		arg.Device,
+		arg.Country,
+		arg.Variant,
+	)
+	return err
+}
+
+const createDomain = `-- name: CreateDomain :execresult
+INSERT INTO domains (host)
+VALUES (?)
+`
+
+func (q *Queries) CreateDomain(ctx context.Context, host string) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createDomain, host)
+}
+
 const createImage = `-- name: CreateImage :exec
 
 INSERT INTO images (id, filename, original_name, alt_text, title, mime_type, size_bytes, width, height)
@@ -85,6 +321,24 @@ func (q *Queries) CreateImage(ctx context.Context, arg CreateImageParams) error
 	return err
 }
 
+const createLinkReport = `-- name: CreateLinkReport :exec
+
+INSERT INTO link_reports (url_id, reason, reporter_ip)
+VALUES (?, ?, ?)
+`
+
+type CreateLinkReportParams struct {
+	UrlID      int32  `json:"url_id"`
+	Reason     string `json:"reason"`
+	ReporterIp string `json:"reporter_ip"`
+}
+
+// Link Report Queries
+func (q *Queries) CreateLinkReport(ctx context.Context, arg CreateLinkReportParams) error {
+	_, err := q.db.ExecContext(ctx, createLinkReport, arg.UrlID, arg.Reason, arg.ReporterIp)
+	return err
+}
+
 const createPost = `-- name: CreatePost :exec
 
 INSERT INTO posts (
@@ -126,6 +380,64 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) error {
 	return err
 }
 
+const createQRTemplate = `-- name: CreateQRTemplate :execresult
+INSERT INTO qr_templates (
+  name, fg_color, bg_color, gradient_stops, gradient_type, gradient_angle, gradient_center_x, gradient_center_y,
+  module_shape, finder_outer_shape, finder_inner_shape, finder_outer_color, finder_inner_color,
+  logo_image_id, logo_size, border_radius, error_correction, quiet_zone, size, dpi
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+`
+
+type CreateQRTemplateParams struct {
+	Name             string          `json:"name"`
+	FgColor          string          `json:"fg_color"`
+	BgColor          string          `json:"bg_color"`
+	GradientStops    string          `json:"gradient_stops"`
+	GradientType     string          `json:"gradient_type"`
+	GradientAngle    sql.NullFloat64 `json:"gradient_angle"`
+	GradientCenterX  sql.NullFloat64 `json:"gradient_center_x"`
+	GradientCenterY  sql.NullFloat64 `json:"gradient_center_y"`
+	ModuleShape      string          `json:"module_shape"`
+	FinderOuterShape string          `json:"finder_outer_shape"`
+	FinderInnerShape string          `json:"finder_inner_shape"`
+	FinderOuterColor string          `json:"finder_outer_color"`
+	FinderInnerColor string          `json:"finder_inner_color"`
+	LogoImageID      sql.NullString  `json:"logo_image_id"`
+	LogoSize         int32           `json:"logo_size"`
+	BorderRadius     int32           `json:"border_radius"`
+	ErrorCorrection  string          `json:"error_correction"`
+	QuietZone        sql.NullInt32   `json:"quiet_zone"`
+	Size             int32           `json:"size"`
+	Dpi              int32           `json:"dpi"`
+}
+
+func (q *Queries) CreateQRTemplate(ctx context.Context, arg CreateQRTemplateParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createQRTemplate,
+		arg.Name,
+		arg.FgColor,
+		arg.BgColor,
+		arg.GradientStops,
+		arg.GradientType,
+		arg.GradientAngle,
+		arg.GradientCenterX,
+		arg.GradientCenterY,
+		arg.ModuleShape,
+		arg.FinderOuterShape,
+		arg.FinderInnerShape,
+		arg.FinderOuterColor,
+		arg.FinderInnerColor,
+		arg.LogoImageID,
+		arg.LogoSize,
+		arg.BorderRadius,
+		arg.ErrorCorrection,
+		arg.QuietZone,
+		arg.Size,
+		arg.Dpi,
+	)
+}
+
 const createTag = `-- name: CreateTag :exec
 
 INSERT INTO tags (
@@ -149,20 +461,99 @@ func (q *Queries) CreateTag(ctx context.Context, arg CreateTagParams) error {
 
 const createURL = `-- name: CreateURL :execresult
 INSERT INTO urls (
-  short_code, original_url, url_hash
+  short_code, original_url, url_hash, is_custom, expires_at, max_clicks, user_id, password_hash,
+  redirect_mode, forward_query, domain
 ) VALUES (
-  ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 )
 `
 
 type CreateURLParams struct {
-	ShortCode   string `json:"short_code"`
-	OriginalUrl string `json:"original_url"`
-	UrlHash     string `json:"url_hash"`
+	ShortCode    string           `json:"short_code"`
+	OriginalUrl  string           `json:"original_url"`
+	UrlHash      string           `json:"url_hash"`
+	IsCustom     bool             `json:"is_custom"`
+	ExpiresAt    sql.NullTime     `json:"expires_at"`
+	MaxClicks    sql.NullInt32    `json:"max_clicks"`
+	UserID       sql.NullString   `json:"user_id"`
+	PasswordHash sql.NullString   `json:"password_hash"`
+	RedirectMode UrlsRedirectMode `json:"redirect_mode"`
+	ForwardQuery bool             `json:"forward_query"`
+	Domain       string           `json:"domain"`
 }
 
 func (q *Queries) CreateURL(ctx context.Context, arg CreateURLParams) (sql.Result, error) {
-	return q.db.ExecContext(ctx, createURL, arg.ShortCode, arg.OriginalUrl, arg.UrlHash)
+	return q.db.ExecContext(ctx, createURL,
+		arg.ShortCode,
+		arg.OriginalUrl,
+		arg.UrlHash,
+		arg.IsCustom,
+		arg.ExpiresAt,
+		arg.MaxClicks,
+		arg.UserID,
+		arg.PasswordHash,
+		arg.RedirectMode,
+		arg.ForwardQuery,
+		arg.Domain,
+	)
+}
+
+const createURLRule = `-- name: CreateURLRule :execresult
+INSERT INTO url_rules (
+  url_id, position, destination, os, devices, languages, countries, starts_at, ends_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+`
+
+type CreateURLRuleParams struct {
+	UrlID       int32        `json:"url_id"`
+	Position    int32        `json:"position"`
+	Destination string       `json:"destination"`
+	Os          string       `json:"os"`
+	Devices     string       `json:"devices"`
+	Languages   string       `json:"languages"`
+	Countries   string       `json:"countries"`
+	StartsAt    sql.NullTime `json:"starts_at"`
+	EndsAt      sql.NullTime `json:"ends_at"`
+}
+
+func (q *Queries) CreateURLRule(ctx context.Context, arg CreateURLRuleParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createURLRule,
+		arg.UrlID,
+		arg.Position,
+		arg.Destination,
+		arg.Os,
+		arg.Devices,
+		arg.Languages,
+		arg.Countries,
+		arg.StartsAt,
+		arg.EndsAt,
+	)
+}
+
+const createURLVariant = `-- name: CreateURLVariant :exec
+INSERT INTO url_variants (url_id, position, label, destination, weight)
+VALUES (?, ?, ?, ?, ?)
+`
+
+type CreateURLVariantParams struct {
+	UrlID       int32  `json:"url_id"`
+	Position    int32  `json:"position"`
+	Label       string `json:"label"`
+	Destination string `json:"destination"`
+	Weight      uint32 `json:"weight"`
+}
+
+func (q *Queries) CreateURLVariant(ctx context.Context, arg CreateURLVariantParams) error {
+	_, err := q.db.ExecContext(ctx, createURLVariant,
+		arg.UrlID,
+		arg.Position,
+		arg.Label,
+		arg.Destination,
+		arg.Weight,
+	)
+	return err
 }
 
 const createUser = `-- name: CreateUser :exec
@@ -192,6 +583,19 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
 	return err
 }
 
+const deleteBlocklistEntry = `-- name: DeleteBlocklistEntry :execrows
+DELETE FROM blocklist_entries
+WHERE id = ?
+`
+
+func (q *Queries) DeleteBlocklistEntry(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteBlocklistEntry, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteCategory = `-- name: DeleteCategory :exec
 DELETE FROM categories
 WHERE id = ?
@@ -202,6 +606,19 @@ func (q *Queries) DeleteCategory(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteDomain = `-- name: DeleteDomain :execrows
+DELETE FROM domains
+WHERE id = ?
+`
+
+func (q *Queries) DeleteDomain(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDomain, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteImage = `-- name: DeleteImage :exec
 DELETE FROM images
 WHERE id = ?
@@ -222,6 +639,19 @@ func (q *Queries) DeletePost(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteQRTemplate = `-- name: DeleteQRTemplate :execrows
+DELETE FROM qr_templates
+WHERE id = ?
+`
+
+func (q *Queries) DeleteQRTemplate(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteQRTemplate, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteTag = `-- name: DeleteTag :exec
 DELETE FROM tags
 WHERE id = ?
@@ -232,6 +662,110 @@ func (q *Queries) DeleteTag(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteURL = `-- name: DeleteURL :exec
+DELETE FROM urls
+WHERE id = ?
+`
+
+func (q *Queries) DeleteURL(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteURL, id)
+	return err
+}
+
+const deleteURLHealth = `-- name: DeleteURLHealth :exec
+DELETE FROM url_health
+WHERE url_id = ?
+`
+
+func (q *Queries) DeleteURLHealth(ctx context.Context, urlID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteURLHealth, urlID)
+	return err
+}
+
+const deleteURLRule = `-- name: DeleteURLRule :execrows
+DELETE FROM url_rules
+WHERE id = ? AND url_id = ?
+`
+
+type DeleteURLRuleParams struct {
+	ID    int32 `json:"id"`
+	UrlID int32 `json:"url_id"`
+}
+
+func (q *Queries) DeleteURLRule(ctx context.Context, arg DeleteURLRuleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteURLRule, arg.ID, arg.UrlID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteURLVariants = `-- name: DeleteURLVariants :exec
+DELETE FROM url_variants
+WHERE url_id = ?
+`
+
+func (q *Queries) DeleteURLVariants(ctx context.Context, urlID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteURLVariants, urlID)
+	return err
+}
+
+const getAPIKeyByHash = `-- name: GetAPIKeyByHash :one
+SELECT k.id, k.user_id, k.name, k.prefix, k.key_hash, k.scopes, k.last_used_at, k.revoked_at, k.created_at, u.role FROM api_keys k
+JOIN users u ON u.id = k.user_id
+WHERE k.key_hash = ? AND k.revoked_at IS NULL
+LIMIT 1
+`
+
+type GetAPIKeyByHashRow struct {
+	ID         string       `json:"id"`
+	UserID     string       `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"key_hash"`
+	Scopes     string       `json:"scopes"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+	Role       string       `json:"role"`
+}
+
+func (q *Queries) GetAPIKeyByHash(ctx context.Context, keyHash string) (GetAPIKeyByHashRow, error) {
+	row := q.db.QueryRowContext(ctx, getAPIKeyByHash, keyHash)
+	var i GetAPIKeyByHashRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		&i.Scopes,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getBlocklistEntry = `-- name: GetBlocklistEntry :one
+SELECT id, kind, pattern, reason, created_at FROM blocklist_entries
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetBlocklistEntry(ctx context.Context, id int32) (BlocklistEntry, error) {
+	row := q.db.QueryRowContext(ctx, getBlocklistEntry, id)
+	var i BlocklistEntry
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Pattern,
+		&i.Reason,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const getCategory = `-- name: GetCategory :one
 SELECT id, name, slug FROM categories
 WHERE id = ? LIMIT 1
@@ -256,6 +790,18 @@ func (q *Queries) GetCategoryBySlug(ctx context.Context, slug string) (Category,
 	return i, err
 }
 
+const getDomain = `-- name: GetDomain :one
+SELECT id, host, created_at FROM domains
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetDomain(ctx context.Context, id int32) (Domain, error) {
+	row := q.db.QueryRowContext(ctx, getDomain, id)
+	var i Domain
+	err := row.Scan(&i.ID, &i.Host, &i.CreatedAt)
+	return i, err
+}
+
 const getImage = `-- name: GetImage :one
 SELECT id, filename, original_name, alt_text, title, mime_type, size_bytes, width, height, created_at, updated_at FROM images
 WHERE id = ? LIMIT 1
@@ -280,6 +826,33 @@ func (q *Queries) GetImage(ctx context.Context, id string) (Image, error) {
 	return i, err
 }
 
+const getLinkReport = `-- name: GetLinkReport :one
+SELECT r.id, r.url_id, u.domain, u.short_code, r.status FROM link_reports r
+JOIN urls u ON u.id = r.url_id
+WHERE r.id = ? LIMIT 1
+`
+
+type GetLinkReportRow struct {
+	ID        int32             `json:"id"`
+	UrlID     int32             `json:"url_id"`
+	Domain    string            `json:"domain"`
+	ShortCode string            `json:"short_code"`
+	Status    LinkReportsStatus `json:"status"`
+}
+
+func (q *Queries) GetLinkReport(ctx context.Context, id int32) (GetLinkReportRow, error) {
+	row := q.db.QueryRowContext(ctx, getLinkReport, id)
+	var i GetLinkReportRow
+	err := row.Scan(
+		&i.ID,
+		&i.UrlID,
+		&i.Domain,
+		&i.ShortCode,
+		&i.Status,
+	)
+	return i, err
+}
+
 const getPost = `-- name: GetPost :one
 SELECT id, title, slug, content, excerpt, meta_description, keywords, featured_image, status, views, category_id, published_at, created_at, updated_at FROM posts
 WHERE id = ? LIMIT 1
@@ -363,6 +936,78 @@ func (q *Queries) GetPostTags(ctx context.Context, postID string) ([]Tag, error)
 	return items, nil
 }
 
+const getQRTemplate = `-- name: GetQRTemplate :one
+SELECT id, name, fg_color, bg_color, gradient_stops, gradient_type, gradient_angle, gradient_center_x, gradient_center_y, module_shape, finder_outer_shape, finder_inner_shape, finder_outer_color, finder_inner_color, logo_image_id, logo_size, border_radius, error_correction, quiet_zone, size, dpi, created_at, updated_at FROM qr_templates
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetQRTemplate(ctx context.Context, id int32) (QrTemplate, error) {
+	row := q.db.QueryRowContext(ctx, getQRTemplate, id)
+	var i QrTemplate
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.FgColor,
+		&i.BgColor,
+		&i.GradientStops,
+		&i.GradientType,
+		&i.GradientAngle,
+		&i.GradientCenterX,
+		&i.GradientCenterY,
+		&i.ModuleShape,
+		&i.FinderOuterShape,
+		&i.FinderInnerShape,
+		&i.FinderOuterColor,
+		&i.FinderInnerColor,
+		&i.LogoImageID,
+		&i.LogoSize,
+		&i.BorderRadius,
+		&i.ErrorCorrection,
+		&i.QuietZone,
+		&i.Size,
+		&i.Dpi,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getQRTemplateByName = `-- name: GetQRTemplateByName :one
+SELECT id, name, fg_color, bg_color, gradient_stops, gradient_type, gradient_angle, gradient_center_x, gradient_center_y, module_shape, finder_outer_shape, finder_inner_shape, finder_outer_color, finder_inner_color, logo_image_id, logo_size, border_radius, error_correction, quiet_zone, size, dpi, created_at, updated_at FROM qr_templates
+WHERE name = ? LIMIT 1
+`
+
+func (q *Queries) GetQRTemplateByName(ctx context.Context, name string) (QrTemplate, error) {
+	row := q.db.QueryRowContext(ctx, getQRTemplateByName, name)
+	var i QrTemplate
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.FgColor,
+		&i.BgColor,
+		&i.GradientStops,
+		&i.GradientType,
+		&i.GradientAngle,
+		&i.GradientCenterX,
+		&i.GradientCenterY,
+		&i.ModuleShape,
+		&i.FinderOuterShape,
+		&i.FinderInnerShape,
+		&i.FinderOuterColor,
+		&i.FinderInnerColor,
+		&i.LogoImageID,
+		&i.LogoSize,
+		&i.BorderRadius,
+		&i.ErrorCorrection,
+		&i.QuietZone,
+		&i.Size,
+		&i.Dpi,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getTag = `-- name: GetTag :one
 SELECT id, name, slug FROM tags
 WHERE id = ? LIMIT 1
@@ -400,54 +1045,167 @@ func (q *Queries) GetTagBySlug(ctx context.Context, slug string) (Tag, error) {
 }
 
 const getURL = `-- name: GetURL :one
-SELECT id, short_code, original_url, url_hash, created_at FROM urls
-WHERE short_code = ? LIMIT 1
+SELECT id, short_code, original_url, url_hash, is_custom, expires_at, max_clicks, click_count, disabled, user_id, password_hash, redirect_mode, sticky, forward_query, domain, created_at, updated_at FROM urls
+WHERE domain = ? AND short_code = ? LIMIT 1
 `
 
-func (q *Queries) GetURL(ctx context.Context, shortCode string) (Url, error) {
-	row := q.db.QueryRowContext(ctx, getURL, shortCode)
+type GetURLParams struct {
+	Domain    string `json:"domain"`
+	ShortCode string `json:"short_code"`
+}
+
+func (q *Queries) GetURL(ctx context.Context, arg GetURLParams) (Url, error) {
+	row := q.db.QueryRowContext(ctx, getURL, arg.Domain, arg.ShortCode)
 	var i Url
 	err := row.Scan(
 		&i.ID,
 		&i.ShortCode,
 		&i.OriginalUrl,
 		&i.UrlHash,
+		&i.IsCustom,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.ClickCount,
+		&i.Disabled,
+		&i.UserID,
+		&i.PasswordHash,
+		&i.RedirectMode,
+		&i.Sticky,
+		&i.ForwardQuery,
+		&i.Domain,
 		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
 const getURLByHash = `-- name: GetURLByHash :one
-SELECT id, short_code, original_url, url_hash, created_at FROM urls
-WHERE url_hash = ? LIMIT 1
+SELECT id, short_code, original_url, url_hash, is_custom, expires_at, max_clicks, click_count, disabled, user_id, password_hash, redirect_mode, sticky, forward_query, domain, created_at, updated_at FROM urls
+WHERE url_hash = ? AND user_id <=> ? AND domain = ? AND is_custom = FALSE AND disabled = FALSE
+  AND expires_at IS NULL AND max_clicks IS NULL AND password_hash IS NULL
+  AND redirect_mode = '302' AND forward_query = FALSE
+  AND NOT EXISTS (SELECT 1 FROM url_variants v WHERE v.url_id = urls.id)
+  AND NOT EXISTS (SELECT 1 FROM url_rules r WHERE r.url_id = urls.id)
+LIMIT 1
 `
 
-func (q *Queries) GetURLByHash(ctx context.Context, urlHash string) (Url, error) {
-	row := q.db.QueryRowContext(ctx, getURLByHash, urlHash)
+type GetURLByHashParams struct {
+	UrlHash string         `json:"url_hash"`
+	UserID  sql.NullString `json:"user_id"`
+	Domain  string         `json:"domain"`
+}
+
+func (q *Queries) GetURLByHash(ctx context.Context, arg GetURLByHashParams) (Url, error) {
+	row := q.db.QueryRowContext(ctx, getURLByHash, arg.UrlHash, arg.UserID, arg.Domain)
 	var i Url
 	err := row.Scan(
 		&i.ID,
 		&i.ShortCode,
 		&i.OriginalUrl,
 		&i.UrlHash,
+		&i.IsCustom,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.ClickCount,
+		&i.Disabled,
+		&i.UserID,
+		&i.PasswordHash,
+		&i.RedirectMode,
+		&i.Sticky,
+		&i.ForwardQuery,
+		&i.Domain,
 		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, password_hash, role, created_at, updated_at FROM users
-WHERE username = ? LIMIT 1
+const getURLHealth = `-- name: GetURLHealth :one
+
+SELECT url_id, status_code, latency_ms, error, consecutive_failures, last_checked_at FROM url_health
+WHERE url_id = ? LIMIT 1
 `
 
-func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUserByUsername, username)
-	var i User
+// Health Check Queries
+func (q *Queries) GetURLHealth(ctx context.Context, urlID int32) (UrlHealth, error) {
+	row := q.db.QueryRowContext(ctx, getURLHealth, urlID)
+	var i UrlHealth
 	err := row.Scan(
-		&i.ID,
-		&i.Username,
-		&i.PasswordHash,
-		&i.Role,
+		&i.UrlID,
+		&i.StatusCode,
+		&i.LatencyMs,
+		&i.Error,
+		&i.ConsecutiveFailures,
+		&i.LastCheckedAt,
+	)
+	return i, err
+}
+
+const getURLPreview = `-- name: GetURLPreview :one
+
+SELECT url_id, status, title, description, image_url, favicon_url, error, fetched_at FROM url_previews
+WHERE url_id = ? LIMIT 1
+`
+
+// Link Preview Queries
+func (q *Queries) GetURLPreview(ctx context.Context, urlID int32) (UrlPreview, error) {
+	row := q.db.QueryRowContext(ctx, getURLPreview, urlID)
+	var i UrlPreview
+	err := row.Scan(
+		&i.UrlID,
+		&i.Status,
+		&i.Title,
+		&i.Description,
+		&i.ImageUrl,
+		&i.FaviconUrl,
+		&i.Error,
+		&i.FetchedAt,
+	)
+	return i, err
+}
+
+const getURLRule = `-- name: GetURLRule :one
+SELECT id, url_id, position, destination, os, devices, languages, countries, starts_at, ends_at, created_at FROM url_rules
+WHERE id = ? AND url_id = ? LIMIT 1
+`
+
+type GetURLRuleParams struct {
+	ID    int32 `json:"id"`
+	UrlID int32 `json:"url_id"`
+}
+
+func (q *Queries) GetURLRule(ctx context.Context, arg GetURLRuleParams) (UrlRule, error) {
+	row := q.db.QueryRowContext(ctx, getURLRule, arg.ID, arg.UrlID)
+	var i UrlRule
+	err := row.Scan(
+		&i.ID,
+		&i.UrlID,
+		&i.Position,
+		&i.Destination,
+		&i.Os,
+		&i.Devices,
+		&i.Languages,
+		&i.Countries,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserByUsername = `-- name: GetUserByUsername :one
+SELECT id, username, password_hash, role, created_at, updated_at FROM users
+WHERE username = ? LIMIT 1
+`
+
+func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByUsername, username)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.Role,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -465,6 +1223,153 @@ func (q *Queries) IncrementPostViews(ctx context.Context, id string) error {
 	return err
 }
 
+const incrementURLClicks = `-- name: IncrementURLClicks :execrows
+UPDATE urls
+SET click_count = click_count + 1
+WHERE id = ? AND (max_clicks IS NULL OR click_count < max_clicks)
+`
+
+func (q *Queries) IncrementURLClicks(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, incrementURLClicks, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listAPIKeysByUser = `-- name: ListAPIKeysByUser :many
+SELECT id, user_id, name, prefix, key_hash, scopes, last_used_at, revoked_at, created_at FROM api_keys
+WHERE user_id = ?
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListAPIKeysByUser(ctx context.Context, userID string) ([]ApiKey, error) {
+	rows, err := q.db.QueryContext(ctx, listAPIKeysByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiKey
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Prefix,
+			&i.KeyHash,
+			&i.Scopes,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBlocklistEntries = `-- name: ListBlocklistEntries :many
+
+SELECT id, kind, pattern, reason, created_at FROM blocklist_entries
+ORDER BY id
+`
+
+// Blocklist Queries
+func (q *Queries) ListBlocklistEntries(ctx context.Context) ([]BlocklistEntry, error) {
+	rows, err := q.db.QueryContext(ctx, listBlocklistEntries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BlocklistEntry
+	for rows.Next() {
+		var i BlocklistEntry
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Pattern,
+			&i.Reason,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBrokenURLs = `-- name: ListBrokenURLs :many
+SELECT u.domain, u.short_code, u.original_url, h.status_code, h.latency_ms, h.error, h.consecutive_failures, h.last_checked_at
+FROM url_health h
+JOIN urls u ON u.id = h.url_id
+WHERE h.consecutive_failures >= ?
+ORDER BY h.consecutive_failures DESC, h.url_id
+LIMIT ? OFFSET ?
+`
+
+type ListBrokenURLsParams struct {
+	MinFailures int32 `json:"min_failures"`
+	Limit       int32 `json:"limit"`
+	Offset      int32 `json:"offset"`
+}
+
+type ListBrokenURLsRow struct {
+	Domain              string        `json:"domain"`
+	ShortCode           string        `json:"short_code"`
+	OriginalUrl         string        `json:"original_url"`
+	StatusCode          sql.NullInt32 `json:"status_code"`
+	LatencyMs           int32         `json:"latency_ms"`
+	Error               string        `json:"error"`
+	ConsecutiveFailures int32         `json:"consecutive_failures"`
+	LastCheckedAt       time.Time     `json:"last_checked_at"`
+}
+
+func (q *Queries) ListBrokenURLs(ctx context.Context, arg ListBrokenURLsParams) ([]ListBrokenURLsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBrokenURLs, arg.MinFailures, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBrokenURLsRow
+	for rows.Next() {
+		var i ListBrokenURLsRow
+		if err := rows.Scan(
+			&i.Domain,
+			&i.ShortCode,
+			&i.OriginalUrl,
+			&i.StatusCode,
+			&i.LatencyMs,
+			&i.Error,
+			&i.ConsecutiveFailures,
+			&i.LastCheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listCategories = `-- name: ListCategories :many
 SELECT id, name, slug FROM categories
 ORDER BY name
@@ -478,8 +1383,760 @@ func (q *Queries) ListCategories(ctx context.Context) ([]Category, error) {
 	defer rows.Close()
 	var items []Category
 	for rows.Next() {
-		var i Category
-		if err := rows.Scan(&i.ID, &i.Name, &i.Slug); err != nil {
+		var i Category
+		if err := rows.Scan(&i.ID, &i.Name, &i.Slug); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClickBrowsers = `-- name: ListClickBrowsers :many
+SELECT browser, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= ? AND clicked_at < ?
+GROUP BY browser
+ORDER BY clicks DESC
+LIMIT 10
+`
+
+type ListClickBrowsersParams struct {
+	UrlID     int32     `json:"url_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type ListClickBrowsersRow struct {
+	Browser string `json:"browser"`
+	Clicks  int64  `json:"clicks"`
+}
+
+func (q *Queries) ListClickBrowsers(ctx context.Context, arg ListClickBrowsersParams) ([]ListClickBrowsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listClickBrowsers, arg.UrlID, arg.StartTime, arg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListClickBrowsersRow
+	for rows.Next() {
+		var i ListClickBrowsersRow
+		if err := rows.Scan(&i.Browser, &i.Clicks); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClickCountries = `-- name: ListClickCountries :many
+SELECT country, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= ? AND clicked_at < ?
+GROUP BY country
+ORDER BY clicks DESC
+LIMIT 10
+`
+
+type ListClickCountriesParams struct {
+	UrlID     int32     `json:"url_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type ListClickCountriesRow struct {
+	Country string `json:"country"`
+	Clicks  int64  `json:"clicks"`
+}
+
+func (q *Queries) ListClickCountries(ctx context.Context, arg ListClickCountriesParams) ([]ListClickCountriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listClickCountries, arg.UrlID, arg.StartTime, arg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListClickCountriesRow
+	for rows.Next() {
+		var i ListClickCountriesRow
+		if err := rows.Scan(&i.Country, &i.Clicks); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClickDevices = `-- name: ListClickDevices :many
+SELECT device, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= ? AND clicked_at < ?
+GROUP BY device
+ORDER BY clicks DESC
+`
+
+type ListClickDevicesParams struct {
+	UrlID     int32     `json:"url_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type ListClickDevicesRow struct {
+	Device string `json:"device"`
+	Clicks int64  `json:"clicks"`
+}
+
+func (q *Queries) ListClickDevices(ctx context.Context, arg ListClickDevicesParams) ([]ListClickDevicesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listClickDevices, arg.UrlID, arg.StartTime, arg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListClickDevicesRow
+	for rows.Next() {
+		var i ListClickDevicesRow
+		if err := rows.Scan(&i.Device, &i.Clicks); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClickOperatingSystems = `-- name: ListClickOperatingSystems :many
+SELECT os, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= ? AND clicked_at < ?
+GROUP BY os
+ORDER BY clicks DESC
+LIMIT 10
+`
+
+type ListClickOperatingSystemsParams struct {
+	UrlID     int32     `json:"url_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type ListClickOperatingSystemsRow struct {
+	Os     string `json:"os"`
+	Clicks int64  `json:"clicks"`
+}
+
+func (q *Queries) ListClickOperatingSystems(ctx context.Context, arg ListClickOperatingSystemsParams) ([]ListClickOperatingSystemsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listClickOperatingSystems, arg.UrlID, arg.StartTime, arg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListClickOperatingSystemsRow
+	for rows.Next() {
+		var i ListClickOperatingSystemsRow
+		if err := rows.Scan(&i.Os, &i.Clicks); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClickVariants = `-- name: ListClickVariants :many
+SELECT variant, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= ? AND clicked_at < ?
+GROUP BY variant
+ORDER BY variant
+`
+
+type ListClickVariantsParams struct {
+	UrlID     int32     `json:"url_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type ListClickVariantsRow struct {
+	Variant string `json:"variant"`
+	Clicks  int64  `json:"clicks"`
+}
+
+func (q *Queries) ListClickVariants(ctx context.Context, arg ListClickVariantsParams) ([]ListClickVariantsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listClickVariants, arg.UrlID, arg.StartTime, arg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListClickVariantsRow
+	for rows.Next() {
+		var i ListClickVariantsRow
+		if err := rows.Scan(&i.Variant, &i.Clicks); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClicksByDay = `-- name: ListClicksByDay :many
+SELECT DATE_FORMAT(clicked_at, '%Y-%m-%d') AS bucket, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= ? AND clicked_at < ?
+GROUP BY bucket
+ORDER BY bucket
+`
+
+type ListClicksByDayParams struct {
+	UrlID     int32     `json:"url_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type ListClicksByDayRow struct {
+	Bucket interface{} `json:"bucket"`
+	Clicks int64       `json:"clicks"`
+}
+
+func (q *Queries) ListClicksByDay(ctx context.Context, arg ListClicksByDayParams) ([]ListClicksByDayRow, error) {
+	rows, err := q.db.QueryContext(ctx, listClicksByDay, arg.UrlID, arg.StartTime, arg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListClicksByDayRow
+	for rows.Next() {
+		var i ListClicksByDayRow
+		if err := rows.Scan(&i.Bucket, &i.Clicks); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClicksByHour = `-- name: ListClicksByHour :many
+SELECT DATE_FORMAT(clicked_at, '%Y-%m-%d %H:00') AS bucket, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= ? AND clicked_at < ?
+GROUP BY bucket
+ORDER BY bucket
+`
+
+type ListClicksByHourParams struct {
+	UrlID     int32     `json:"url_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type ListClicksByHourRow struct {
+	Bucket interface{} `json:"bucket"`
+	Clicks int64       `json:"clicks"`
+}
+
+func (q *Queries) ListClicksByHour(ctx context.Context, arg ListClicksByHourParams) ([]ListClicksByHourRow, error) {
+	rows, err := q.db.QueryContext(ctx, listClicksByHour, arg.UrlID, arg.StartTime, arg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListClicksByHourRow
+	for rows.Next() {
+		var i ListClicksByHourRow
+		if err := rows.Scan(&i.Bucket, &i.Clicks); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClicksByWeek = `-- name: ListClicksByWeek :many
+SELECT DATE_FORMAT(DATE_SUB(DATE(clicked_at), INTERVAL WEEKDAY(clicked_at) DAY), '%Y-%m-%d') AS bucket, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= ? AND clicked_at < ?
+GROUP BY bucket
+ORDER BY bucket
+`
+
+type ListClicksByWeekParams struct {
+	UrlID     int32     `json:"url_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type ListClicksByWeekRow struct {
+	Bucket interface{} `json:"bucket"`
+	Clicks int64       `json:"clicks"`
+}
+
+func (q *Queries) ListClicksByWeek(ctx context.Context, arg ListClicksByWeekParams) ([]ListClicksByWeekRow, error) {
+	rows, err := q.db.QueryContext(ctx, listClicksByWeek, arg.UrlID, arg.StartTime, arg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListClicksByWeekRow
+	for rows.Next() {
+		var i ListClicksByWeekRow
+		if err := rows.Scan(&i.Bucket, &i.Clicks); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDomains = `-- name: ListDomains :many
+
+SELECT id, host, created_at FROM domains
+ORDER BY host
+`
+
+// Domain Queries
+func (q *Queries) ListDomains(ctx context.Context) ([]Domain, error) {
+	rows, err := q.db.QueryContext(ctx, listDomains)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Domain
+	for rows.Next() {
+		var i Domain
+		if err := rows.Scan(&i.ID, &i.Host, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listImages = `-- name: ListImages :many
+SELECT id, filename, original_name, alt_text, title, mime_type, size_bytes, width, height, created_at, updated_at FROM images
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListImages(ctx context.Context) ([]Image, error) {
+	rows, err := q.db.QueryContext(ctx, listImages)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Image
+	for rows.Next() {
+		var i Image
+		if err := rows.Scan(
+			&i.ID,
+			&i.Filename,
+			&i.OriginalName,
+			&i.AltText,
+			&i.Title,
+			&i.MimeType,
+			&i.SizeBytes,
+			&i.Width,
+			&i.Height,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLinkReports = `-- name: ListLinkReports :many
+SELECT r.id, r.url_id, u.domain, u.short_code, u.original_url, u.disabled, r.reason, r.reporter_ip, r.status, r.created_at, r.reviewed_at
+FROM link_reports r
+JOIN urls u ON u.id = r.url_id
+WHERE r.status = ?
+ORDER BY r.created_at, r.id
+LIMIT ? OFFSET ?
+`
+
+type ListLinkReportsParams struct {
+	Status LinkReportsStatus `json:"status"`
+	Limit  int32             `json:"limit"`
+	Offset int32             `json:"offset"`
+}
+
+type ListLinkReportsRow struct {
+	ID          int32             `json:"id"`
+	UrlID       int32             `json:"url_id"`
+	Domain      string            `json:"domain"`
+	ShortCode   string            `json:"short_code"`
+	OriginalUrl string            `json:"original_url"`
+	Disabled    bool              `json:"disabled"`
+	Reason      string            `json:"reason"`
+	ReporterIp  string            `json:"reporter_ip"`
+	Status      LinkReportsStatus `json:"status"`
+	CreatedAt   time.Time         `json:"created_at"`
+	ReviewedAt  sql.NullTime      `json:"reviewed_at"`
+}
+
+func (q *Queries) ListLinkReports(ctx context.Context, arg ListLinkReportsParams) ([]ListLinkReportsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLinkReports, arg.Status, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLinkReportsRow
+	for rows.Next() {
+		var i ListLinkReportsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UrlID,
+			&i.Domain,
+			&i.ShortCode,
+			&i.OriginalUrl,
+			&i.Disabled,
+			&i.Reason,
+			&i.ReporterIp,
+			&i.Status,
+			&i.CreatedAt,
+			&i.ReviewedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPosts = `-- name: ListPosts :many
+SELECT id, title, slug, content, excerpt, meta_description, keywords, featured_image, status, views, category_id, published_at, created_at, updated_at FROM posts
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListPosts(ctx context.Context) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, listPosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Slug,
+			&i.Content,
+			&i.Excerpt,
+			&i.MetaDescription,
+			&i.Keywords,
+			&i.FeaturedImage,
+			&i.Status,
+			&i.Views,
+			&i.CategoryID,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPublishedPosts = `-- name: ListPublishedPosts :many
+SELECT id, title, slug, content, excerpt, meta_description, keywords, featured_image, status, views, category_id, published_at, created_at, updated_at FROM posts
+WHERE status = 'published'
+ORDER BY published_at DESC
+`
+
+func (q *Queries) ListPublishedPosts(ctx context.Context) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, listPublishedPosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Slug,
+			&i.Content,
+			&i.Excerpt,
+			&i.MetaDescription,
+			&i.Keywords,
+			&i.FeaturedImage,
+			&i.Status,
+			&i.Views,
+			&i.CategoryID,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPublishedPostsWithCategory = `-- name: ListPublishedPostsWithCategory :many
+SELECT p.id, p.title, p.slug, p.content, p.excerpt, p.meta_description, p.keywords, p.featured_image, p.status, p.views, p.category_id, p.published_at, p.created_at, p.updated_at, c.name AS category_name, c.slug AS category_slug
+FROM posts p
+LEFT JOIN categories c ON p.category_id = c.id
+WHERE p.status = 'published'
+ORDER BY p.published_at DESC
+`
+
+type ListPublishedPostsWithCategoryRow struct {
+	ID              string         `json:"id"`
+	Title           string         `json:"title"`
+	Slug            string         `json:"slug"`
+	Content         string         `json:"content"`
+	Excerpt         sql.NullString `json:"excerpt"`
+	MetaDescription sql.NullString `json:"meta_description"`
+	Keywords        sql.NullString `json:"keywords"`
+	FeaturedImage   sql.NullString `json:"featured_image"`
+	Status          PostsStatus    `json:"status"`
+	Views           uint32         `json:"views"`
+	CategoryID      sql.NullString `json:"category_id"`
+	PublishedAt     sql.NullTime   `json:"published_at"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	CategoryName    sql.NullString `json:"category_name"`
+	CategorySlug    sql.NullString `json:"category_slug"`
+}
+
+func (q *Queries) ListPublishedPostsWithCategory(ctx context.Context) ([]ListPublishedPostsWithCategoryRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPublishedPostsWithCategory)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPublishedPostsWithCategoryRow
+	for rows.Next() {
+		var i ListPublishedPostsWithCategoryRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Slug,
+			&i.Content,
+			&i.Excerpt,
+			&i.MetaDescription,
+			&i.Keywords,
+			&i.FeaturedImage,
+			&i.Status,
+			&i.Views,
+			&i.CategoryID,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CategoryName,
+			&i.CategorySlug,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listQRTemplates = `-- name: ListQRTemplates :many
+
+SELECT id, name, fg_color, bg_color, gradient_stops, gradient_type, gradient_angle, gradient_center_x, gradient_center_y, module_shape, finder_outer_shape, finder_inner_shape, finder_outer_color, finder_inner_color, logo_image_id, logo_size, border_radius, error_correction, quiet_zone, size, dpi, created_at, updated_at FROM qr_templates
+ORDER BY name
+`
+
+// QR Template Queries
+func (q *Queries) ListQRTemplates(ctx context.Context) ([]QrTemplate, error) {
+	rows, err := q.db.QueryContext(ctx, listQRTemplates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QrTemplate
+	for rows.Next() {
+		var i QrTemplate
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.FgColor,
+			&i.BgColor,
+			&i.GradientStops,
+			&i.GradientType,
+			&i.GradientAngle,
+			&i.GradientCenterX,
+			&i.GradientCenterY,
+			&i.ModuleShape,
+			&i.FinderOuterShape,
+			&i.FinderInnerShape,
+			&i.FinderOuterColor,
+			&i.FinderInnerColor,
+			&i.LogoImageID,
+			&i.LogoSize,
+			&i.BorderRadius,
+			&i.ErrorCorrection,
+			&i.QuietZone,
+			&i.Size,
+			&i.Dpi,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT id, name, slug FROM tags
+ORDER BY name
+`
+
+func (q *Queries) ListTags(ctx context.Context) ([]Tag, error) {
+	rows, err := q.db.QueryContext(ctx, listTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tag
+	for rows.Next() {
+		var i Tag
+		if err := rows.Scan(&i.ID, &i.Name, &i.Slug); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listURLRules = `-- name: ListURLRules :many
+
+SELECT id, url_id, position, destination, os, devices, languages, countries, starts_at, ends_at, created_at FROM url_rules
+WHERE url_id = ?
+ORDER BY position, id
+`
+
+// Redirect Rule Queries
+func (q *Queries) ListURLRules(ctx context.Context, urlID int32) ([]UrlRule, error) {
+	rows, err := q.db.QueryContext(ctx, listURLRules, urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UrlRule
+	for rows.Next() {
+		var i UrlRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.UrlID,
+			&i.Position,
+			&i.Destination,
+			&i.Os,
+			&i.Devices,
+			&i.Languages,
+			&i.Countries,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.CreatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -493,32 +2150,31 @@ func (q *Queries) ListCategories(ctx context.Context) ([]Category, error) {
 	return items, nil
 }
 
-const listImages = `-- name: ListImages :many
-SELECT id, filename, original_name, alt_text, title, mime_type, size_bytes, width, height, created_at, updated_at FROM images
-ORDER BY created_at DESC
+const listURLVariants = `-- name: ListURLVariants :many
+
+SELECT id, url_id, position, label, destination, weight, created_at FROM url_variants
+WHERE url_id = ?
+ORDER BY position, id
 `
 
-func (q *Queries) ListImages(ctx context.Context) ([]Image, error) {
-	rows, err := q.db.QueryContext(ctx, listImages)
+// Destination Variant Queries
+func (q *Queries) ListURLVariants(ctx context.Context, urlID int32) ([]UrlVariant, error) {
+	rows, err := q.db.QueryContext(ctx, listURLVariants, urlID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Image
+	var items []UrlVariant
 	for rows.Next() {
-		var i Image
+		var i UrlVariant
 		if err := rows.Scan(
 			&i.ID,
-			&i.Filename,
-			&i.OriginalName,
-			&i.AltText,
-			&i.Title,
-			&i.MimeType,
-			&i.SizeBytes,
-			&i.Width,
-			&i.Height,
+			&i.UrlID,
+			&i.Position,
+			&i.Label,
+			&i.Destination,
+			&i.Weight,
 			&i.CreatedAt,
-			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -533,33 +2189,44 @@ func (q *Queries) ListImages(ctx context.Context) ([]Image, error) {
 	return items, nil
 }
 
-const listPosts = `-- name: ListPosts :many
-SELECT id, title, slug, content, excerpt, meta_description, keywords, featured_image, status, views, category_id, published_at, created_at, updated_at FROM posts
+const listURLs = `-- name: ListURLs :many
+
+SELECT id, short_code, original_url, url_hash, is_custom, expires_at, max_clicks, click_count, disabled, user_id, password_hash, redirect_mode, sticky, forward_query, domain, created_at, updated_at FROM urls
 ORDER BY created_at DESC
+LIMIT ? OFFSET ?
 `
 
-func (q *Queries) ListPosts(ctx context.Context) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, listPosts)
+type ListURLsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+// URL Admin Queries
+func (q *Queries) ListURLs(ctx context.Context, arg ListURLsParams) ([]Url, error) {
+	rows, err := q.db.QueryContext(ctx, listURLs, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []Url
 	for rows.Next() {
-		var i Post
+		var i Url
 		if err := rows.Scan(
 			&i.ID,
-			&i.Title,
-			&i.Slug,
-			&i.Content,
-			&i.Excerpt,
-			&i.MetaDescription,
-			&i.Keywords,
-			&i.FeaturedImage,
-			&i.Status,
-			&i.Views,
-			&i.CategoryID,
-			&i.PublishedAt,
+			&i.ShortCode,
+			&i.OriginalUrl,
+			&i.UrlHash,
+			&i.IsCustom,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.ClickCount,
+			&i.Disabled,
+			&i.UserID,
+			&i.PasswordHash,
+			&i.RedirectMode,
+			&i.Sticky,
+			&i.ForwardQuery,
+			&i.Domain,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -576,34 +2243,44 @@ func (q *Queries) ListPosts(ctx context.Context) ([]Post, error) {
 	return items, nil
 }
 
-const listPublishedPosts = `-- name: ListPublishedPosts :many
-SELECT id, title, slug, content, excerpt, meta_description, keywords, featured_image, status, views, category_id, published_at, created_at, updated_at FROM posts
-WHERE status = 'published'
-ORDER BY published_at DESC
+const listURLsByUser = `-- name: ListURLsByUser :many
+SELECT id, short_code, original_url, url_hash, is_custom, expires_at, max_clicks, click_count, disabled, user_id, password_hash, redirect_mode, sticky, forward_query, domain, created_at, updated_at FROM urls
+WHERE user_id = ?
+ORDER BY created_at DESC
+LIMIT ? OFFSET ?
 `
 
-func (q *Queries) ListPublishedPosts(ctx context.Context) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, listPublishedPosts)
+type ListURLsByUserParams struct {
+	UserID sql.NullString `json:"user_id"`
+	Limit  int32          `json:"limit"`
+	Offset int32          `json:"offset"`
+}
+
+func (q *Queries) ListURLsByUser(ctx context.Context, arg ListURLsByUserParams) ([]Url, error) {
+	rows, err := q.db.QueryContext(ctx, listURLsByUser, arg.UserID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []Url
 	for rows.Next() {
-		var i Post
+		var i Url
 		if err := rows.Scan(
 			&i.ID,
-			&i.Title,
-			&i.Slug,
-			&i.Content,
-			&i.Excerpt,
-			&i.MetaDescription,
-			&i.Keywords,
-			&i.FeaturedImage,
-			&i.Status,
-			&i.Views,
-			&i.CategoryID,
-			&i.PublishedAt,
+			&i.ShortCode,
+			&i.OriginalUrl,
+			&i.UrlHash,
+			&i.IsCustom,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.ClickCount,
+			&i.Disabled,
+			&i.UserID,
+			&i.PasswordHash,
+			&i.RedirectMode,
+			&i.Sticky,
+			&i.ForwardQuery,
+			&i.Domain,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -620,59 +2297,43 @@ func (q *Queries) ListPublishedPosts(ctx context.Context) ([]Post, error) {
 	return items, nil
 }
 
-const listPublishedPostsWithCategory = `-- name: ListPublishedPostsWithCategory :many
-SELECT p.id, p.title, p.slug, p.content, p.excerpt, p.meta_description, p.keywords, p.featured_image, p.status, p.views, p.category_id, p.published_at, p.created_at, p.updated_at, c.name AS category_name, c.slug AS category_slug
-FROM posts p
-LEFT JOIN categories c ON p.category_id = c.id
-WHERE p.status = 'published'
-ORDER BY p.published_at DESC
+const listURLsForHealthCheck = `-- name: ListURLsForHealthCheck :many
+SELECT u.id, u.domain, u.short_code, u.original_url, h.consecutive_failures FROM urls u
+LEFT JOIN url_health h ON h.url_id = u.id
+WHERE u.disabled = FALSE AND (u.expires_at IS NULL OR u.expires_at > NOW())
+  AND (h.last_checked_at IS NULL OR h.last_checked_at < ?)
+ORDER BY h.last_checked_at, u.id
+LIMIT ?
 `
 
-type ListPublishedPostsWithCategoryRow struct {
-	ID              string         `json:"id"`
-	Title           string         `json:"title"`
-	Slug            string         `json:"slug"`
-	Content         string         `json:"content"`
-	Excerpt         sql.NullString `json:"excerpt"`
-	MetaDescription sql.NullString `json:"meta_description"`
-	Keywords        sql.NullString `json:"keywords"`
-	FeaturedImage   sql.NullString `json:"featured_image"`
-	Status          PostsStatus    `json:"status"`
-	Views           uint32         `json:"views"`
-	CategoryID      sql.NullString `json:"category_id"`
-	PublishedAt     sql.NullTime   `json:"published_at"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	CategoryName    sql.NullString `json:"category_name"`
-	CategorySlug    sql.NullString `json:"category_slug"`
+type ListURLsForHealthCheckParams struct {
+	CheckedBefore time.Time `json:"checked_before"`
+	Limit         int32     `json:"limit"`
 }
 
-func (q *Queries) ListPublishedPostsWithCategory(ctx context.Context) ([]ListPublishedPostsWithCategoryRow, error) {
-	rows, err := q.db.QueryContext(ctx, listPublishedPostsWithCategory)
+type ListURLsForHealthCheckRow struct {
+	ID                  int32         `json:"id"`
+	Domain              string        `json:"domain"`
+	ShortCode           string        `json:"short_code"`
+	OriginalUrl         string        `json:"original_url"`
+	ConsecutiveFailures sql.NullInt32 `json:"consecutive_failures"`
+}
+
+func (q *Queries) ListURLsForHealthCheck(ctx context.Context, arg ListURLsForHealthCheckParams) ([]ListURLsForHealthCheckRow, error) {
+	rows, err := q.db.QueryContext(ctx, listURLsForHealthCheck, arg.CheckedBefore, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListPublishedPostsWithCategoryRow
+	var items []ListURLsForHealthCheckRow
 	for rows.Next() {
-		var i ListPublishedPostsWithCategoryRow
+		var i ListURLsForHealthCheckRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.Title,
-			&i.Slug,
-			&i.Content,
-			&i.Excerpt,
-			&i.MetaDescription,
-			&i.Keywords,
-			&i.FeaturedImage,
-			&i.Status,
-			&i.Views,
-			&i.CategoryID,
-			&i.PublishedAt,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.CategoryName,
-			&i.CategorySlug,
+			&i.Domain,
+			&i.ShortCode,
+			&i.OriginalUrl,
+			&i.ConsecutiveFailures,
 		); err != nil {
 			return nil, err
 		}
@@ -687,21 +2348,82 @@ func (q *Queries) ListPublishedPostsWithCategory(ctx context.Context) ([]ListPub
 	return items, nil
 }
 
-const listTags = `-- name: ListTags :many
-SELECT id, name, slug FROM tags
-ORDER BY name
+const removeTagsFromPost = `-- name: RemoveTagsFromPost :exec
+DELETE FROM post_tags
+WHERE post_id = ?
 `
 
-func (q *Queries) ListTags(ctx context.Context) ([]Tag, error) {
-	rows, err := q.db.QueryContext(ctx, listTags)
+func (q *Queries) RemoveTagsFromPost(ctx context.Context, postID string) error {
+	_, err := q.db.ExecContext(ctx, removeTagsFromPost, postID)
+	return err
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = ? AND user_id = ? AND revoked_at IS NULL
+`
+
+type RevokeAPIKeyParams struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeAPIKey, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchURLs = `-- name: SearchURLs :many
+SELECT id, short_code, original_url, url_hash, is_custom, expires_at, max_clicks, click_count, disabled, user_id, password_hash, redirect_mode, sticky, forward_query, domain, created_at, updated_at FROM urls
+WHERE short_code LIKE ? OR original_url LIKE ?
+ORDER BY created_at DESC
+LIMIT ? OFFSET ?
+`
+
+type SearchURLsParams struct {
+	ShortCode   string `json:"short_code"`
+	OriginalUrl string `json:"original_url"`
+	Limit       int32  `json:"limit"`
+	Offset      int32  `json:"offset"`
+}
+
+func (q *Queries) SearchURLs(ctx context.Context, arg SearchURLsParams) ([]Url, error) {
+	rows, err := q.db.QueryContext(ctx, searchURLs,
+		arg.ShortCode,
+		arg.OriginalUrl,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Tag
+	var items []Url
 	for rows.Next() {
-		var i Tag
-		if err := rows.Scan(&i.ID, &i.Name, &i.Slug); err != nil {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.ShortCode,
+			&i.OriginalUrl,
+			&i.UrlHash,
+			&i.IsCustom,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.ClickCount,
+			&i.Disabled,
+			&i.UserID,
+			&i.PasswordHash,
+			&i.RedirectMode,
+			&i.Sticky,
+			&i.ForwardQuery,
+			&i.Domain,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -715,13 +2437,126 @@ func (q *Queries) ListTags(ctx context.Context) ([]Tag, error) {
 	return items, nil
 }
 
-const removeTagsFromPost = `-- name: RemoveTagsFromPost :exec
-DELETE FROM post_tags
-WHERE post_id = ?
+const setLinkReportStatus = `-- name: SetLinkReportStatus :execrows
+UPDATE link_reports
+SET status = ?, reviewed_at = ?
+WHERE id = ?
 `
 
-func (q *Queries) RemoveTagsFromPost(ctx context.Context, postID string) error {
-	_, err := q.db.ExecContext(ctx, removeTagsFromPost, postID)
+type SetLinkReportStatusParams struct {
+	Status     LinkReportsStatus `json:"status"`
+	ReviewedAt sql.NullTime      `json:"reviewed_at"`
+	ID         int32             `json:"id"`
+}
+
+func (q *Queries) SetLinkReportStatus(ctx context.Context, arg SetLinkReportStatusParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setLinkReportStatus, arg.Status, arg.ReviewedAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setURLDisabled = `-- name: SetURLDisabled :exec
+UPDATE urls
+SET disabled = ?
+WHERE id = ?
+`
+
+type SetURLDisabledParams struct {
+	Disabled bool  `json:"disabled"`
+	ID       int32 `json:"id"`
+}
+
+func (q *Queries) SetURLDisabled(ctx context.Context, arg SetURLDisabledParams) error {
+	_, err := q.db.ExecContext(ctx, setURLDisabled, arg.Disabled, arg.ID)
+	return err
+}
+
+const setURLForwardQuery = `-- name: SetURLForwardQuery :exec
+UPDATE urls
+SET forward_query = ?
+WHERE id = ?
+`
+
+type SetURLForwardQueryParams struct {
+	ForwardQuery bool  `json:"forward_query"`
+	ID           int32 `json:"id"`
+}
+
+func (q *Queries) SetURLForwardQuery(ctx context.Context, arg SetURLForwardQueryParams) error {
+	_, err := q.db.ExecContext(ctx, setURLForwardQuery, arg.ForwardQuery, arg.ID)
+	return err
+}
+
+const setURLRedirectMode = `-- name: SetURLRedirectMode :exec
+UPDATE urls
+SET redirect_mode = ?
+WHERE id = ?
+`
+
+type SetURLRedirectModeParams struct {
+	RedirectMode UrlsRedirectMode `json:"redirect_mode"`
+	ID           int32            `json:"id"`
+}
+
+func (q *Queries) SetURLRedirectMode(ctx context.Context, arg SetURLRedirectModeParams) error {
+	_, err := q.db.ExecContext(ctx, setURLRedirectMode, arg.RedirectMode, arg.ID)
+	return err
+}
+
+const setURLSticky = `-- name: SetURLSticky :exec
+UPDATE urls
+SET sticky = ?
+WHERE id = ?
+`
+
+type SetURLStickyParams struct {
+	Sticky bool  `json:"sticky"`
+	ID     int32 `json:"id"`
+}
+
+func (q *Queries) SetURLSticky(ctx context.Context, arg SetURLStickyParams) error {
+	_, err := q.db.ExecContext(ctx, setURLSticky, arg.Sticky, arg.ID)
+	return err
+}
+
+const touchAPIKey = `-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = ?
+WHERE id = ?
+`
+
+type TouchAPIKeyParams struct {
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	ID         string       `json:"id"`
+}
+
+func (q *Queries) TouchAPIKey(ctx context.Context, arg TouchAPIKeyParams) error {
+	_, err := q.db.ExecContext(ctx, touchAPIKey, arg.LastUsedAt, arg.ID)
+	return err
+}
+
+const updateBlocklistEntry = `-- name: UpdateBlocklistEntry :exec
+UPDATE blocklist_entries
+SET kind = ?, pattern = ?, reason = ?
+WHERE id = ?
+`
+
+type UpdateBlocklistEntryParams struct {
+	Kind    BlocklistEntriesKind `json:"kind"`
+	Pattern string               `json:"pattern"`
+	Reason  string               `json:"reason"`
+	ID      int32                `json:"id"`
+}
+
+func (q *Queries) UpdateBlocklistEntry(ctx context.Context, arg UpdateBlocklistEntryParams) error {
+	_, err := q.db.ExecContext(ctx, updateBlocklistEntry,
+		arg.Kind,
+		arg.Pattern,
+		arg.Reason,
+		arg.ID,
+	)
 	return err
 }
 
@@ -812,6 +2647,66 @@ func (q *Queries) UpdatePostViews(ctx context.Context, arg UpdatePostViewsParams
 	return err
 }
 
+const updateQRTemplate = `-- name: UpdateQRTemplate :exec
+UPDATE qr_templates
+SET name = ?, fg_color = ?, bg_color = ?, gradient_stops = ?, gradient_type = ?, gradient_angle = ?,
+  gradient_center_x = ?, gradient_center_y = ?, module_shape = ?, finder_outer_shape = ?, finder_inner_shape = ?,
+  finder_outer_color = ?, finder_inner_color = ?, logo_image_id = ?, logo_size = ?, border_radius = ?,
+  error_correction = ?, quiet_zone = ?, size = ?, dpi = ?
+WHERE id = ?
+`
+
+type UpdateQRTemplateParams struct {
+	Name             string          `json:"name"`
+	FgColor          string          `json:"fg_color"`
+	BgColor          string          `json:"bg_color"`
+	GradientStops    string          `json:"gradient_stops"`
+	GradientType     string          `json:"gradient_type"`
+	GradientAngle    sql.NullFloat64 `json:"gradient_angle"`
+	GradientCenterX  sql.NullFloat64 `json:"gradient_center_x"`
+	GradientCenterY  sql.NullFloat64 `json:"gradient_center_y"`
+	ModuleShape      string          `json:"module_shape"`
+	FinderOuterShape string          `json:"finder_outer_shape"`
+	FinderInnerShape string          `json:"finder_inner_shape"`
+	FinderOuterColor string          `json:"finder_outer_color"`
+	FinderInnerColor string          `json:"finder_inner_color"`
+	LogoImageID      sql.NullString  `json:"logo_image_id"`
+	LogoSize         int32           `json:"logo_size"`
+	BorderRadius     int32           `json:"border_radius"`
+	ErrorCorrection  string          `json:"error_correction"`
+	QuietZone        sql.NullInt32   `json:"quiet_zone"`
+	Size             int32           `json:"size"`
+	Dpi              int32           `json:"dpi"`
+	ID               int32           `json:"id"`
+}
+
+func (q *Queries) UpdateQRTemplate(ctx context.Context, arg UpdateQRTemplateParams) error {
+	_, err := q.db.ExecContext(ctx, updateQRTemplate,
+		arg.Name,
+		arg.FgColor,
+		arg.BgColor,
+		arg.GradientStops,
+		arg.GradientType,
+		arg.GradientAngle,
+		arg.GradientCenterX,
+		arg.GradientCenterY,
+		arg.ModuleShape,
+		arg.FinderOuterShape,
+		arg.FinderInnerShape,
+		arg.FinderOuterColor,
+		arg.FinderInnerColor,
+		arg.LogoImageID,
+		arg.LogoSize,
+		arg.BorderRadius,
+		arg.ErrorCorrection,
+		arg.QuietZone,
+		arg.Size,
+		arg.Dpi,
+		arg.ID,
+	)
+	return err
+}
+
 const updateTag = `-- name: UpdateTag :exec
 UPDATE tags
 SET name = ?, slug = ?
@@ -829,6 +2724,59 @@ func (q *Queries) UpdateTag(ctx context.Context, arg UpdateTagParams) error {
 	return err
 }
 
+const updateURLDestination = `-- name: UpdateURLDestination :exec
+UPDATE urls
+SET original_url = ?, url_hash = ?
+WHERE id = ?
+`
+
+type UpdateURLDestinationParams struct {
+	OriginalUrl string `json:"original_url"`
+	UrlHash     string `json:"url_hash"`
+	ID          int32  `json:"id"`
+}
+
+func (q *Queries) UpdateURLDestination(ctx context.Context, arg UpdateURLDestinationParams) error {
+	_, err := q.db.ExecContext(ctx, updateURLDestination, arg.OriginalUrl, arg.UrlHash, arg.ID)
+	return err
+}
+
+const updateURLRule = `-- name: UpdateURLRule :exec
+UPDATE url_rules
+SET position = ?, destination = ?, os = ?, devices = ?, languages = ?, countries = ?,
+  starts_at = ?, ends_at = ?
+WHERE id = ? AND url_id = ?
+`
+
+type UpdateURLRuleParams struct {
+	Position    int32        `json:"position"`
+	Destination string       `json:"destination"`
+	Os          string       `json:"os"`
+	Devices     string       `json:"devices"`
+	Languages   string       `json:"languages"`
+	Countries   string       `json:"countries"`
+	StartsAt    sql.NullTime `json:"starts_at"`
+	EndsAt      sql.NullTime `json:"ends_at"`
+	ID          int32        `json:"id"`
+	UrlID       int32        `json:"url_id"`
+}
+
+func (q *Queries) UpdateURLRule(ctx context.Context, arg UpdateURLRuleParams) error {
+	_, err := q.db.ExecContext(ctx, updateURLRule,
+		arg.Position,
+		arg.Destination,
+		arg.Os,
+		arg.Devices,
+		arg.Languages,
+		arg.Countries,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.ID,
+		arg.UrlID,
+	)
+	return err
+}
+
 const updateUserPassword = `-- name: UpdateUserPassword :exec
 UPDATE users
 SET password_hash = ?
@@ -844,3 +2792,72 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.PasswordHash, arg.Username)
 	return err
 }
+
+const upsertURLHealth = `-- name: UpsertURLHealth :exec
+INSERT INTO url_health (
+  url_id, status_code, latency_ms, error, consecutive_failures, last_checked_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+)
+ON DUPLICATE KEY UPDATE
+  status_code = VALUES(status_code), latency_ms = VALUES(latency_ms), error = VALUES(error),
+  consecutive_failures = VALUES(consecutive_failures), last_checked_at = VALUES(last_checked_at)
+`
+
+type UpsertURLHealthParams struct {
+	UrlID               int32         `json:"url_id"`
+	StatusCode          sql.NullInt32 `json:"status_code"`
+	LatencyMs           int32         `json:"latency_ms"`
+	Error               string        `json:"error"`
+	ConsecutiveFailures int32         `json:"consecutive_failures"`
+	LastCheckedAt       time.Time     `json:"last_checked_at"`
+}
+
+func (q *Queries) UpsertURLHealth(ctx context.Context, arg UpsertURLHealthParams) error {
+	_, err := q.db.ExecContext(ctx, upsertURLHealth,
+		arg.UrlID,
+		arg.StatusCode,
+		arg.LatencyMs,
+		arg.Error,
+		arg.ConsecutiveFailures,
+		arg.LastCheckedAt,
+	)
+	return err
+}
+
+const upsertURLPreview = `-- name: UpsertURLPreview :exec
+INSERT INTO url_previews (
+  url_id, status, title, description, image_url, favicon_url, error, fetched_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?
+)
+ON DUPLICATE KEY UPDATE
+  status = VALUES(status), title = VALUES(title), description = VALUES(description),
+  image_url = VALUES(image_url), favicon_url = VALUES(favicon_url), error = VALUES(error),
+  fetched_at = VALUES(fetched_at)
+`
+
+type UpsertURLPreviewParams struct {
+	UrlID       int32             `json:"url_id"`
+	Status      UrlPreviewsStatus `json:"status"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	ImageUrl    string            `json:"image_url"`
+	FaviconUrl  string            `json:"favicon_url"`
+	Error       string            `json:"error"`
+	FetchedAt   sql.NullTime      `json:"fetched_at"`
+}
+
+func (q *Queries) UpsertURLPreview(ctx context.Context, arg UpsertURLPreviewParams) error {
+	_, err := q.db.ExecContext(ctx, upsertURLPreview,
+		arg.UrlID,
+		arg.Status,
+		arg.Title,
+		arg.Description,
+		arg.ImageUrl,
+		arg.FaviconUrl,
+		arg.Error,
+		arg.FetchedAt,
+	)
+	return err
+}
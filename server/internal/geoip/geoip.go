@@ -0,0 +1,131 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lookup resolves an IP address to an ISO 3166-1 alpha-2 country code.
+// Implementations return "" when the country is unknown.
+type Lookup interface {
+	Country(ip net.IP) string
+}
+
+// Nop is used when no GeoIP database is configured.
+type Nop struct{}
+
+func (Nop) Country(net.IP) string { return "" }
+
+type ipRange struct {
+	start   net.IP // 16-byte form
+	end     net.IP
+	country string
+}
+
+// Database is an in-memory IP range table loaded from an offline CSV feed.
+type Database struct {
+	ranges []ipRange
+}
+
+// Open loads a CSV database from disk. See Load for the accepted formats.
+func Open(path string) (*Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Load parses "start,end,country[,...]" rows. Start/end may be IP addresses
+// (DB-IP "ip-to-country" lite) or IPv4 integers (IP2Location LITE DB1).
+// Extra columns are ignored and lines starting with '#' are skipped.
+func Load(r io.Reader) (*Database, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+
+	db := &Database{}
+	line := 0
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("geoip: line %d: %w", line, err)
+		}
+		if len(rec) < 3 {
+			return nil, fmt.Errorf("geoip: line %d: expected at least 3 columns", line)
+		}
+
+		start, ok1 := parseIP(rec[0])
+		end, ok2 := parseIP(rec[1])
+		if !ok1 || !ok2 {
+			if line == 1 {
+				continue // header row
+			}
+			return nil, fmt.Errorf("geoip: line %d: invalid IP range", line)
+		}
+
+		country := strings.ToUpper(strings.TrimSpace(rec[2]))
+		if len(country) != 2 || country == "ZZ" {
+			country = "" // unassigned / reserved ranges
+		}
+		db.ranges = append(db.ranges, ipRange{start: start, end: end, country: country})
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return bytes.Compare(db.ranges[i].start, db.ranges[j].start) < 0
+	})
+	return db, nil
+}
+
+// Country returns the country code for ip using a binary search over the sorted ranges.
+func (d *Database) Country(ip net.IP) string {
+	ip = ip.To16()
+	if ip == nil || len(d.ranges) == 0 {
+		return ""
+	}
+
+	// First range whose start is greater than ip; the candidate is the one before it.
+	i := sort.Search(len(d.ranges), func(i int) bool {
+		return bytes.Compare(d.ranges[i].start, ip) > 0
+	})
+	if i == 0 {
+		return ""
+	}
+	r := d.ranges[i-1]
+	if bytes.Compare(ip, r.end) <= 0 {
+		return r.country
+	}
+	return ""
+}
+
+// Len returns the number of loaded ranges.
+func (d *Database) Len() int {
+	return len(d.ranges)
+}
+
+func parseIP(s string) (net.IP, bool) {
+	s = strings.TrimSpace(s)
+	if ip := net.ParseIP(s); ip != nil {
+		return ip.To16(), true
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return nil, false
+	}
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, uint32(n))
+	return ip.To16(), true
+}
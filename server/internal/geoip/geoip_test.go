@@ -0,0 +1,43 @@
+package geoip
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestDatabaseCountry(t *testing.T) {
+	csv := `ip_start,ip_end,country
+1.0.0.0,1.0.0.255,AU
+"16777472","16778239","CN"
+8.8.8.0,8.8.8.255,US
+2001:4860::,2001:4860:ffff:ffff:ffff:ffff:ffff:ffff,US
+10.0.0.0,10.255.255.255,ZZ
+`
+	db, err := Load(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	tests := []struct {
+		ip       string
+		expected string
+	}{
+		{"1.0.0.0", "AU"},
+		{"1.0.0.255", "AU"},
+		{"1.0.1.5", "CN"}, // integer range 1.0.1.0 - 1.0.3.255
+		{"8.8.8.8", "US"},
+		{"8.8.9.1", ""},
+		{"2001:4860:4860::8888", "US"},
+		{"10.1.2.3", ""},
+		{"0.0.0.1", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.ip, func(t *testing.T) {
+			if got := db.Country(net.ParseIP(tc.ip)); got != tc.expected {
+				t.Errorf("Country(%s) = %q, want %q", tc.ip, got, tc.expected)
+			}
+		})
+	}
+}
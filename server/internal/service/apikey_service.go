@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-shortener-sqlc/internal/auth"
+	"go-shortener-sqlc/internal/db"
+)
+
+// apiKeyPrefix marks our keys so they are easy to spot in logs and secret scanners.
+const apiKeyPrefix = "gs_"
+
+// apiKeyTouchInterval limits last_used_at writes to one per key per interval.
+const apiKeyTouchInterval = time.Minute
+
+var (
+	ErrAPIKeyNameRequired = errors.New("name is required (max 100 chars)")
+	ErrAPIKeyScopes       = errors.New("scopes must be a non-empty list of: urls:read, urls:write, posts:write, images:write")
+	ErrInvalidAPIKey      = errors.New("invalid or revoked API key")
+)
+
+type APIKeyService struct {
+	q *db.Queries
+}
+
+func NewAPIKeyService(q *db.Queries) *APIKeyService {
+	return &APIKeyService{q: q}
+}
+
+// APIKey is the API representation of a key. The secret itself is never returned after creation.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKey is returned once, when the key is created.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+func newAPIKey(k db.ApiKey) APIKey {
+	key := APIKey{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    splitScopes(k.Scopes),
+		CreatedAt: k.CreatedAt,
+	}
+	if k.LastUsedAt.Valid {
+		key.LastUsedAt = &k.LastUsedAt.Time
+	}
+	if k.RevokedAt.Valid {
+		key.RevokedAt = &k.RevokedAt.Time
+	}
+	return key
+}
+
+// Create generates a new key for userID. Only its SHA-256 hash is stored.
+func (s *APIKeyService) Create(ctx context.Context, userID, name string, scopes []string) (*CreatedAPIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, ErrAPIKeyNameRequired
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	secret := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	id := uuid.New().String()
+	err = s.q.CreateAPIKey(ctx, db.CreateAPIKeyParams{
+		ID:      id,
+		UserID:  userID,
+		Name:    name,
+		Prefix:  secret[:len(apiKeyPrefix)+8],
+		KeyHash: hashAPIKey(secret),
+		Scopes:  strings.Join(scopes, ","),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreatedAPIKey{
+		APIKey: APIKey{
+			ID:        id,
+			Name:      name,
+			Prefix:    secret[:len(apiKeyPrefix)+8],
+			Scopes:    scopes,
+			CreatedAt: time.Now().UTC(),
+		},
+		Key: secret,
+	}, nil
+}
+
+// List returns all keys of userID, including revoked ones, newest first.
+func (s *APIKeyService) List(ctx context.Context, userID string) ([]APIKey, error) {
+	rows, err := s.q.ListAPIKeysByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]APIKey, len(rows))
+	for i, k := range rows {
+		keys[i] = newAPIKey(k)
+	}
+	return keys, nil
+}
+
+// Revoke disables a key of userID. Returns sql.ErrNoRows if no active key matches.
+func (s *APIKeyService) Revoke(ctx context.Context, userID, id string) error {
+	n, err := s.q.RevokeAPIKey(ctx, db.RevokeAPIKeyParams{ID: id, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Authenticate resolves a raw bearer token to the claims of its owner.
+// The role is read from the user at request time, so demoting a user also limits their keys.
+func (s *APIKeyService) Authenticate(ctx context.Context, secret string) (*auth.Claims, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.q.GetAPIKeyByHash(ctx, hashAPIKey(secret))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if !key.LastUsedAt.Valid || now.Sub(key.LastUsedAt.Time) > apiKeyTouchInterval {
+		err := s.q.TouchAPIKey(ctx, db.TouchAPIKeyParams{
+			LastUsedAt: sql.NullTime{Time: now, Valid: true},
+			ID:         key.ID,
+		})
+		if err != nil {
+			slog.Warn("Failed to update API key last_used_at", "key_id", key.ID, "error", err)
+		}
+	}
+
+	return &auth.Claims{
+		UserID:   key.UserID,
+		Role:     key.Role,
+		APIKeyID: key.ID,
+		Scopes:   splitScopes(key.Scopes),
+	}, nil
+}
+
+func hashAPIKey(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// normalizeScopes validates, de-duplicates and sorts the requested scopes.
+func normalizeScopes(scopes []string) ([]string, error) {
+	set := make(map[string]bool)
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !auth.ValidScopes[scope] {
+			return nil, ErrAPIKeyScopes
+		}
+		set[scope] = true
+	}
+	if len(set) == 0 {
+		return nil, ErrAPIKeyScopes
+	}
+
+	out := make([]string, 0, len(set))
+	for scope := range set {
+		out = append(out, scope)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func splitScopes(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-shortener-sqlc/internal/cache"
+)
+
+// attemptLimiter counts failed attempts per key in a fixed window. It counts in the
+// cache backend (so limits hold across instances with Redis), and in an in-process
+// map when the backend can't count (Nop) or is unavailable.
+type attemptLimiter struct {
+	cache  cache.Cache
+	prefix string
+	max    int64
+	window time.Duration
+
+	mu      sync.Mutex
+	entries map[string]attemptWindow
+}
+
+type attemptWindow struct {
+	count   int64
+	expires time.Time
+}
+
+func newAttemptLimiter(c cache.Cache, prefix string, max int64, window time.Duration) *attemptLimiter {
+	return &attemptLimiter{
+		cache:   c,
+		prefix:  prefix,
+		max:     max,
+		window:  window,
+		entries: make(map[string]attemptWindow),
+	}
+}
+
+// Blocked reports whether key has used up its attempts, and for how much longer.
+func (l *attemptLimiter) Blocked(ctx context.Context, key string) (bool, time.Duration) {
+	if v, err := l.cache.Get(ctx, l.prefix+key); err == nil {
+		if n, err := strconv.ParseInt(string(v), 10, 64); err == nil && n >= l.max {
+			ttl, _ := l.cache.TTL(ctx, l.prefix+key)
+			return true, max(ttl, time.Second)
+		}
+	}
+
+	// Attempts the backend couldn't count are in the local map
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.entries[key]
+	if ok && time.Now().Before(e.expires) && e.count >= l.max {
+		return true, time.Until(e.expires)
+	}
+	return false, 0
+}
+
+// Fail records a failed attempt for key.
+func (l *attemptLimiter) Fail(ctx context.Context, key string) {
+	if _, err := l.cache.Incr(ctx, l.prefix+key, l.window); err == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if len(l.entries) > 10000 {
+		for k, e := range l.entries {
+			if now.After(e.expires) {
+				delete(l.entries, k)
+			}
+		}
+	}
+	e, ok := l.entries[key]
+	if !ok || now.After(e.expires) {
+		e = attemptWindow{expires: now.Add(l.window)}
+	}
+	e.count++
+	l.entries[key] = e
+}
+
+// Reset clears the counter for key after a successful attempt.
+func (l *attemptLimiter) Reset(ctx context.Context, key string) {
+	l.cache.Delete(ctx, l.prefix+key)
+	l.mu.Lock()
+	delete(l.entries, key)
+	l.mu.Unlock()
+}
@@ -0,0 +1,538 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-shortener-sqlc/internal/db"
+)
+
+const (
+	blocklistReload     = time.Minute // how often DB entries and the feed file are re-read
+	maxBlocklistPattern = 512
+	maxBlocklistReason  = 255
+)
+
+var (
+	ErrURLBlocked        = errors.New("destination is blocklisted")
+	ErrBlocklistKind     = errors.New("kind must be one of: host, suffix, regex")
+	ErrBlocklistPattern  = errors.New("invalid blocklist pattern")
+	ErrBlocklistReason   = errors.New("reason must be at most 255 characters")
+	ErrBlocklistConflict = errors.New("blocklist entry already exists")
+)
+
+// Blocklist entry kinds.
+const (
+	BlockHost   = "host"   // exact host, e.g. "evil.example"
+	BlockSuffix = "suffix" // the domain and every subdomain, e.g. "*.evil.example"
+	BlockRegex  = "regex"  // RE2 expression matched against the full URL
+	BlockPrefix = "prefix" // canonical URL prefix; feed file only, see normalizePrefix
+)
+
+// Blocklist entry sources.
+const (
+	BlockSourceDB   = "db"
+	BlockSourceFile = "file"
+)
+
+// BlocklistEntry is a single blocklist rule.
+type BlocklistEntry struct {
+	ID        int32      `json:"id,omitempty"`
+	Kind      string     `json:"kind"`
+	Pattern   string     `json:"pattern"`
+	Reason    string     `json:"reason"`
+	Source    string     `json:"source"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// normalize validates the entry and rewrites Pattern into its canonical form
+// (lowercase host, "*." prefix removed from suffixes).
+func (e *BlocklistEntry) normalize() error {
+	e.Kind = strings.ToLower(strings.TrimSpace(e.Kind))
+	e.Pattern = strings.TrimSpace(e.Pattern)
+	e.Reason = strings.TrimSpace(e.Reason)
+	if len(e.Reason) > maxBlocklistReason {
+		return ErrBlocklistReason
+	}
+	if e.Pattern == "" || len(e.Pattern) > maxBlocklistPattern {
+		return ErrBlocklistPattern
+	}
+
+	switch e.Kind {
+	case BlockHost, BlockSuffix:
+		host := e.Pattern
+		if e.Kind == BlockSuffix {
+			host = strings.TrimPrefix(strings.TrimPrefix(host, "*"), ".")
+		}
+		host = normalizeHost(host)
+		if !validBlockHost(host) {
+			return ErrBlocklistPattern
+		}
+		e.Pattern = host
+	case BlockRegex:
+		if _, err := regexp.Compile(e.Pattern); err != nil {
+			return fmt.Errorf("%w: %v", ErrBlocklistPattern, err)
+		}
+	default:
+		return ErrBlocklistKind
+	}
+	return nil
+}
+
+// normalizePrefix validates a feed URL entry and rewrites Pattern into its canonical
+// form (see canonicalURL). Prefix entries only come from the feed file, so the admin
+// API and the database keep to host, suffix and regex.
+func (e *BlocklistEntry) normalizePrefix() error {
+	e.Pattern = strings.TrimSpace(e.Pattern)
+	if e.Pattern == "" || len(e.Pattern) > maxBlocklistPattern {
+		return ErrBlocklistPattern
+	}
+	u, err := url.Parse(e.Pattern)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBlocklistPattern, err)
+	}
+	c := canonicalURL(u)
+	if c == "" {
+		return ErrBlocklistPattern
+	}
+	e.Pattern = c
+	return nil
+}
+
+var blockHostPattern = regexp.MustCompile(`^[a-z0-9_]([a-z0-9_-]*[a-z0-9_])?(\.[a-z0-9_]([a-z0-9_-]*[a-z0-9_])?)*$`)
+
+func validBlockHost(host string) bool {
+	return net.ParseIP(host) != nil || blockHostPattern.MatchString(host)
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// blockMatcher is an immutable, indexed view of all entries.
+type blockMatcher struct {
+	hosts    map[string]string // host → reason
+	suffixes map[string]string // domain → reason
+	prefixes []blockPrefix     // sorted, and no entry is a prefix of another
+	regexes  []blockRegex
+}
+
+type blockPrefix struct {
+	prefix string
+	reason string
+}
+
+type blockRegex struct {
+	re     *regexp.Regexp
+	reason string
+}
+
+// newBlockMatcher indexes normalized entries; regexes that fail to compile are skipped.
+func newBlockMatcher(entries []BlocklistEntry) *blockMatcher {
+	m := &blockMatcher{hosts: make(map[string]string), suffixes: make(map[string]string)}
+	for _, e := range entries {
+		switch e.Kind {
+		case BlockHost:
+			m.hosts[e.Pattern] = e.Reason
+		case BlockSuffix:
+			m.suffixes[e.Pattern] = e.Reason
+		case BlockPrefix:
+			m.prefixes = append(m.prefixes, blockPrefix{prefix: e.Pattern, reason: e.Reason})
+		case BlockRegex:
+			re, err := regexp.Compile(e.Pattern)
+			if err != nil {
+				slog.Warn("Skipping invalid blocklist regex", "pattern", e.Pattern, "error", err)
+				continue
+			}
+			m.regexes = append(m.regexes, blockRegex{re: re, reason: e.Reason})
+		}
+	}
+
+	// Drop prefixes covered by a shorter one. Sorted, a prefix comes right before the
+	// entries it covers, so the kept set is prefix-free.
+	sort.Slice(m.prefixes, func(i, j int) bool { return m.prefixes[i].prefix < m.prefixes[j].prefix })
+	kept := m.prefixes[:0]
+	for _, p := range m.prefixes {
+		if len(kept) == 0 || !strings.HasPrefix(p.prefix, kept[len(kept)-1].prefix) {
+			kept = append(kept, p)
+		}
+	}
+	m.prefixes = kept
+	return m
+}
+
+// matchPrefix reports whether a prefix entry covers the canonical URL c. In a sorted,
+// prefix-free list only the last entry not after c can be a prefix of it.
+func (m *blockMatcher) matchPrefix(c string) (string, bool) {
+	i := sort.Search(len(m.prefixes), func(i int) bool { return m.prefixes[i].prefix > c })
+	if i > 0 && strings.HasPrefix(c, m.prefixes[i-1].prefix) {
+		return m.prefixes[i-1].reason, true
+	}
+	return "", false
+}
+
+// match reports whether rawURL is blocked, and the reason of the matching entry.
+// Prefixes are compared with the canonical form of rawURL (see canonicalURL); regexes
+// are tried against both.
+func (m *blockMatcher) match(rawURL string) (string, bool) {
+	targets := []string{rawURL}
+	if u, err := url.Parse(rawURL); err == nil {
+		if host := normalizeHost(u.Hostname()); host != "" {
+			if reason, ok := m.hosts[host]; ok {
+				return reason, true
+			}
+			// evil.example blocks a.b.evil.example: try each parent domain
+			for h := host; ; {
+				if reason, ok := m.suffixes[h]; ok {
+					return reason, true
+				}
+				i := strings.IndexByte(h, '.')
+				if i < 0 {
+					break
+				}
+				h = h[i+1:]
+			}
+		}
+		if c := canonicalURL(u); c != "" {
+			if reason, ok := m.matchPrefix(c); ok {
+				return reason, true
+			}
+			if c != rawURL {
+				targets = append(targets, c)
+			}
+		}
+	}
+	for _, r := range m.regexes {
+		for _, target := range targets {
+			if r.re.MatchString(target) {
+				return r.reason, true
+			}
+		}
+	}
+	return "", false
+}
+
+// canonicalURL writes u with a lowercase scheme and host, without user info, default
+// port or fragment, and with the path decoded, so spelling variants of one URL compare
+// equal. It returns "" for URLs without a host.
+func canonicalURL(u *url.URL) string {
+	host := normalizeHost(u.Hostname())
+	if host == "" {
+		return ""
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	c := scheme + "://" + host + u.Path
+	if u.RawQuery != "" {
+		c += "?" + u.RawQuery
+	}
+	return c
+}
+
+// Blocklist rejects destinations by host, domain suffix or regex. Entries come from
+// the blocklist_entries table (managed through the admin API) and an optional local
+// feed file, and are merged into an in-memory matcher that is rebuilt after every
+// change and once a minute, so edits from other instances and file updates are picked
+// up without a restart.
+type Blocklist struct {
+	q    *db.Queries
+	file string
+
+	mu      sync.RWMutex
+	matcher *blockMatcher
+
+	reloadMu    sync.Mutex // serializes Reload; guards the file cache below
+	fileEntries []BlocklistEntry
+	fileMod     time.Time
+
+	reloads *reloader
+}
+
+// NewBlocklist loads the entries and starts the reload loop. file is an optional feed
+// path (see parseBlocklistFeed for the format). Call Close on shutdown.
+func NewBlocklist(q *db.Queries, file string) *Blocklist {
+	b := &Blocklist{q: q, file: file, matcher: newBlockMatcher(nil)}
+	b.reloads = startReloader("blocklist", blocklistReload, b.Reload)
+	return b
+}
+
+// Close stops the reload loop.
+func (b *Blocklist) Close() {
+	if b == nil {
+		return
+	}
+	b.reloads.close()
+}
+
+// Reload rebuilds the matcher from the database and the feed file. The file is only
+// re-read when its modification time changes. If the database can't be read the
+// previous matcher is kept; if the file can't be read its last good entries are used,
+// so a broken feed never disables the database entries.
+func (b *Blocklist) Reload(ctx context.Context) error {
+	b.reloadMu.Lock()
+	defer b.reloadMu.Unlock()
+
+	entries, err := b.List(ctx)
+	if err != nil {
+		return err
+	}
+	fileEntries, err := b.loadFile()
+	if err != nil {
+		slog.Error("Failed to read blocklist feed", "file", b.file, "error", err)
+		fileEntries = b.fileEntries
+	}
+
+	m := newBlockMatcher(append(entries, fileEntries...))
+	b.mu.Lock()
+	b.matcher = m
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *Blocklist) loadFile() ([]BlocklistEntry, error) {
+	if b.file == "" {
+		return nil, nil
+	}
+	info, err := os.Stat(b.file)
+	if err != nil {
+		return nil, err
+	}
+	if info.ModTime().Equal(b.fileMod) {
+		return b.fileEntries, nil
+	}
+
+	f, err := os.Open(b.file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entries, err := parseBlocklistFeed(f)
+	if err != nil {
+		return nil, err
+	}
+
+	b.fileEntries, b.fileMod = entries, info.ModTime()
+	slog.Info("Loaded blocklist feed", "file", b.file, "entries", len(entries))
+	return entries, nil
+}
+
+// Check returns ErrURLBlocked if rawURL matches an entry. A nil Blocklist blocks nothing.
+func (b *Blocklist) Check(rawURL string) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.RLock()
+	m := b.matcher
+	b.mu.RUnlock()
+
+	if _, blocked := m.match(rawURL); blocked {
+		return ErrURLBlocked
+	}
+	return nil
+}
+
+// --- Admin CRUD (database entries only; the feed file is read-only) ---
+
+// List returns the database entries, oldest first.
+func (b *Blocklist) List(ctx context.Context) ([]BlocklistEntry, error) {
+	if b.q == nil {
+		return nil, nil
+	}
+	rows, err := b.q.ListBlocklistEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]BlocklistEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = newBlocklistEntry(row)
+	}
+	return entries, nil
+}
+
+// Create adds an entry and applies it immediately.
+func (b *Blocklist) Create(ctx context.Context, entry BlocklistEntry) (*BlocklistEntry, error) {
+	if err := entry.normalize(); err != nil {
+		return nil, err
+	}
+	result, err := b.q.CreateBlocklistEntry(ctx, db.CreateBlocklistEntryParams{
+		Kind:    db.BlocklistEntriesKind(entry.Kind),
+		Pattern: entry.Pattern,
+		Reason:  entry.Reason,
+	})
+	if err != nil {
+		if isDuplicateKey(err) {
+			return nil, ErrBlocklistConflict
+		}
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return b.get(ctx, int32(id))
+}
+
+// Update replaces an entry and applies the change immediately.
+func (b *Blocklist) Update(ctx context.Context, id int32, entry BlocklistEntry) (*BlocklistEntry, error) {
+	if err := entry.normalize(); err != nil {
+		return nil, err
+	}
+	if _, err := b.q.GetBlocklistEntry(ctx, id); err != nil {
+		return nil, err
+	}
+	err := b.q.UpdateBlocklistEntry(ctx, db.UpdateBlocklistEntryParams{
+		Kind:    db.BlocklistEntriesKind(entry.Kind),
+		Pattern: entry.Pattern,
+		Reason:  entry.Reason,
+		ID:      id,
+	})
+	if err != nil {
+		if isDuplicateKey(err) {
+			return nil, ErrBlocklistConflict
+		}
+		return nil, err
+	}
+	return b.get(ctx, id)
+}
+
+// Delete removes an entry and applies the change immediately.
+func (b *Blocklist) Delete(ctx context.Context, id int32) error {
+	n, err := b.q.DeleteBlocklistEntry(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	b.reloads.now(ctx)
+	return nil
+}
+
+// get reloads the matcher after a write and returns the stored entry.
+func (b *Blocklist) get(ctx context.Context, id int32) (*BlocklistEntry, error) {
+	b.reloads.now(ctx)
+	row, err := b.q.GetBlocklistEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	entry := newBlocklistEntry(row)
+	return &entry, nil
+}
+
+func newBlocklistEntry(row db.BlocklistEntry) BlocklistEntry {
+	return BlocklistEntry{
+		ID:        row.ID,
+		Kind:      string(row.Kind),
+		Pattern:   row.Pattern,
+		Reason:    row.Reason,
+		Source:    BlockSourceDB,
+		CreatedAt: &row.CreatedAt,
+	}
+}
+
+// parseBlocklistFeed reads a blocklist feed, one entry per line, accepting the
+// common formats of public phishing/malware lists:
+//
+//	# comment (also "!" and trailing "# ...")
+//	evil.example                 exact host (plain domain list)
+//	0.0.0.0 evil.example a.test  hosts file; the address is ignored
+//	*.evil.example               domain and subdomains
+//	||evil.example^              domain and subdomains (Adblock syntax)
+//	/^https?://[^/]+/login\.php/ regex between slashes
+//	https://host/phish/page      URL list: blocks that URL and anything below it
+//
+// Invalid lines are skipped with a warning.
+func parseBlocklistFeed(r io.Reader) ([]BlocklistEntry, error) {
+	var entries []BlocklistEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 64<<10)
+
+	lineNo, skipped := 0, 0
+	for scanner.Scan() {
+		lineNo++
+		for _, entry := range parseFeedLine(scanner.Text()) {
+			entry.Source = BlockSourceFile
+			normalize := entry.normalize
+			if entry.Kind == BlockPrefix {
+				normalize = entry.normalizePrefix
+			}
+			if err := normalize(); err != nil {
+				skipped++
+				if skipped <= 10 {
+					slog.Warn("Skipping invalid blocklist feed line", "line", lineNo, "error", err)
+				}
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if skipped > 0 {
+		slog.Warn("Skipped invalid blocklist feed lines", "count", skipped)
+	}
+	return entries, nil
+}
+
+// hostsFileNames are entries of a stock hosts file that must never be blocked.
+var hostsFileNames = map[string]bool{
+	"localhost": true, "localhost.localdomain": true, "local": true, "broadcasthost": true,
+	"ip6-localhost": true, "ip6-loopback": true, "0.0.0.0": true,
+}
+
+// parseFeedLine converts one feed line into entries; blank and comment lines yield none.
+func parseFeedLine(line string) []BlocklistEntry {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' || line[0] == '!' {
+		return nil
+	}
+
+	// Regex: /.../ (may contain "#", so handled before comment stripping)
+	if len(line) > 2 && line[0] == '/' && line[len(line)-1] == '/' {
+		return []BlocklistEntry{{Kind: BlockRegex, Pattern: line[1 : len(line)-1]}}
+	}
+	if i := strings.Index(line, " #"); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+
+	switch {
+	case strings.HasPrefix(line, "||"):
+		return []BlocklistEntry{{Kind: BlockSuffix, Pattern: strings.TrimSuffix(strings.TrimPrefix(line, "||"), "^")}}
+	case strings.Contains(line, "://"):
+		return []BlocklistEntry{{Kind: BlockPrefix, Pattern: line}}
+	case strings.HasPrefix(line, "*."):
+		return []BlocklistEntry{{Kind: BlockSuffix, Pattern: line}}
+	}
+
+	// Hosts file: "<address> <host> [<host>...]"
+	fields := strings.Fields(line)
+	if len(fields) == 1 || net.ParseIP(fields[0]) == nil {
+		return []BlocklistEntry{{Kind: BlockHost, Pattern: line}} // several words without an address: rejected by normalize
+	}
+	var entries []BlocklistEntry
+	for _, host := range fields[1:] {
+		if !hostsFileNames[strings.ToLower(host)] {
+			entries = append(entries, BlocklistEntry{Kind: BlockHost, Pattern: host})
+		}
+	}
+	return entries
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go-shortener-sqlc/internal/db"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestBlocklistMatch(t *testing.T) {
+	entries := []BlocklistEntry{
+		{Kind: BlockHost, Pattern: "Phish.Example."},
+		{Kind: BlockSuffix, Pattern: "*.malware.test"},
+		{Kind: BlockRegex, Pattern: `^https?://[^/]+/wp-login\.php`},
+	}
+	for i := range entries {
+		if err := entries[i].normalize(); err != nil {
+			t.Fatalf("normalize %q: %v", entries[i].Pattern, err)
+		}
+	}
+	m := newBlockMatcher(entries)
+
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"https://phish.example/login", true},
+		{"https://PHISH.example./", true},
+		{"https://www.phish.example/", false}, // host entries are exact
+		{"https://malware.test", true},
+		{"http://a.b.malware.test:8080/x", true},
+		{"https://notmalware.test/", false},
+		{"https://blog.test/wp-login.php?x=1", true},
+		{"https://blog.test/about", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.url, func(t *testing.T) {
+			if _, blocked := m.match(tc.url); blocked != tc.blocked {
+				t.Errorf("match(%q) = %v, want %v", tc.url, blocked, tc.blocked)
+			}
+		})
+	}
+}
+
+func TestBlocklistFeedURLBypass(t *testing.T) {
+	entries, err := parseBlocklistFeed(strings.NewReader("https://Evil.example/phish\n"))
+	if err != nil {
+		t.Fatalf("parseBlocklistFeed: %v", err)
+	}
+	m := newBlockMatcher(entries)
+
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"https://evil.example/phish", true},
+		{"https://evil.example/phish/page?x=1", true},
+		{"HTTPS://EVIL.example/phish", true},
+		{"https://evil.example:443/phish", true},
+		{"https://x@evil.example/phish", true},
+		{"https://evil.example./phish", true},
+		{"https://evil.example/%70hish", true},
+		{"http://evil.example/phish", false}, // the scheme is part of the prefix
+		{"https://evil.example:8443/phish", false},
+		{"https://evil.example/Phish", false}, // paths are case-sensitive
+		{"https://evil.example/other", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.url, func(t *testing.T) {
+			if _, blocked := m.match(tc.url); blocked != tc.blocked {
+				t.Errorf("match(%q) = %v, want %v", tc.url, blocked, tc.blocked)
+			}
+		})
+	}
+}
+
+func TestBlockMatcherPrefixes(t *testing.T) {
+	m := newBlockMatcher([]BlocklistEntry{
+		{Kind: BlockPrefix, Pattern: "https://a.example/x/y/z", Reason: "deep"},
+		{Kind: BlockPrefix, Pattern: "https://a.example/x", Reason: "x"},
+		{Kind: BlockPrefix, Pattern: "https://a.example/m", Reason: "m"},
+		{Kind: BlockPrefix, Pattern: "https://b.example/", Reason: "b"},
+	})
+	if len(m.prefixes) != 3 {
+		t.Errorf("prefixes = %v, want the covered entry dropped", m.prefixes)
+	}
+
+	tests := []struct {
+		url    string
+		reason string
+	}{
+		{"https://a.example/x/y/q", "x"},
+		{"https://a.example/x/y/z/1", "x"},
+		{"https://a.example/mm", "m"},
+		{"https://b.example/anything", "b"},
+		{"https://a.example/n", ""},
+		{"https://a.example/", ""},
+		{"https://c.example/x", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.url, func(t *testing.T) {
+			if reason, _ := m.match(tc.url); reason != tc.reason {
+				t.Errorf("match(%q) reason = %q, want %q", tc.url, reason, tc.reason)
+			}
+		})
+	}
+}
+
+func TestBlocklistEntryNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    BlocklistEntry
+		expected string
+		err      error
+	}{
+		{name: "Host", entry: BlocklistEntry{Kind: "HOST", Pattern: " Evil.Example "}, expected: "evil.example"},
+		{name: "Suffix Wildcard", entry: BlocklistEntry{Kind: "suffix", Pattern: "*.evil.example"}, expected: "evil.example"},
+		{name: "IP Host", entry: BlocklistEntry{Kind: "host", Pattern: "203.0.113.7"}, expected: "203.0.113.7"},
+		{name: "Host With Path", entry: BlocklistEntry{Kind: "host", Pattern: "evil.example/login"}, err: ErrBlocklistPattern},
+		{name: "Bad Regex", entry: BlocklistEntry{Kind: "regex", Pattern: "(unclosed"}, err: ErrBlocklistPattern},
+		{name: "Unknown Kind", entry: BlocklistEntry{Kind: "url", Pattern: "evil.example"}, err: ErrBlocklistKind},
+		{name: "Prefix Kind", entry: BlocklistEntry{Kind: "prefix", Pattern: "https://evil.example/"}, err: ErrBlocklistKind},
+		{name: "Long Reason", entry: BlocklistEntry{Kind: "host", Pattern: "evil.example", Reason: strings.Repeat("x", 256)}, err: ErrBlocklistReason},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.entry.normalize()
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("normalize error = %v, want %v", err, tc.err)
+			}
+			if err == nil && tc.entry.Pattern != tc.expected {
+				t.Errorf("pattern = %q, want %q", tc.entry.Pattern, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseBlocklistFeed(t *testing.T) {
+	feed := `# Phishing feed
+! Adblock-style comment
+
+127.0.0.1 localhost
+0.0.0.0 phish.example tracker.example # two hosts
+plain.example
+*.wild.example
+||adblock.example^
+https://host.example/phish/page.html
+/^https?://[^/]+/#login/
+not a host
+`
+	entries, err := parseBlocklistFeed(strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("parseBlocklistFeed: %v", err)
+	}
+
+	var got []string
+	for _, e := range entries {
+		if e.Source != BlockSourceFile {
+			t.Errorf("entry %q source = %q, want %q", e.Pattern, e.Source, BlockSourceFile)
+		}
+		got = append(got, e.Kind+":"+e.Pattern)
+	}
+	expected := []string{
+		"host:phish.example",
+		"host:tracker.example",
+		"host:plain.example",
+		"suffix:wild.example",
+		"suffix:adblock.example",
+		"prefix:https://host.example/phish/page.html",
+		"regex:^https?://[^/]+/#login",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("entries =\n%v\nwant\n%v", got, expected)
+	}
+}
+
+func TestBlocklistMissingFeedKeepsDBEntries(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM blocklist_entries").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "pattern", "reason", "created_at"}).
+			AddRow(1, "host", "evil.example", "phishing", time.Now()))
+
+	b := NewBlocklist(db.New(mockDB), filepath.Join(t.TempDir(), "missing.txt"))
+	defer b.Close()
+
+	if err := b.Check("https://evil.example/login"); !errors.Is(err, ErrURLBlocked) {
+		t.Errorf("Check = %v, want ErrURLBlocked", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
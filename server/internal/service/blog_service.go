@@ -7,16 +7,34 @@ import (
 
 	"github.com/google/uuid"
 
+	"go-shortener-sqlc/internal/cache"
 	"go-shortener-sqlc/internal/db"
 	"go-shortener-sqlc/internal/utils"
 )
 
+// publishedPostsKey caches the public post list, the blog's hottest read. Writes to
+// posts or categories delete it; view counts in it may lag by up to the TTL.
+const (
+	publishedPostsKey = "posts:published"
+	publishedPostsTTL = 5 * time.Minute
+)
+
 type BlogService struct {
-	q *db.Queries
+	q     *db.Queries
+	cache cache.Cache
+}
+
+// NewBlogService creates the service; a nil cache disables caching of the post list.
+func NewBlogService(q *db.Queries, c cache.Cache) *BlogService {
+	if c == nil {
+		c = cache.Nop{}
+	}
+	return &BlogService{q: q, cache: c}
 }
 
-func NewBlogService(q *db.Queries) *BlogService {
-	return &BlogService{q: q}
+// invalidate drops the cached post list.
+func (s *BlogService) invalidate(ctx context.Context) {
+	s.cache.Delete(ctx, publishedPostsKey)
 }
 
 // Categories
@@ -49,15 +67,23 @@ func (s *BlogService) UpdateCategory(ctx context.Context, id, name, slug string)
 	if slug == "" {
 		slug = utils.MakeSlug(name)
 	}
-	return s.q.UpdateCategory(ctx, db.UpdateCategoryParams{
+	err := s.q.UpdateCategory(ctx, db.UpdateCategoryParams{
 		ID:   id,
 		Name: name,
 		Slug: slug,
 	})
+	if err == nil {
+		s.invalidate(ctx)
+	}
+	return err
 }
 
 func (s *BlogService) DeleteCategory(ctx context.Context, id string) error {
-	return s.q.DeleteCategory(ctx, id)
+	err := s.q.DeleteCategory(ctx, id)
+	if err == nil {
+		s.invalidate(ctx)
+	}
+	return err
 }
 
 // Tags
@@ -171,6 +197,7 @@ func (s *BlogService) CreatePost(ctx context.Context, params CreatePostParams) e
 	if err != nil {
 		return err
 	}
+	s.invalidate(ctx)
 
 	// 2. Handle Tags
 	if len(params.TagNames) > 0 {
@@ -193,7 +220,7 @@ func (s *BlogService) CreatePost(ctx context.Context, params CreatePostParams) e
 }
 
 func (s *BlogService) ListPublishedPosts(ctx context.Context) ([]db.ListPublishedPostsWithCategoryRow, error) {
-	return s.q.ListPublishedPostsWithCategory(ctx)
+	return cache.Fetch(ctx, s.cache, publishedPostsKey, publishedPostsTTL, s.q.ListPublishedPostsWithCategory)
 }
 
 func (s *BlogService) GetPostBySlug(ctx context.Context, slug string) (db.Post, error) {
@@ -260,6 +287,7 @@ func (s *BlogService) UpdatePost(ctx context.Context, params UpdatePostParams) e
 	if err != nil {
 		return err
 	}
+	s.invalidate(ctx)
 
 	// 2. Handle Tags (Replace all)
 	// First, remove existing tags
@@ -289,7 +317,11 @@ func (s *BlogService) UpdatePost(ctx context.Context, params UpdatePostParams) e
 }
 
 func (s *BlogService) DeletePost(ctx context.Context, id string) error {
-	return s.q.DeletePost(ctx, id)
+	err := s.q.DeletePost(ctx, id)
+	if err == nil {
+		s.invalidate(ctx)
+	}
+	return err
 }
 
 func (s *BlogService) UpdatePostViews(ctx context.Context, id string, views uint32) error {
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go-shortener-sqlc/internal/db"
+	"go-shortener-sqlc/internal/geoip"
+	"go-shortener-sqlc/internal/utils"
+)
+
+const (
+	clickBufferSize    = 10000
+	clickBatchSize     = 500
+	clickFlushInterval = 2 * time.Second
+	clickFlushTimeout  = 10 * time.Second
+)
+
+// Stats bucket sizes accepted by ClickService.Stats.
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+// maxStatsBuckets caps the length of a returned time series.
+const maxStatsBuckets = 1000
+
+var (
+	ErrInvalidInterval = errors.New("interval must be one of: hour, day, week")
+	ErrInvalidRange    = errors.New("invalid time range: 'from' must be before 'to' and span at most 1000 buckets")
+)
+
+// ClickEvent is the raw data captured on the redirect path.
+// Parsing and GeoIP lookups happen later in the background worker.
+type ClickEvent struct {
+	URLID     int32
+	ClickedAt time.Time
+	Referrer  string
+	UserAgent string
+	IP        net.IP
+	Variant   string // label of the destination variant served, if any
+}
+
+// ClickService buffers click events in memory and batch-inserts them,
+// so recording a click never adds DB latency to a redirect.
+type ClickService struct {
+	conn   *sql.DB
+	q      *db.Queries
+	geo    geoip.Lookup
+	events chan ClickEvent
+	quit   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewClickService starts the background writer. Call Close on shutdown to flush pending events.
+func NewClickService(conn *sql.DB, q *db.Queries, geo geoip.Lookup) *ClickService {
+	if geo == nil {
+		geo = geoip.Nop{}
+	}
+	s := &ClickService{
+		conn:   conn,
+		q:      q,
+		geo:    geo,
+		events: make(chan ClickEvent, clickBufferSize),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Record queues a click without blocking. Events are dropped if the buffer is full.
+func (s *ClickService) Record(ev ClickEvent) {
+	if s == nil {
+		return
+	}
+	select {
+	case s.events <- ev:
+	default:
+		slog.Warn("Click buffer full, dropping event", "url_id", ev.URLID)
+	}
+}
+
+// Close stops the writer after flushing everything still buffered.
+func (s *ClickService) Close() {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() { close(s.quit) })
+	<-s.done
+}
+
+func (s *ClickService) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(clickFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]ClickEvent, 0, clickBatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			s.flush(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case ev := <-s.events:
+			batch = append(batch, ev)
+			if len(batch) >= clickBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.quit:
+			// Drain what is left in the buffer, then exit
+			for {
+				select {
+				case ev := <-s.events:
+					batch = append(batch, ev)
+					if len(batch) >= clickBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush writes one batch inside a single transaction. A row that fails to insert is
+// logged and skipped: MySQL keeps the transaction open after a failed statement, so
+// one bad click doesn't cost the rest of the batch.
+func (s *ClickService) flush(batch []ClickEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), clickFlushTimeout)
+	defer cancel()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("Failed to begin click batch", "error", err, "count", len(batch))
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+	for _, ev := range batch {
+		ua := utils.ParseUserAgent(ev.UserAgent)
+		err := qtx.CreateClick(ctx, db.CreateClickParams{
+			UrlID:     ev.URLID,
+			ClickedAt: ev.ClickedAt.UTC(),
+			Referrer:  truncate(ev.Referrer, 2048),
+			UserAgent: truncate(ev.UserAgent, 512),
+			Browser:   ua.Browser,
+			Os:        ua.OS,
+			Device:    ua.Device,
+			Country:   s.country(ev.IP),
+			Variant:   truncate(ev.Variant, 32),
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				slog.Error("Failed to insert click batch", "error", err, "count", len(batch))
+				return
+			}
+			slog.Warn("Skipping click that could not be stored", "error", err, "url_id", ev.URLID)
+			continue
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Failed to commit click batch", "error", err, "count", len(batch))
+	}
+}
+
+func (s *ClickService) country(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return s.geo.Country(ip)
+}
+
+// StatsBucket is one point in a click time series. Key is the bucket start in UTC.
+type StatsBucket struct {
+	Key    string `json:"key"`
+	Clicks int64  `json:"clicks"`
+}
+
+// StatsCount is the click count for one value of a dimension (browser, country, ...).
+type StatsCount struct {
+	Value  string `json:"value"`
+	Clicks int64  `json:"clicks"`
+}
+
+// ClickStats is the analytics summary for one short URL over a time range.
+type ClickStats struct {
+	ShortCode string        `json:"short_code"`
+	Interval  string        `json:"interval"`
+	From      time.Time     `json:"from"`
+	To        time.Time     `json:"to"`
+	Total     int64         `json:"total"`
+	Series    []StatsBucket `json:"series"`
+	Browsers  []StatsCount  `json:"browsers"`
+	OS        []StatsCount  `json:"os"`
+	Devices   []StatsCount  `json:"devices"`
+	Countries []StatsCount  `json:"countries"`
+	Variants  []StatsCount  `json:"variants"` // empty for links without variants
+}
+
+// DefaultStatsRange returns the default look-back window for an interval.
+func DefaultStatsRange(interval string) time.Duration {
+	switch interval {
+	case IntervalHour:
+		return 48 * time.Hour
+	case IntervalWeek:
+		return 26 * 7 * 24 * time.Hour
+	default:
+		return 30 * 24 * time.Hour
+	}
+}
+
+// Stats returns totals, breakdowns and a zero-filled time series for the code in [from, to).
+func (s *ClickService) Stats(ctx context.Context, code, interval string, from, to time.Time) (*ClickStats, error) {
+	if interval != IntervalHour && interval != IntervalDay && interval != IntervalWeek {
+		return nil, ErrInvalidInterval
+	}
+	if !from.Before(to) || to.Sub(bucketStart(from, interval)) > maxStatsBuckets*bucketSize(interval) {
+		return nil, ErrInvalidRange
+	}
+
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to = from.UTC(), to.UTC()
+	stats := &ClickStats{ShortCode: url.ShortCode, Interval: interval, From: from, To: to}
+
+	stats.Total, err = s.q.CountClicks(ctx, db.CountClicksParams{UrlID: url.ID, StartTime: from, EndTime: to})
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.Series, err = s.series(ctx, url.ID, interval, from, to); err != nil {
+		return nil, err
+	}
+
+	browsers, err := s.q.ListClickBrowsers(ctx, db.ListClickBrowsersParams{UrlID: url.ID, StartTime: from, EndTime: to})
+	if err != nil {
+		return nil, err
+	}
+	stats.Browsers = make([]StatsCount, len(browsers))
+	for i, row := range browsers {
+		stats.Browsers[i] = StatsCount{Value: row.Browser, Clicks: row.Clicks}
+	}
+
+	systems, err := s.q.ListClickOperatingSystems(ctx, db.ListClickOperatingSystemsParams{UrlID: url.ID, StartTime: from, EndTime: to})
+	if err != nil {
+		return nil, err
+	}
+	stats.OS = make([]StatsCount, len(systems))
+	for i, row := range systems {
+		stats.OS[i] = StatsCount{Value: row.Os, Clicks: row.Clicks}
+	}
+
+	devices, err := s.q.ListClickDevices(ctx, db.ListClickDevicesParams{UrlID: url.ID, StartTime: from, EndTime: to})
+	if err != nil {
+		return nil, err
+	}
+	stats.Devices = make([]StatsCount, len(devices))
+	for i, row := range devices {
+		stats.Devices[i] = StatsCount{Value: row.Device, Clicks: row.Clicks}
+	}
+
+	countries, err := s.q.ListClickCountries(ctx, db.ListClickCountriesParams{UrlID: url.ID, StartTime: from, EndTime: to})
+	if err != nil {
+		return nil, err
+	}
+	stats.Countries = make([]StatsCount, len(countries))
+	for i, row := range countries {
+		stats.Countries[i] = StatsCount{Value: row.Country, Clicks: row.Clicks}
+	}
+
+	variants, err := s.q.ListClickVariants(ctx, db.ListClickVariantsParams{UrlID: url.ID, StartTime: from, EndTime: to})
+	if err != nil {
+		return nil, err
+	}
+	stats.Variants = make([]StatsCount, 0, len(variants))
+	for _, row := range variants {
+		if row.Variant != "" {
+			stats.Variants = append(stats.Variants, StatsCount{Value: row.Variant, Clicks: row.Clicks})
+		}
+	}
+
+	return stats, nil
+}
+
+// series loads the grouped counts and fills empty buckets with zero so charts have no gaps.
+func (s *ClickService) series(ctx context.Context, urlID int32, interval string, from, to time.Time) ([]StatsBucket, error) {
+	counts := make(map[string]int64)
+
+	switch interval {
+	case IntervalHour:
+		rows, err := s.q.ListClicksByHour(ctx, db.ListClicksByHourParams{UrlID: urlID, StartTime: from, EndTime: to})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			counts[bucketKey(row.Bucket)] = row.Clicks
+		}
+	case IntervalDay:
+		rows, err := s.q.ListClicksByDay(ctx, db.ListClicksByDayParams{UrlID: urlID, StartTime: from, EndTime: to})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			counts[bucketKey(row.Bucket)] = row.Clicks
+		}
+	case IntervalWeek:
+		rows, err := s.q.ListClicksByWeek(ctx, db.ListClicksByWeekParams{UrlID: urlID, StartTime: from, EndTime: to})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			counts[bucketKey(row.Bucket)] = row.Clicks
+		}
+	}
+
+	var series []StatsBucket
+	for t := bucketStart(from, interval); t.Before(to); t = nextBucket(t, interval) {
+		key := formatBucket(t, interval)
+		series = append(series, StatsBucket{Key: key, Clicks: counts[key]})
+	}
+	return series, nil
+}
+
+// bucketStart truncates t to the start of its bucket (weeks start on Monday, matching WEEKDAY()).
+func bucketStart(t time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalHour:
+		return t.Truncate(time.Hour)
+	case IntervalWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func bucketSize(interval string) time.Duration {
+	switch interval {
+	case IntervalHour:
+		return time.Hour
+	case IntervalWeek:
+		return 7 * 24 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+func nextBucket(t time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalHour:
+		return t.Add(time.Hour)
+	case IntervalWeek:
+		return t.AddDate(0, 0, 7)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// formatBucket must produce the same keys as the DATE_FORMAT patterns in query.sql.
+func formatBucket(t time.Time, interval string) string {
+	if interval == IntervalHour {
+		return t.Format("2006-01-02 15:00")
+	}
+	return t.Format("2006-01-02")
+}
+
+// bucketKey converts the untyped DATE_FORMAT column returned by the driver.
+func bucketKey(v interface{}) string {
+	switch b := v.(type) {
+	case []byte:
+		return string(b)
+	case string:
+		return b
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// truncate cuts s to at most max bytes without splitting a rune. Invalid UTF-8 from
+// the request is dropped first, since MySQL rejects it in text columns.
+func truncate(s string, max int) string {
+	s = strings.ToValidUTF8(s, "")
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"go-shortener-sqlc/internal/db"
+	"go-shortener-sqlc/internal/geoip"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		max  int
+		want string
+	}{
+		{"Short", "abc", 5, "abc"},
+		{"ASCII", "abcdef", 3, "abc"},
+		{"Rune Boundary", "aé", 2, "a"},
+		{"Whole Rune", "aé", 3, "aé"},
+		{"Invalid UTF-8", "a\xffb", 5, "ab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncate(tt.in, tt.max)
+			if got != tt.want {
+				t.Errorf("truncate(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncate(%q, %d) = %q is not valid UTF-8", tt.in, tt.max, got)
+			}
+		})
+	}
+
+	long := strings.Repeat("é", 1025) // 2050 bytes
+	if got := truncate(long, 2048); len(got) != 2048 || !utf8.ValidString(got) {
+		t.Errorf("truncate of a long referrer = %d bytes, valid %v", len(got), utf8.ValidString(got))
+	}
+}
+
+func TestFlushSkipsFailedClick(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer conn.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO clicks").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO clicks").WillReturnError(errors.New("bad row"))
+	mock.ExpectExec("INSERT INTO clicks").WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	s := &ClickService{conn: conn, q: db.New(conn), geo: geoip.Nop{}}
+	now := time.Now()
+	s.flush([]ClickEvent{
+		{URLID: 1, ClickedAt: now},
+		{URLID: 2, ClickedAt: now},
+		{URLID: 3, ClickedAt: now},
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+	"sync"
+
+	"go-shortener-sqlc/internal/db"
+)
+
+// DefaultCodeAlphabet is Base62 without the lookalikes 0/O/o and 1/l/I.
+const DefaultCodeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnpqrstuvwxyz"
+
+const (
+	DefaultCodeLength = 6
+	minCodeLength     = 4
+	maxCodeLength     = 20 // urls.short_code is VARCHAR(20)
+
+	// codeBlockSize is how many sequence values an instance reserves per database round trip.
+	// Values left over at shutdown are skipped, never reused.
+	codeBlockSize = 100
+	codeSequence  = "urls"
+	feistelRounds = 6
+)
+
+// Code generator strategies (CODE_GENERATOR).
+const (
+	CodeSequential = "sequential"
+	CodeRandom     = "random"
+)
+
+var (
+	ErrCodeStrategy       = errors.New("code generator must be one of: sequential, random")
+	ErrCodeAlphabet       = errors.New("code alphabet needs at least 2 distinct characters from A-Z, a-z, 0-9, '-' and '_'")
+	ErrCodeLength         = errors.New("code length must be 4-20")
+	ErrCodeSecret         = errors.New("sequential codes need a secret, or anyone can map codes back to the counter")
+	ErrCodeSpaceExhausted = errors.New("all short codes of the configured length have been issued")
+)
+
+// CodeGenerator produces short codes for links without a custom alias.
+type CodeGenerator interface {
+	Next(ctx context.Context) (string, error)
+	// Unique reports whether Next never returns the same code twice. Shorten skips the
+	// existence check for such generators; a clash with a custom alias still fails the
+	// insert on the unique key and Shorten moves on to the next code.
+	Unique() bool
+}
+
+// CodeConfig selects and tunes the code generator.
+type CodeConfig struct {
+	Strategy string // random (default) or sequential
+	Alphabet string // default DefaultCodeAlphabet
+	Length   int    // default DefaultCodeLength
+	Secret   string // keys the sequential permutation; required for sequential codes
+}
+
+// NewCodeGenerator builds the generator described by cfg.
+func NewCodeGenerator(q *db.Queries, cfg CodeConfig) (CodeGenerator, error) {
+	f, err := newCodeFormat(cfg.Alphabet, cfg.Length)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Strategy {
+	case "", CodeRandom:
+		return newRandomCodes(f), nil
+	case CodeSequential:
+		if cfg.Secret == "" {
+			return nil, ErrCodeSecret
+		}
+		return newSequentialCodes(q, f, cfg.Secret), nil
+	default:
+		return nil, ErrCodeStrategy
+	}
+}
+
+// codeFormat writes numbers as fixed-length codes over an alphabet.
+type codeFormat struct {
+	alphabet string
+	length   int
+}
+
+func newCodeFormat(alphabet string, length int) (codeFormat, error) {
+	if alphabet == "" {
+		alphabet = DefaultCodeAlphabet
+	}
+	if length == 0 {
+		length = DefaultCodeLength
+	}
+	if length < minCodeLength || length > maxCodeLength {
+		return codeFormat{}, ErrCodeLength
+	}
+	if len(alphabet) < 2 || len(alphabet) > 64 {
+		return codeFormat{}, ErrCodeAlphabet
+	}
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if !isCodeChar(c) || strings.IndexByte(alphabet[i+1:], c) >= 0 {
+			return codeFormat{}, ErrCodeAlphabet
+		}
+	}
+	return codeFormat{alphabet: alphabet, length: length}, nil
+}
+
+func isCodeChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+// space returns how many distinct codes the format can write, capped at 2^62 so the
+// permutation below stays within uint64.
+func (f codeFormat) space() uint64 {
+	const limit = 1 << 62
+	base := uint64(len(f.alphabet))
+	n := uint64(1)
+	for i := 0; i < f.length; i++ {
+		if n > limit/base {
+			return limit
+		}
+		n *= base
+	}
+	return n
+}
+
+func (f codeFormat) encode(n uint64) string {
+	base := uint64(len(f.alphabet))
+	b := make([]byte, f.length)
+	for i := f.length - 1; i >= 0; i-- {
+		b[i] = f.alphabet[n%base]
+		n /= base
+	}
+	return string(b)
+}
+
+func (f codeFormat) decode(code string) (uint64, bool) {
+	if len(code) != f.length {
+		return 0, false
+	}
+	base := uint64(len(f.alphabet))
+	var n uint64
+	for i := 0; i < len(code); i++ {
+		d := strings.IndexByte(f.alphabet, code[i])
+		if d < 0 {
+			return 0, false
+		}
+		n = n*base + uint64(d)
+	}
+	return n, true
+}
+
+// RandomCodes draws every character independently from crypto/rand. Codes can repeat,
+// so Shorten checks each one against the database before using it.
+type RandomCodes struct {
+	format codeFormat
+}
+
+func newRandomCodes(f codeFormat) *RandomCodes {
+	return &RandomCodes{format: f}
+}
+
+var defaultCodes = newRandomCodes(codeFormat{alphabet: DefaultCodeAlphabet, length: DefaultCodeLength})
+
+func (g *RandomCodes) Next(ctx context.Context) (string, error) {
+	alphabet := g.format.alphabet
+	// Reject bytes past the last full multiple of the alphabet size to avoid modulo bias
+	limit := byte(256 - 256%len(alphabet))
+	code := make([]byte, 0, g.format.length)
+	buf := make([]byte, g.format.length*2)
+	for len(code) < g.format.length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if limit != 0 && b >= limit {
+				continue
+			}
+			code = append(code, alphabet[int(b)%len(alphabet)])
+			if len(code) == g.format.length {
+				break
+			}
+		}
+	}
+	return string(code), nil
+}
+
+func (g *RandomCodes) Unique() bool { return false }
+
+// SequentialCodes numbers links from a shared database counter and passes each number
+// through a keyed permutation of the code space, so codes are collision-free but don't
+// reveal the order or number of links. Instances reserve blocks of codeBlockSize values.
+//
+// Changing the alphabet, length or secret changes the mapping; codes issued before can
+// then clash with new ones, which Shorten handles like an alias clash.
+type SequentialCodes struct {
+	q      *db.Queries
+	format codeFormat
+	perm   feistel
+
+	mu        sync.Mutex
+	next, end uint64 // reserved, unused sequence values [next, end)
+}
+
+func newSequentialCodes(q *db.Queries, f codeFormat, secret string) *SequentialCodes {
+	return &SequentialCodes{q: q, format: f, perm: newFeistel(secret, f.space())}
+}
+
+func (g *SequentialCodes) Next(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.next == g.end {
+		if err := g.reserve(ctx); err != nil {
+			return "", err
+		}
+	}
+	n := g.next
+	g.next++
+	if n >= g.perm.domain {
+		return "", ErrCodeSpaceExhausted
+	}
+	return g.format.encode(g.perm.encrypt(n)), nil
+}
+
+func (g *SequentialCodes) Unique() bool { return true }
+
+// reserve claims the next block of sequence values.
+func (g *SequentialCodes) reserve(ctx context.Context) error {
+	res, err := g.q.AllocateCodeBlock(ctx, db.AllocateCodeBlockParams{Size: codeBlockSize, Name: codeSequence})
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("code sequence %q is missing from code_sequences", codeSequence)
+	}
+	end, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	g.end = uint64(end)
+	g.next = g.end - codeBlockSize
+	return nil
+}
+
+// sequence maps a code back to the counter value it was issued for.
+func (g *SequentialCodes) sequence(code string) (uint64, bool) {
+	n, ok := g.format.decode(code)
+	if !ok || n >= g.perm.domain {
+		return 0, false
+	}
+	return g.perm.decrypt(n), true
+}
+
+// feistel is a balanced Feistel network over the smallest even power of two covering
+// domain. Cycle walking (re-encrypting until the result falls inside the domain) turns
+// it into a permutation of [0, domain).
+type feistel struct {
+	key    [sha256.Size]byte
+	domain uint64
+	half   uint
+	mask   uint64
+}
+
+func newFeistel(secret string, domain uint64) feistel {
+	n := bits.Len64(domain - 1)
+	n += n % 2
+	if n < 2 {
+		n = 2
+	}
+	half := uint(n / 2)
+	return feistel{key: sha256.Sum256([]byte(secret)), domain: domain, half: half, mask: 1<<half - 1}
+}
+
+func (p feistel) round(i int, x uint64) uint64 {
+	var buf [sha256.Size + 9]byte
+	copy(buf[:], p.key[:])
+	buf[sha256.Size] = byte(i)
+	binary.BigEndian.PutUint64(buf[sha256.Size+1:], x)
+	sum := sha256.Sum256(buf[:])
+	return binary.BigEndian.Uint64(sum[:8]) & p.mask
+}
+
+func (p feistel) encrypt(x uint64) uint64 {
+	for {
+		l, r := x>>p.half, x&p.mask
+		for i := 0; i < feistelRounds; i++ {
+			l, r = r, l^p.round(i, r)
+		}
+		x = l<<p.half | r
+		if x < p.domain {
+			return x
+		}
+	}
+}
+
+func (p feistel) decrypt(x uint64) uint64 {
+	for {
+		l, r := x>>p.half, x&p.mask
+		for i := feistelRounds - 1; i >= 0; i-- {
+			l, r = r^p.round(i, l), l
+		}
+		x = l<<p.half | r
+		if x < p.domain {
+			return x
+		}
+	}
+}
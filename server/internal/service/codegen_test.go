@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go-shortener-sqlc/internal/db"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestNewCodeGenerator(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  CodeConfig
+		err  error
+	}{
+		{name: "Defaults", cfg: CodeConfig{}},
+		{name: "Sequential", cfg: CodeConfig{Strategy: "sequential", Secret: "secret"}},
+		{name: "Missing Secret", cfg: CodeConfig{Strategy: "sequential"}, err: ErrCodeSecret},
+		{name: "Random", cfg: CodeConfig{Strategy: "random", Alphabet: "abcdef", Length: 8}},
+		{name: "Unknown Strategy", cfg: CodeConfig{Strategy: "uuid"}, err: ErrCodeStrategy},
+		{name: "Too Short", cfg: CodeConfig{Length: 3}, err: ErrCodeLength},
+		{name: "Too Long", cfg: CodeConfig{Length: 21}, err: ErrCodeLength},
+		{name: "Duplicate Character", cfg: CodeConfig{Alphabet: "abca"}, err: ErrCodeAlphabet},
+		{name: "Unsafe Character", cfg: CodeConfig{Alphabet: "ab/"}, err: ErrCodeAlphabet},
+		{name: "Single Character", cfg: CodeConfig{Alphabet: "a"}, err: ErrCodeAlphabet},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewCodeGenerator(nil, tc.cfg); !errors.Is(err, tc.err) {
+				t.Errorf("NewCodeGenerator error = %v, want %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestRandomCodes(t *testing.T) {
+	g := newRandomCodes(codeFormat{alphabet: DefaultCodeAlphabet, length: 8})
+	for i := 0; i < 100; i++ {
+		code, err := g.Next(context.Background())
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if len(code) != 8 || strings.ContainsAny(code, "0Oo1lI") {
+			t.Fatalf("Next = %q, want 8 characters without lookalikes", code)
+		}
+	}
+}
+
+func TestFeistelPermutation(t *testing.T) {
+	// 3^4 = 81 codes: small enough to check every value, and not a power of two, so
+	// cycle walking is exercised
+	f := codeFormat{alphabet: "abc", length: 4}
+	p := newFeistel("secret", f.space())
+
+	seen := make(map[uint64]bool)
+	for n := uint64(0); n < p.domain; n++ {
+		x := p.encrypt(n)
+		if x >= p.domain {
+			t.Fatalf("encrypt(%d) = %d, outside [0, %d)", n, x, p.domain)
+		}
+		if seen[x] {
+			t.Fatalf("encrypt(%d) = %d repeats an earlier value", n, x)
+		}
+		seen[x] = true
+		if back := p.decrypt(x); back != n {
+			t.Fatalf("decrypt(encrypt(%d)) = %d", n, back)
+		}
+	}
+
+	if other := newFeistel("other", f.space()); other.encrypt(1) == p.encrypt(1) && other.encrypt(2) == p.encrypt(2) {
+		t.Error("different secrets produced the same permutation")
+	}
+}
+
+func TestSequentialCodes(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	f := codeFormat{alphabet: "abc", length: 4} // 81 codes
+	g := newSequentialCodes(db.New(mockDB), f, "secret")
+	ctx := context.Background()
+
+	// One block of 100 covers the whole code space
+	mock.ExpectExec("UPDATE code_sequences SET next_value").
+		WithArgs(codeBlockSize, codeSequence).
+		WillReturnResult(sqlmock.NewResult(100, 1))
+
+	seen := make(map[string]bool)
+	for i := uint64(0); i < 81; i++ {
+		code, err := g.Next(ctx)
+		if err != nil {
+			t.Fatalf("Next #%d: %v", i, err)
+		}
+		if seen[code] {
+			t.Fatalf("Next #%d = %q was already issued", i, code)
+		}
+		seen[code] = true
+		if n, ok := g.sequence(code); !ok || n != i {
+			t.Fatalf("sequence(%q) = %d, %v, want %d", code, n, ok, i)
+		}
+	}
+
+	if _, err := g.Next(ctx); !errors.Is(err, ErrCodeSpaceExhausted) {
+		t.Errorf("Next past the code space error = %v, want %v", err, ErrCodeSpaceExhausted)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	t.Run("Missing Sequence Row", func(t *testing.T) {
+		g := newSequentialCodes(db.New(mockDB), f, "secret")
+		mock.ExpectExec("UPDATE code_sequences SET next_value").
+			WithArgs(codeBlockSize, codeSequence).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		if _, err := g.Next(ctx); err == nil {
+			t.Error("Next succeeded without a sequence row")
+		}
+	})
+}
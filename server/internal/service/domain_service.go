@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go-shortener-sqlc/internal/db"
+)
+
+const domainReload = time.Minute // how often other instances' domain changes are picked up
+
+var (
+	ErrInvalidDomain  = errors.New("domain must be a host name such as go.example.com")
+	ErrUnknownDomain  = errors.New("domain is not registered")
+	ErrDomainConflict = errors.New("domain is already registered")
+	ErrDomainInUse    = errors.New("domain still has links")
+)
+
+// Domain is a branded host that links can be created on.
+type Domain struct {
+	ID        int32     `json:"id"`
+	Host      string    `json:"host"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type domainKey struct{}
+
+// WithDomain scopes short code lookups made with ctx to domain ("" is the default domain).
+func WithDomain(ctx context.Context, domain string) context.Context {
+	return context.WithValue(ctx, domainKey{}, domain)
+}
+
+// DomainFrom returns the domain set by WithDomain, or "" for the default domain.
+func DomainFrom(ctx context.Context) string {
+	domain, _ := ctx.Value(domainKey{}).(string)
+	return domain
+}
+
+// ShortURL builds the public URL of code. Codes on the default domain live under
+// baseURL; branded domains are served at their root with baseURL's scheme.
+func ShortURL(baseURL, domain, code string) string {
+	if domain == "" {
+		return strings.TrimSuffix(baseURL, "/") + "/" + code
+	}
+	scheme := "https"
+	if u, err := url.Parse(baseURL); err == nil && u.Scheme != "" {
+		scheme = u.Scheme
+	}
+	return scheme + "://" + domain + "/" + code
+}
+
+var domainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)+[a-z]([a-z0-9-]*[a-z0-9])?$`)
+
+// DomainService keeps the registered domains in memory so every request can be
+// matched by Host without a query. Writes apply immediately on this instance and
+// within domainReload on the others.
+type DomainService struct {
+	q           *db.Queries
+	defaultHost string // host of BASE_URL; always the default domain
+
+	mu    sync.RWMutex
+	hosts map[string]bool
+
+	reloads *reloader
+}
+
+// NewDomainService loads the domains and starts the reload loop. Call Close on shutdown.
+func NewDomainService(q *db.Queries, baseURL string) *DomainService {
+	s := &DomainService{q: q, hosts: make(map[string]bool)}
+	if u, err := url.Parse(baseURL); err == nil {
+		s.defaultHost = normalizeHost(u.Hostname())
+	}
+	s.reloads = startReloader("domains", domainReload, s.Reload)
+	return s
+}
+
+// Close stops the reload loop.
+func (s *DomainService) Close() {
+	if s == nil {
+		return
+	}
+	s.reloads.close()
+}
+
+// Reload re-reads the registered domains. On error the previous set is kept.
+func (s *DomainService) Reload(ctx context.Context) error {
+	if s.q == nil {
+		return nil
+	}
+	rows, err := s.q.ListDomains(ctx)
+	if err != nil {
+		return err
+	}
+	hosts := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		hosts[row.Host] = true
+	}
+	s.mu.Lock()
+	s.hosts = hosts
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *DomainService) registered(host string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hosts[host]
+}
+
+// Resolve maps a request's Host header to the domain its links live on. Hosts that
+// are not registered (BASE_URL's, localhost, ...) map to the default domain.
+func (s *DomainService) Resolve(hostport string) string {
+	if s == nil {
+		return ""
+	}
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = normalizeHost(host)
+	if s.registered(host) {
+		return host
+	}
+	return ""
+}
+
+// Canonical normalizes a domain chosen by a client: "" and BASE_URL's host are the
+// default domain, anything else must be registered. A nil DomainService only knows
+// the default domain.
+func (s *DomainService) Canonical(domain string) (string, error) {
+	host := normalizeHost(strings.TrimSpace(domain))
+	if host == "" {
+		return "", nil
+	}
+	if s == nil {
+		return "", ErrUnknownDomain
+	}
+	if host == s.defaultHost {
+		return "", nil
+	}
+	if !s.registered(host) {
+		return "", ErrUnknownDomain
+	}
+	return host, nil
+}
+
+// --- Admin CRUD ---
+
+// List returns the registered domains by host.
+func (s *DomainService) List(ctx context.Context) ([]Domain, error) {
+	rows, err := s.q.ListDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+	domains := make([]Domain, len(rows))
+	for i, row := range rows {
+		domains[i] = Domain(row)
+	}
+	return domains, nil
+}
+
+// Create registers host. DNS for it must point at this server.
+func (s *DomainService) Create(ctx context.Context, host string) (*Domain, error) {
+	host = normalizeHost(strings.TrimSpace(host))
+	if len(host) > 253 || !domainPattern.MatchString(host) {
+		return nil, ErrInvalidDomain
+	}
+	if host == s.defaultHost {
+		return nil, ErrDomainConflict
+	}
+
+	result, err := s.q.CreateDomain(ctx, host)
+	if err != nil {
+		if isDuplicateKey(err) {
+			return nil, ErrDomainConflict
+		}
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	s.reloads.now(ctx)
+
+	row, err := s.q.GetDomain(ctx, int32(id))
+	if err != nil {
+		return nil, err
+	}
+	domain := Domain(row)
+	return &domain, nil
+}
+
+// Delete removes a domain that no link uses anymore.
+func (s *DomainService) Delete(ctx context.Context, id int32) error {
+	row, err := s.q.GetDomain(ctx, id)
+	if err != nil {
+		return err
+	}
+	n, err := s.q.CountURLsByDomain(ctx, row.Host)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrDomainInUse
+	}
+	if deleted, err := s.q.DeleteDomain(ctx, id); err != nil {
+		return err
+	} else if deleted == 0 {
+		return sql.ErrNoRows
+	}
+	s.reloads.now(ctx)
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDomainResolve(t *testing.T) {
+	s := &DomainService{defaultHost: "sho.rt", hosts: map[string]bool{"go.example.com": true}}
+
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{"go.example.com", "go.example.com"},
+		{"GO.Example.com.:8080", "go.example.com"},
+		{"sho.rt", ""},
+		{"localhost:8080", ""},
+		{"other.example.com", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.host, func(t *testing.T) {
+			if got := s.Resolve(tc.host); got != tc.expected {
+				t.Errorf("Resolve(%q) = %q, want %q", tc.host, got, tc.expected)
+			}
+		})
+	}
+
+	var none *DomainService
+	if got := none.Resolve("go.example.com"); got != "" {
+		t.Errorf("nil Resolve = %q, want default domain", got)
+	}
+}
+
+func TestDomainCanonical(t *testing.T) {
+	s := &DomainService{defaultHost: "sho.rt", hosts: map[string]bool{"go.example.com": true}}
+
+	tests := []struct {
+		name     string
+		domain   string
+		expected string
+		err      error
+	}{
+		{name: "Default", domain: "", expected: ""},
+		{name: "Base URL Host", domain: "Sho.rt", expected: ""},
+		{name: "Registered", domain: " go.example.com ", expected: "go.example.com"},
+		{name: "Unregistered", domain: "other.example.com", err: ErrUnknownDomain},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := s.Canonical(tc.domain)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("Canonical(%q) error = %v, want %v", tc.domain, err, tc.err)
+			}
+			if got != tc.expected {
+				t.Errorf("Canonical(%q) = %q, want %q", tc.domain, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestShortURL(t *testing.T) {
+	tests := []struct {
+		baseURL, domain, expected string
+	}{
+		{"https://sho.rt", "", "https://sho.rt/abc123"},
+		{"http://localhost:8080/", "", "http://localhost:8080/abc123"},
+		{"http://localhost:8080", "go.example.com", "http://go.example.com/abc123"},
+		{"https://sho.rt", "go.example.com", "https://go.example.com/abc123"},
+	}
+
+	for _, tc := range tests {
+		if got := ShortURL(tc.baseURL, tc.domain, "abc123"); got != tc.expected {
+			t.Errorf("ShortURL(%q, %q) = %q, want %q", tc.baseURL, tc.domain, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-shortener-sqlc/internal/db"
+	"go-shortener-sqlc/internal/utils"
+)
+
+const (
+	healthTimeout    = 10 * time.Second
+	healthBatchSize  = 500
+	healthPoll       = time.Minute     // how often the worker looks for links that are due
+	healthHostDelay  = 2 * time.Second // pause between two requests to the same host
+	healthUserAgent  = "go-shortener-healthcheck/1.0"
+	healthMaxDrain   = 4 << 10 // body bytes read from a GET so the connection can be reused
+	healthErrMaxSize = 255
+)
+
+// Defaults for HealthConfig fields left at zero.
+const (
+	DefaultHealthConcurrency      = 8
+	DefaultHealthFailureThreshold = 3
+)
+
+// HealthConfig controls the destination health checker.
+type HealthConfig struct {
+	Interval         time.Duration // how often each link is checked; 0 disables the background worker
+	Concurrency      int           // hosts checked in parallel
+	FailureThreshold int           // consecutive failed checks before a link counts as broken
+	FallbackURL      string        // where broken links redirect; empty keeps redirecting to the destination
+}
+
+// URLHealth is the result of the latest check of a link's destination.
+type URLHealth struct {
+	Domain              string    `json:"domain"`
+	ShortCode           string    `json:"short_code"`
+	OriginalURL         string    `json:"original_url"`
+	StatusCode          *int32    `json:"status_code"` // nil when no response was received
+	LatencyMs           int32     `json:"latency_ms"`
+	Error               string    `json:"error,omitempty"`
+	ConsecutiveFailures int32     `json:"consecutive_failures"`
+	Broken              bool      `json:"broken"`
+	LastCheckedAt       time.Time `json:"last_checked_at"`
+}
+
+// URLHealthPage is a paginated list of link health results.
+type URLHealthPage struct {
+	Items []URLHealth `json:"items"`
+	Total int64       `json:"total"`
+	Page  int         `json:"page"`
+	Limit int         `json:"limit"`
+}
+
+// healthResult is the outcome of a single check.
+type healthResult struct {
+	statusCode int // 0 when the request failed
+	latency    time.Duration
+	err        error
+}
+
+func (r healthResult) ok() bool {
+	return r.err == nil && r.statusCode < 400
+}
+
+// HealthService periodically requests every active link's original_url and records
+// the status code, latency and number of consecutive failures. Requests go through
+// the same SSRF-safe client as link previews. Hosts are checked in parallel up to
+// Concurrency, but links sharing a host are checked one after another with a pause
+// in between, so a popular domain never sees a burst of requests from us.
+type HealthService struct {
+	q         *db.Queries
+	urls      *URLService // cache invalidation when a link becomes broken or recovers
+	cfg       HealthConfig
+	client    *http.Client
+	validate  func(rawURL string) error
+	hostDelay time.Duration
+	quit      chan struct{}
+	wg        sync.WaitGroup
+	once      sync.Once
+}
+
+// NewHealthService starts the background checker when cfg.Interval is set. Call Close on shutdown.
+func NewHealthService(q *db.Queries, urls *URLService, cfg HealthConfig) *HealthService {
+	return newHealthService(q, urls, cfg, utils.ValidateTargetURL, isBlockedIP)
+}
+
+func newHealthService(q *db.Queries, urls *URLService, cfg HealthConfig, validate func(string) error, blockIP func(net.IP) bool) *HealthService {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = DefaultHealthConcurrency
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = DefaultHealthFailureThreshold
+	}
+	if cfg.FallbackURL != "" {
+		if err := ValidateDestination(cfg.FallbackURL); err != nil {
+			slog.Warn("Ignoring invalid health check fallback URL", "url", cfg.FallbackURL, "error", err)
+			cfg.FallbackURL = ""
+		}
+	}
+
+	s := &HealthService{
+		q:         q,
+		urls:      urls,
+		cfg:       cfg,
+		client:    newSafeClient(validate, blockIP, healthTimeout),
+		validate:  validate,
+		hostDelay: healthHostDelay,
+		quit:      make(chan struct{}),
+	}
+	if cfg.Interval > 0 {
+		s.wg.Add(1)
+		go s.loop()
+	}
+	return s
+}
+
+// Close stops the background checker, waiting for in-flight checks to finish.
+func (s *HealthService) Close() {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() { close(s.quit) })
+	s.wg.Wait()
+}
+
+func (s *HealthService) loop() {
+	defer s.wg.Done()
+	for {
+		for {
+			n, err := s.CheckDue(context.Background())
+			if err != nil {
+				slog.Error("Health check round failed", "error", err)
+				break
+			}
+			if n < healthBatchSize {
+				break
+			}
+		}
+		select {
+		case <-s.quit:
+			return
+		case <-time.After(healthPoll):
+		}
+	}
+}
+
+// CheckDue checks one batch of links whose last check is older than the interval
+// (never-checked links first) and returns how many results were stored. Unstored
+// links come back in the next batch, so the loop only continues at once when the
+// whole batch was stored.
+func (s *HealthService) CheckDue(ctx context.Context) (int, error) {
+	links, err := s.q.ListURLsForHealthCheck(ctx, db.ListURLsForHealthCheckParams{
+		CheckedBefore: time.Now().UTC().Add(-s.cfg.Interval),
+		Limit:         healthBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	byHost := make(map[string][]db.ListURLsForHealthCheckRow)
+	var hosts []string
+	for _, link := range links {
+		host := ""
+		if u, err := url.Parse(link.OriginalUrl); err == nil {
+			host = strings.ToLower(u.Hostname())
+		}
+		if _, ok := byHost[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], link)
+	}
+
+	sem := make(chan struct{}, s.cfg.Concurrency)
+	var wg sync.WaitGroup
+	var stored atomic.Int64
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(links []db.ListURLsForHealthCheckRow) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			for i, link := range links {
+				if i > 0 && !s.pause() {
+					return
+				}
+				prev := link.ConsecutiveFailures.Int32
+				if _, err := s.record(WithDomain(ctx, link.Domain), link.ID, link.ShortCode, link.OriginalUrl, prev); err != nil {
+					slog.Error("Failed to store health check", "url_id", link.ID, "error", err)
+					continue
+				}
+				stored.Add(1)
+			}
+		}(byHost[host])
+	}
+	wg.Wait()
+	return int(stored.Load()), nil
+}
+
+// pause waits hostDelay between requests to the same host. It returns false on shutdown.
+func (s *HealthService) pause() bool {
+	select {
+	case <-s.quit:
+		return false
+	case <-time.After(s.hostDelay):
+		return true
+	}
+}
+
+// Get returns the latest health check of a short code. Links that were never
+// checked return sql.ErrNoRows.
+func (s *HealthService) Get(ctx context.Context, code string) (*URLHealth, error) {
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
+	}
+	row, err := s.q.GetURLHealth(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.newURLHealth(url.Domain, url.ShortCode, url.OriginalUrl, row.StatusCode, row.LatencyMs, row.Error, row.ConsecutiveFailures, row.LastCheckedAt), nil
+}
+
+// Check requests the destination of a short code now and stores the result.
+func (s *HealthService) Check(ctx context.Context, code string) (*URLHealth, error) {
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
+	}
+	var prev int32
+	row, err := s.q.GetURLHealth(ctx, url.ID)
+	if err == nil {
+		prev = row.ConsecutiveFailures
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+	return s.record(ctx, url.ID, url.ShortCode, url.OriginalUrl, prev)
+}
+
+// ListBroken returns the links that failed at least FailureThreshold checks in a row, worst first.
+func (s *HealthService) ListBroken(ctx context.Context, page, limit int) (*URLHealthPage, error) {
+	threshold := int32(s.cfg.FailureThreshold)
+	rows, err := s.q.ListBrokenURLs(ctx, db.ListBrokenURLsParams{
+		MinFailures: threshold,
+		Limit:       int32(limit),
+		Offset:      int32((page - 1) * limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.q.CountBrokenURLs(ctx, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]URLHealth, len(rows))
+	for i, r := range rows {
+		items[i] = *s.newURLHealth(r.Domain, r.ShortCode, r.OriginalUrl, r.StatusCode, r.LatencyMs, r.Error, r.ConsecutiveFailures, r.LastCheckedAt)
+	}
+	return &URLHealthPage{Items: items, Total: total, Page: page, Limit: limit}, nil
+}
+
+// fallbackFor returns the fallback destination if the link is broken, or "" if it is
+// healthy, unchecked, or no fallback is configured.
+func (s *HealthService) fallbackFor(ctx context.Context, urlID int32) (string, error) {
+	if s == nil || s.cfg.FallbackURL == "" {
+		return "", nil
+	}
+	row, err := s.q.GetURLHealth(ctx, urlID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	if row.ConsecutiveFailures < int32(s.cfg.FailureThreshold) {
+		return "", nil
+	}
+	return s.cfg.FallbackURL, nil
+}
+
+// record checks dest and stores the outcome. prev is the stored number of consecutive failures.
+func (s *HealthService) record(ctx context.Context, urlID int32, code, dest string, prev int32) (*URLHealth, error) {
+	res := s.check(ctx, dest)
+
+	failures := int32(0)
+	errMsg := ""
+	if !res.ok() {
+		failures = prev + 1
+		if res.err != nil {
+			errMsg = truncate(res.err.Error(), healthErrMaxSize)
+		} else {
+			errMsg = fmt.Sprintf("destination returned status %d", res.statusCode)
+		}
+	}
+	status := sql.NullInt32{Int32: int32(res.statusCode), Valid: res.statusCode != 0}
+	latency := int32(res.latency.Milliseconds())
+	now := time.Now().UTC().Truncate(time.Second)
+
+	err := s.q.UpsertURLHealth(ctx, db.UpsertURLHealthParams{
+		UrlID:               urlID,
+		StatusCode:          status,
+		LatencyMs:           latency,
+		Error:               errMsg,
+		ConsecutiveFailures: failures,
+		LastCheckedAt:       now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The fallback is cached with the redirect, so drop it when the broken state flips
+	threshold := int32(s.cfg.FailureThreshold)
+	if s.urls != nil && s.cfg.FallbackURL != "" && (prev >= threshold) != (failures >= threshold) {
+		s.urls.invalidate(ctx, code)
+	}
+	return s.newURLHealth(DomainFrom(ctx), code, dest, status, latency, errMsg, failures, now), nil
+}
+
+// check sends a HEAD request to dest, retrying with GET when the server rejects
+// HEAD or answers with an error (many servers handle HEAD poorly).
+func (s *HealthService) check(ctx context.Context, dest string) healthResult {
+	if err := s.validate(dest); err != nil {
+		return healthResult{err: ErrPreviewUnsafe}
+	}
+
+	res := s.do(ctx, http.MethodHead, dest)
+	if res.err == nil && res.statusCode >= 400 {
+		res = s.do(ctx, http.MethodGet, dest)
+	}
+	return res
+}
+
+func (s *HealthService) do(ctx context.Context, method, dest string) healthResult {
+	req, err := http.NewRequestWithContext(ctx, method, dest, nil)
+	if err != nil {
+		return healthResult{err: err}
+	}
+	req.Header.Set("User-Agent", healthUserAgent)
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		if errors.Is(err, ErrPreviewUnsafe) {
+			err = ErrPreviewUnsafe
+		}
+		return healthResult{latency: latency, err: err}
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, healthMaxDrain))
+	resp.Body.Close()
+	return healthResult{statusCode: resp.StatusCode, latency: latency}
+}
+
+func (s *HealthService) newURLHealth(domain, code, dest string, status sql.NullInt32, latency int32, errMsg string, failures int32, checkedAt time.Time) *URLHealth {
+	h := &URLHealth{
+		Domain:              domain,
+		ShortCode:           code,
+		OriginalURL:         dest,
+		LatencyMs:           latency,
+		Error:               errMsg,
+		ConsecutiveFailures: failures,
+		Broken:              failures >= int32(s.cfg.FailureThreshold),
+		LastCheckedAt:       checkedAt,
+	}
+	if status.Valid {
+		h.StatusCode = &status.Int32
+	}
+	return h
+}
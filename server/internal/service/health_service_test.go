@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-shortener-sqlc/internal/db"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestHealthCheck(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/gone", http.NotFound)
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/metadata", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := newHealthService(nil, nil, HealthConfig{}, allowTarget(srv), allowLoopback)
+	defer s.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		statusCode int
+		ok         bool
+		err        error
+	}{
+		{name: "OK", path: "/ok", statusCode: 200, ok: true},
+		{name: "Falls Back To GET", path: "/no-head", statusCode: 200, ok: true},
+		{name: "Follows Redirects", path: "/moved", statusCode: 200, ok: true},
+		{name: "Not Found", path: "/gone", statusCode: 404},
+		{name: "Unsafe Redirect Hop", path: "/metadata", err: ErrPreviewUnsafe},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := s.check(context.Background(), srv.URL+tc.path)
+			if !errors.Is(res.err, tc.err) {
+				t.Fatalf("check error = %v, want %v", res.err, tc.err)
+			}
+			if res.statusCode != tc.statusCode || res.ok() != tc.ok {
+				t.Errorf("check = %d (ok %v), want %d (ok %v)", res.statusCode, res.ok(), tc.statusCode, tc.ok)
+			}
+		})
+	}
+}
+
+func TestHealthRecordAndFallback(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	q := db.New(mockDB)
+	s := newHealthService(q, nil, HealthConfig{FailureThreshold: 2, FallbackURL: "https://93.184.215.14/gone"},
+		allowTarget(srv), allowLoopback)
+	defer s.Close()
+
+	// Second failure in a row reaches the threshold
+	mock.ExpectExec("INSERT INTO url_health").
+		WithArgs(int32(1), int32(404), sqlmock.AnyArg(), "destination returned status 404", int32(2), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	health, err := s.record(context.Background(), 1, "abc123", srv.URL, 1)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if !health.Broken || health.StatusCode == nil || *health.StatusCode != 404 {
+		t.Errorf("record = %+v, want broken with status 404", health)
+	}
+
+	healthRows := func(failures int32) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"url_id", "status_code", "latency_ms", "error", "consecutive_failures", "last_checked_at"}).
+			AddRow(1, 404, 12, "destination returned status 404", failures, time.Now())
+	}
+
+	tests := []struct {
+		name     string
+		mock     func()
+		expected string
+	}{
+		{
+			name: "Broken",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM url_health").WithArgs(int32(1)).WillReturnRows(healthRows(2))
+			},
+			expected: "https://93.184.215.14/gone",
+		},
+		{
+			name: "Below Threshold",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM url_health").WithArgs(int32(1)).WillReturnRows(healthRows(1))
+			},
+		},
+		{
+			name: "Never Checked",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM url_health").WithArgs(int32(1)).WillReturnError(sql.ErrNoRows)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+			got, err := s.fallbackFor(context.Background(), 1)
+			if err != nil {
+				t.Fatalf("fallbackFor: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("fallbackFor = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateDestinationClearsHealth(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	q := db.New(mockDB)
+	urls := NewURLService(mockDB, q, nil, nil)
+	urls.Health = NewHealthService(q, urls, HealthConfig{FailureThreshold: 2, FallbackURL: "https://93.184.215.14/gone"})
+	defer urls.Health.Close()
+	ctx := context.Background()
+	fixed := "https://93.184.215.14/fixed"
+
+	row := func(dest string) *sqlmock.Rows {
+		return urlTestRows().AddRow(1, "abc123", dest, "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now())
+	}
+	mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+		WithArgs("", "abc123").
+		WillReturnRows(row("https://93.184.215.14/broken"))
+	mock.ExpectExec("UPDATE urls").WithArgs(fixed, hashURL(fixed), int32(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM url_health").WithArgs(int32(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+		WithArgs("", "abc123").
+		WillReturnRows(row(fixed))
+	if _, err := urls.UpdateDestination(ctx, "abc123", fixed); err != nil {
+		t.Fatalf("UpdateDestination: %v", err)
+	}
+
+	// The failures of the old destination no longer count
+	mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+		WithArgs("", "abc123").
+		WillReturnRows(row(fixed))
+	mock.ExpectQuery("SELECT (.+) FROM url_rules").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT (.+) FROM url_variants").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT (.+) FROM url_health").WithArgs(int32(1)).WillReturnError(sql.ErrNoRows)
+	resolved, err := urls.GetOriginalURL(ctx, "abc123", Visitor{})
+	if err != nil || resolved.Fallback != "" || resolved.OriginalURL != fixed {
+		t.Fatalf("redirect after update = %+v, %v", resolved, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckDueCountsStoredResults(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	s := newHealthService(db.New(mockDB), nil, HealthConfig{}, allowTarget(srv), allowLoopback)
+	s.hostDelay = 0
+	defer s.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM urls u").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "domain", "short_code", "original_url", "consecutive_failures"}).
+			AddRow(1, "", "abc123", srv.URL+"/a", nil).
+			AddRow(2, "", "def456", srv.URL+"/b", nil))
+	mock.ExpectExec("INSERT INTO url_health").WithArgs(int32(1), sqlmock.AnyArg(), sqlmock.AnyArg(), "", int32(0), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO url_health").WithArgs(int32(2), sqlmock.AnyArg(), sqlmock.AnyArg(), "", int32(0), sqlmock.AnyArg()).
+		WillReturnError(errors.New("database is down"))
+
+	// A failed store must not count, or the loop would re-check the same links at once
+	n, err := s.CheckDue(context.Background())
+	if err != nil || n != 1 {
+		t.Errorf("CheckDue = %d, %v, want 1 stored result", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
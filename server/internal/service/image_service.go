@@ -13,9 +13,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
+	"go-shortener-sqlc/internal/cache"
 	"go-shortener-sqlc/internal/db"
 	"go-shortener-sqlc/internal/utils"
 )
@@ -25,6 +27,8 @@ const (
 	ThumbWidth    = 300
 	MediumWidth   = 800
 	JPEGQuality   = 80
+
+	imageCacheTTL = time.Hour
 )
 
 // Allowed MIME types for image uploads
@@ -37,11 +41,16 @@ var allowedMIMETypes = map[string]bool{
 
 type ImageService struct {
 	q         *db.Queries
+	cache     cache.Cache
 	uploadDir string
 }
 
-func NewImageService(q *db.Queries, uploadDir string) *ImageService {
-	return &ImageService{q: q, uploadDir: uploadDir}
+// NewImageService creates the service; a nil cache disables caching of image metadata.
+func NewImageService(q *db.Queries, c cache.Cache, uploadDir string) *ImageService {
+	if c == nil {
+		c = cache.Nop{}
+	}
+	return &ImageService{q: q, cache: c, uploadDir: uploadDir}
 }
 
 // ImageURLs holds the URLs for different image sizes.
@@ -161,16 +170,23 @@ func (s *ImageService) Upload(ctx context.Context, file multipart.File, header *
 	}, nil
 }
 
-// GetByID returns image metadata with URLs.
+// GetByID returns image metadata with URLs. Posts resolve their featured image
+// through it on every view, so results are cached.
 func (s *ImageService) GetByID(ctx context.Context, id string) (*ImageResponse, error) {
-	img, err := s.q.GetImage(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-	return &ImageResponse{
-		Image: img,
-		URLs:  s.buildURLs(img.Filename),
-	}, nil
+	return cache.Fetch(ctx, s.cache, imageCacheKey(id), imageCacheTTL, func(ctx context.Context) (*ImageResponse, error) {
+		img, err := s.q.GetImage(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return &ImageResponse{
+			Image: img,
+			URLs:  s.buildURLs(img.Filename),
+		}, nil
+	})
+}
+
+func imageCacheKey(id string) string {
+	return "image:" + id
 }
 
 // List returns all images with URLs.
@@ -192,11 +208,16 @@ func (s *ImageService) List(ctx context.Context) ([]ImageResponse, error) {
 
 // UpdateMeta updates the SEO fields (alt_text, title) of an image.
 func (s *ImageService) UpdateMeta(ctx context.Context, id, altText, title string) error {
-	return s.q.UpdateImage(ctx, db.UpdateImageParams{
+	err := s.q.UpdateImage(ctx, db.UpdateImageParams{
 		ID:      id,
 		AltText: altText,
 		Title:   title,
 	})
+	if err != nil {
+		return err
+	}
+	s.cache.Delete(ctx, imageCacheKey(id))
+	return nil
 }
 
 // Delete removes image files from disk and metadata from DB.
@@ -217,7 +238,11 @@ func (s *ImageService) Delete(ctx context.Context, id string) error {
 	}
 
 	// 3. Delete from DB
-	return s.q.DeleteImage(ctx, id)
+	if err := s.q.DeleteImage(ctx, id); err != nil {
+		return err
+	}
+	s.cache.Delete(ctx, imageCacheKey(id))
+	return nil
 }
 
 // EnsureUploadDirs creates the upload directories if they don't exist.
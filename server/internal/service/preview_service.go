@@ -0,0 +1,399 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"go-shortener-sqlc/internal/db"
+	"go-shortener-sqlc/internal/utils"
+)
+
+const (
+	previewQueueSize  = 1000
+	previewWorkers    = 4
+	previewTimeout    = 10 * time.Second
+	previewMaxBytes   = 512 << 10 // only the <head> is needed
+	maxFetchRedirects = 5
+	previewUserAgent  = "go-shortener-preview/1.0"
+)
+
+var (
+	ErrPreviewUnsafe  = errors.New("destination is not allowed (private or local address)")
+	ErrPreviewNotHTML = errors.New("destination is not an HTML page")
+)
+
+// Preview statuses. A link without a stored preview reports PreviewPending.
+const (
+	PreviewPending = "pending"
+	PreviewOK      = "ok"
+	PreviewFailed  = "failed"
+)
+
+// LinkPreview is the metadata of a link's destination page.
+type LinkPreview struct {
+	Status      string     `json:"status"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	ImageURL    string     `json:"image_url"`
+	FaviconURL  string     `json:"favicon_url"`
+	Error       string     `json:"error,omitempty"`
+	FetchedAt   *time.Time `json:"fetched_at"`
+}
+
+type previewJob struct {
+	urlID int32
+	dest  string
+}
+
+// PreviewService fetches destination pages in the background and stores their
+// title, description, Open Graph image and favicon. Every request, including each
+// redirect hop, goes through the SSRF checks, and the dialer refuses private
+// addresses so DNS answers cannot be swapped between check and connect.
+type PreviewService struct {
+	q        *db.Queries
+	client   *http.Client
+	validate func(rawURL string) error
+	jobs     chan previewJob
+	quit     chan struct{}
+	wg       sync.WaitGroup
+	once     sync.Once
+}
+
+// NewPreviewService starts the fetch workers. Call Close on shutdown.
+func NewPreviewService(q *db.Queries) *PreviewService {
+	return newPreviewService(q, utils.ValidateTargetURL, isBlockedIP)
+}
+
+func newPreviewService(q *db.Queries, validate func(string) error, blockIP func(net.IP) bool) *PreviewService {
+	s := &PreviewService{
+		q:        q,
+		validate: validate,
+		jobs:     make(chan previewJob, previewQueueSize),
+		quit:     make(chan struct{}),
+	}
+	s.client = newSafeClient(validate, blockIP, previewTimeout)
+
+	for i := 0; i < previewWorkers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+	return s
+}
+
+// isBlockedIP extends utils.IsPrivateIP with the ranges it leaves out (IPv6 ULA, 0.0.0.0).
+func isBlockedIP(ip net.IP) bool {
+	return utils.IsPrivateIP(ip) || ip.IsPrivate() || ip.IsUnspecified()
+}
+
+// newSafeClient returns an HTTP client for fetching user-supplied destinations. Every
+// redirect hop is validated, and the dialer refuses blocked addresses after DNS resolution.
+// Shared by the preview fetcher and the health checker.
+func newSafeClient(validate func(string) error, blockIP func(net.IP) bool, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		// Runs after DNS resolution, on the address actually being connected to
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blockIP(ip) {
+				return ErrPreviewUnsafe
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                  nil, // a proxy would bypass the dial check
+			DialContext:            dialer.DialContext,
+			TLSHandshakeTimeout:    5 * time.Second,
+			ResponseHeaderTimeout:  timeout,
+			MaxResponseHeaderBytes: 64 << 10,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return ErrPreviewUnsafe
+			}
+			if err := validate(req.URL.String()); err != nil {
+				return ErrPreviewUnsafe
+			}
+			return nil
+		},
+	}
+}
+
+// Enqueue schedules a background fetch without blocking. Jobs are dropped if the queue is full.
+func (s *PreviewService) Enqueue(urlID int32, dest string) {
+	if s == nil {
+		return
+	}
+	select {
+	case s.jobs <- previewJob{urlID: urlID, dest: dest}:
+	default:
+		slog.Warn("Preview queue full, dropping job", "url_id", urlID)
+	}
+}
+
+// Close stops the workers. Queued jobs that have not started are dropped.
+func (s *PreviewService) Close() {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() { close(s.quit) })
+	s.wg.Wait()
+}
+
+func (s *PreviewService) worker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case job := <-s.jobs:
+			ctx, cancel := context.WithTimeout(context.Background(), 2*previewTimeout)
+			if _, err := s.update(ctx, job.urlID, job.dest); err != nil {
+				slog.Error("Failed to store link preview", "url_id", job.urlID, "error", err)
+			}
+			cancel()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// Get returns the stored preview of a short code.
+func (s *PreviewService) Get(ctx context.Context, code string) (*LinkPreview, error) {
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
+	}
+	row, err := s.q.GetURLPreview(ctx, url.ID)
+	if err == sql.ErrNoRows {
+		return &LinkPreview{Status: PreviewPending}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return newLinkPreview(row), nil
+}
+
+// Refresh fetches the destination of a short code now and stores the result.
+func (s *PreviewService) Refresh(ctx context.Context, code string) (*LinkPreview, error) {
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
+	}
+	return s.update(ctx, url.ID, url.OriginalUrl)
+}
+
+// update fetches dest and stores the outcome; fetch failures are stored, not returned.
+func (s *PreviewService) update(ctx context.Context, urlID int32, dest string) (*LinkPreview, error) {
+	preview, fetchErr := s.fetch(ctx, dest)
+	if fetchErr != nil {
+		preview = &LinkPreview{Status: PreviewFailed, Error: truncate(fetchErr.Error(), 255)}
+	} else {
+		preview.Status = PreviewOK
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	preview.FetchedAt = &now
+
+	err := s.q.UpsertURLPreview(ctx, db.UpsertURLPreviewParams{
+		UrlID:       urlID,
+		Status:      db.UrlPreviewsStatus(preview.Status),
+		Title:       preview.Title,
+		Description: preview.Description,
+		ImageUrl:    preview.ImageURL,
+		FaviconUrl:  preview.FaviconURL,
+		Error:       preview.Error,
+		FetchedAt:   nullTimePtr(preview.FetchedAt),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// fetch downloads the start of dest and extracts its metadata.
+func (s *PreviewService) fetch(ctx context.Context, dest string) (*LinkPreview, error) {
+	if err := s.validate(dest); err != nil {
+		return nil, ErrPreviewUnsafe
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dest, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", previewUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrPreviewUnsafe) {
+			return nil, ErrPreviewUnsafe
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("destination returned status %d", resp.StatusCode)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrPreviewNotHTML
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, previewMaxBytes))
+	if err != nil {
+		return nil, err
+	}
+	return parsePreview(body, resp.Request.URL), nil
+}
+
+func newLinkPreview(row db.UrlPreview) *LinkPreview {
+	p := &LinkPreview{
+		Status:      string(row.Status),
+		Title:       row.Title,
+		Description: row.Description,
+		ImageURL:    row.ImageUrl,
+		FaviconURL:  row.FaviconUrl,
+		Error:       row.Error,
+	}
+	if row.FetchedAt.Valid {
+		p.FetchedAt = &row.FetchedAt.Time
+	}
+	return p
+}
+
+// --- HTML parsing ---
+
+var (
+	headEndPattern = regexp.MustCompile(`(?i)</head\s*>`)
+	titlePattern   = regexp.MustCompile(`(?is)<title\b[^>]*>(.*?)</title\s*>`)
+	tagPattern     = regexp.MustCompile(`(?is)<(meta|link)\b([^>]*)>`)
+	attrPattern    = regexp.MustCompile(`(?s)([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*("[^"]*"|'[^']*'|[^\s"'>]+)`)
+)
+
+// parsePreview extracts the metadata from the <head> of page. Relative URLs are
+// resolved against base (the final URL after redirects).
+func parsePreview(page []byte, base *url.URL) *LinkPreview {
+	if loc := headEndPattern.FindIndex(page); loc != nil {
+		page = page[:loc[0]]
+	}
+
+	var title, ogTitle, description, ogDescription, image, twitterImage, icon string
+	if m := titlePattern.FindSubmatch(page); m != nil {
+		title = string(m[1])
+	}
+
+	for _, m := range tagPattern.FindAllSubmatch(page, -1) {
+		attrs := parseAttrs(m[2])
+		if strings.EqualFold(string(m[1]), "link") {
+			// rel="icon" and rel="shortcut icon"
+			for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+				if rel == "icon" && icon == "" {
+					icon = attrs["href"]
+				}
+			}
+			continue
+		}
+
+		key := strings.ToLower(attrs["property"])
+		if key == "" {
+			key = strings.ToLower(attrs["name"])
+		}
+		content := attrs["content"]
+		switch key {
+		case "description":
+			description = content
+		case "og:title":
+			ogTitle = content
+		case "og:description":
+			ogDescription = content
+		case "og:image", "og:image:url":
+			if image == "" {
+				image = content
+			}
+		case "twitter:image":
+			twitterImage = content
+		}
+	}
+
+	return &LinkPreview{
+		Title:       cleanText(firstNonEmpty(title, ogTitle), 512),
+		Description: cleanText(firstNonEmpty(description, ogDescription), 1024),
+		ImageURL:    resolveRef(base, firstNonEmpty(image, twitterImage)),
+		FaviconURL:  resolveRef(base, firstNonEmpty(icon, "/favicon.ico")),
+	}
+}
+
+func parseAttrs(raw []byte) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range attrPattern.FindAllSubmatch(raw, -1) {
+		name := strings.ToLower(string(m[1]))
+		val := string(m[2])
+		if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') {
+			val = val[1 : len(val)-1]
+		}
+		if _, ok := attrs[name]; !ok {
+			attrs[name] = html.UnescapeString(val)
+		}
+	}
+	return attrs
+}
+
+// cleanText unescapes entities, collapses whitespace and caps the length (in bytes, on a rune boundary).
+func cleanText(s string, max int) string {
+	s = strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+	if len(s) <= max {
+		return s
+	}
+	s = s[:max]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// resolveRef turns ref into an absolute http(s) URL, or "" if that is not possible.
+func resolveRef(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	s := u.String()
+	if len(s) > maxURLLength {
+		return ""
+	}
+	return s
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
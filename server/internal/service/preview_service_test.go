@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"go-shortener-sqlc/internal/utils"
+)
+
+// allowTarget keeps the SSRF checks for every host except the httptest server.
+func allowTarget(target *httptest.Server) func(string) error {
+	testHost := strings.TrimPrefix(target.URL, "http://")
+	return func(rawURL string) error {
+		if u, err := url.Parse(rawURL); err == nil && u.Host == testHost {
+			return nil
+		}
+		return utils.ValidateTargetURL(rawURL)
+	}
+}
+
+// allowLoopback blocks the same addresses as isBlockedIP, except loopback.
+func allowLoopback(ip net.IP) bool {
+	return !ip.IsLoopback() && isBlockedIP(ip)
+}
+
+func newTestPreviewService(t *testing.T, target *httptest.Server) *PreviewService {
+	t.Helper()
+	s := newPreviewService(nil, allowTarget(target), allowLoopback)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestPreviewFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/start", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/blog/post", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/blog/post", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<!doctype html><html><head>
+			<title>
+				Hello &amp; Welcome
+			</title>
+			<meta name="description" content="A  short   description">
+			<meta property='og:image' content='/img/cover.png'>
+			<link rel="shortcut icon" href="fav.ico">
+			</head><body><meta name="description" content="ignored"></body></html>`)
+	})
+	mux.HandleFunc("/bare", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<head><meta property="og:title" content="OG Title"><meta property="og:description" content="OG Desc"></head>`)
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte{0x89, 'P', 'N', 'G'})
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/metadata", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	})
+	mux.HandleFunc("/huge", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<head>"+strings.Repeat(" ", previewMaxBytes)+"<title>Too far</title></head>")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := newTestPreviewService(t, srv)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected *LinkPreview
+		err      error
+	}{
+		{
+			name: "Follows Redirects And Resolves Relative URLs",
+			path: "/start",
+			expected: &LinkPreview{
+				Title:       "Hello & Welcome",
+				Description: "A short description",
+				ImageURL:    srv.URL + "/img/cover.png",
+				FaviconURL:  srv.URL + "/blog/fav.ico",
+			},
+		},
+		{
+			name: "Open Graph Fallbacks",
+			path: "/bare",
+			expected: &LinkPreview{
+				Title:       "OG Title",
+				Description: "OG Desc",
+				FaviconURL:  srv.URL + "/favicon.ico",
+			},
+		},
+		{
+			name:     "Stops Reading At Size Limit",
+			path:     "/huge",
+			expected: &LinkPreview{FaviconURL: srv.URL + "/favicon.ico"},
+		},
+		{name: "Not HTML", path: "/image.png", err: ErrPreviewNotHTML},
+		{name: "Unsafe Redirect Hop", path: "/metadata", err: ErrPreviewUnsafe},
+		{name: "Error Status", path: "/missing", err: errors.New("destination returned status 404")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := s.fetch(context.Background(), srv.URL+tc.path)
+			if tc.err != nil {
+				if err == nil || err.Error() != tc.err.Error() {
+					t.Fatalf("fetch error = %v, want %v", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetch: %v", err)
+			}
+			if *got != *tc.expected {
+				t.Errorf("fetch = %+v, want %+v", *got, *tc.expected)
+			}
+		})
+	}
+}
+
+func TestPreviewDialGuard(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a blocked address")
+	}))
+	defer srv.Close()
+
+	// The URL check passes (as it would after a DNS rebind), but the dialer still refuses loopback
+	s := newPreviewService(nil, func(string) error { return nil }, isBlockedIP)
+	defer s.Close()
+
+	if _, err := s.fetch(context.Background(), srv.URL); !errors.Is(err, ErrPreviewUnsafe) {
+		t.Errorf("fetch error = %v, want %v", err, ErrPreviewUnsafe)
+	}
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"slices"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/vector"
+
+	"go-shortener-sqlc/internal/utils"
+)
+
+// Module and finder pattern shapes (QROptions.ModuleShape, FinderOuterShape,
+// FinderInnerShape).
+const (
+	QRShapeSquare  = "square"
+	QRShapeCircle  = "circle"
+	QRShapeRounded = "rounded"
+	QRShapeDiamond = "diamond"
+)
+
+var (
+	ErrQRModuleShape = errors.New("module_shape must be one of: square, circle, rounded, diamond")
+	ErrQRFinderShape = errors.New("finder shapes must be one of: square, rounded, circle")
+)
+
+// qrFinderSize is the width of a finder pattern in modules.
+const qrFinderSize = 7
+
+// bezierCircle places the control points of a quarter circle drawn as one cubic curve.
+const bezierCircle = 0.5523
+
+type qrPoint struct{ X, Y float64 }
+
+// qrSegment is a path command: 'M' move, 'L' line, 'C' cubic curve or 'Z' close.
+type qrSegment struct {
+	op  byte
+	pts []qrPoint
+}
+
+// qrPath is an outline on the design grid. Holes run against the outer contour, so
+// the nonzero fill rule every writer uses leaves them empty.
+type qrPath []qrSegment
+
+func (p *qrPath) moveTo(pt qrPoint) { *p = append(*p, qrSegment{'M', []qrPoint{pt}}) }
+func (p *qrPath) lineTo(pt qrPoint) { *p = append(*p, qrSegment{'L', []qrPoint{pt}}) }
+func (p *qrPath) cubeTo(c1, c2, pt qrPoint) {
+	*p = append(*p, qrSegment{'C', []qrPoint{c1, c2, pt}})
+}
+func (p *qrPath) close() { *p = append(*p, qrSegment{'Z', nil}) }
+
+// roundedRect adds a rectangle with corner radii from the top left clockwise. Radii of
+// half the side make a circle; reverse runs the other way for holes.
+func (p *qrPath) roundedRect(x, y, w, h float64, radii [4]float64, reverse bool) {
+	corners := [4]qrPoint{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}
+	order := [4]int{0, 1, 2, 3}
+	if reverse {
+		order = [4]int{0, 3, 2, 1}
+	}
+	for i, c := range order {
+		corner := corners[c]
+		in := towards(corner, corners[order[(i+3)%4]], radii[c])
+		out := towards(corner, corners[order[(i+1)%4]], radii[c])
+		if i == 0 {
+			p.moveTo(in)
+		} else {
+			p.lineTo(in)
+		}
+		if radii[c] > 0 {
+			p.cubeTo(towards(in, corner, radii[c]*bezierCircle), towards(out, corner, radii[c]*bezierCircle), out)
+		}
+	}
+	p.close()
+}
+
+// polygon adds a closed polygon.
+func (p *qrPath) polygon(pts ...qrPoint) {
+	for i, pt := range pts {
+		if i == 0 {
+			p.moveTo(pt)
+		} else {
+			p.lineTo(pt)
+		}
+	}
+	p.close()
+}
+
+// towards returns the point d away from a in the direction of b.
+func towards(a, b qrPoint, d float64) qrPoint {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		return a
+	}
+	return qrPoint{a.X + dx/l*d, a.Y + dy/l*d}
+}
+
+// qrPaint fills a layer: a solid color or, when gradient is set, a gradient over the
+// whole code.
+type qrPaint struct {
+	color    color.NRGBA
+	gradient *utils.Gradient
+}
+
+type qrLayer struct {
+	path  qrPath
+	paint qrPaint
+}
+
+// qrDrawing is a styled QR code on the design grid, shared by the PNG renderer and
+// the vector writers.
+type qrDrawing struct {
+	size   int // grid width and height
+	pixels int // output width and height
+	dpi    int
+	bg     color.NRGBA
+	layers []qrLayer
+	logo   image.Image // centered, nil for none
+}
+
+func newQRDrawing(sym *qrSymbol, opts QROptions) (*qrDrawing, error) {
+	d := &qrDrawing{size: sym.size(), pixels: opts.Size, dpi: opts.DPI, logo: sym.logo}
+	if d.pixels == 0 {
+		d.pixels = d.size
+	}
+	if d.dpi == 0 {
+		d.dpi = qrDefaultDPI
+	}
+
+	var modules qrPaint
+	var err error
+	if d.bg, err = utils.ParseHexColor(opts.BgColor); err != nil {
+		return nil, ErrQRColor
+	}
+	if modules.color, err = utils.ParseHexColor(opts.FgColor); err != nil {
+		return nil, ErrQRColor
+	}
+	if modules.gradient, err = opts.gradient(); err != nil {
+		return nil, err
+	}
+	outer, inner := modules, modules
+	if opts.FinderOuterColor != "" {
+		c, err := utils.ParseHexColor(opts.FinderOuterColor)
+		if err != nil {
+			return nil, ErrQRColor
+		}
+		outer = qrPaint{color: c}
+	}
+	if opts.FinderInnerColor != "" {
+		c, err := utils.ParseHexColor(opts.FinderInnerColor)
+		if err != nil {
+			return nil, ErrQRColor
+		}
+		inner = qrPaint{color: c}
+	}
+
+	n := len(sym.modules)
+	border := float64(sym.quiet * qrModuleWidth)
+	dark := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < n && y < n && sym.modules[y][x] && !inFinder(n, x, y)
+	}
+
+	// Data, timing and alignment modules
+	var data qrPath
+	const m = qrModuleWidth
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if !dark(x, y) {
+				continue
+			}
+			mx, my := border+float64(x*m), border+float64(y*m)
+			switch opts.ModuleShape {
+			case QRShapeCircle:
+				data.roundedRect(mx+2, my+2, m-4, m-4, [4]float64{m/2 - 2, m/2 - 2, m/2 - 2, m/2 - 2}, false)
+			case QRShapeRounded:
+				// Round only the corners with no dark neighbor on either side, so runs stay joined
+				var r [4]float64
+				up, right, down, left := dark(x, y-1), dark(x+1, y), dark(x, y+1), dark(x-1, y)
+				for i, round := range []bool{!up && !left, !up && !right, !down && !right, !down && !left} {
+					if round {
+						r[i] = m / 2
+					}
+				}
+				data.roundedRect(mx, my, m, m, r, false)
+			case QRShapeDiamond:
+				data.polygon(qrPoint{mx + m/2, my}, qrPoint{mx + m, my + m/2}, qrPoint{mx + m/2, my + m}, qrPoint{mx, my + m/2})
+			default:
+				// Merge runs so square modules have no seams
+				start := x
+				for dark(x+1, y) {
+					x++
+				}
+				data.roundedRect(border+float64(start*m), my, float64((x-start+1)*m), m, [4]float64{}, false)
+			}
+		}
+	}
+	d.layers = append(d.layers, qrLayer{data, modules})
+
+	// Finder patterns: a 7x7 ring around a 3x3 center
+	var rings, centers qrPath
+	for _, corner := range []image.Point{{0, 0}, {n - qrFinderSize, 0}, {0, n - qrFinderSize}} {
+		fx, fy := border+float64(corner.X*m), border+float64(corner.Y*m)
+		outerR, holeR := 0.0, 0.0
+		switch opts.FinderOuterShape {
+		case QRShapeRounded:
+			outerR, holeR = 2*m, m
+		case QRShapeCircle:
+			outerR, holeR = 3.5*m, 2.5*m
+		}
+		rings.roundedRect(fx, fy, 7*m, 7*m, [4]float64{outerR, outerR, outerR, outerR}, false)
+		rings.roundedRect(fx+m, fy+m, 5*m, 5*m, [4]float64{holeR, holeR, holeR, holeR}, true)
+
+		centerR := 0.0
+		switch opts.FinderInnerShape {
+		case QRShapeRounded:
+			centerR = 0.75 * m
+		case QRShapeCircle:
+			centerR = 1.5 * m
+		}
+		centers.roundedRect(fx+2*m, fy+2*m, 3*m, 3*m, [4]float64{centerR, centerR, centerR, centerR}, false)
+	}
+	d.layers = append(d.layers, qrLayer{rings, outer}, qrLayer{centers, inner})
+	return d, nil
+}
+
+// inFinder reports whether module (x, y) of an n-module code is part of a finder pattern.
+func inFinder(n, x, y int) bool {
+	left, top := x < qrFinderSize, y < qrFinderSize
+	right, bottom := x >= n-qrFinderSize, y >= n-qrFinderSize
+	return (left && top) || (right && top) || (left && bottom)
+}
+
+// logoRect is where the logo goes on the grid.
+func (d *qrDrawing) logoRect() image.Rectangle {
+	b := d.logo.Bounds()
+	x, y := (d.size-b.Dx())/2, (d.size-b.Dy())/2
+	return image.Rect(x, y, x+b.Dx(), y+b.Dy())
+}
+
+// raster renders the drawing with module pixels per module, offset by the extra
+// margin that fits the grid into the output size.
+func (d *qrDrawing) raster(module int, offset image.Point) image.Image {
+	bounds := image.Rect(0, 0, d.pixels, d.pixels)
+	img := image.NewRGBA(bounds)
+	draw.Draw(img, bounds, image.NewUniform(d.bg), image.Point{}, draw.Src)
+
+	scale := float64(module) / qrModuleWidth
+	px := func(pt qrPoint) (float32, float32) {
+		return float32(float64(offset.X) + pt.X*scale), float32(float64(offset.Y) + pt.Y*scale)
+	}
+	r := vector.NewRasterizer(d.pixels, d.pixels)
+	// Layers painted with the same gradient (data modules and finders by default) share one image of it
+	gradients := make(map[*utils.Gradient]image.Image)
+	for _, layer := range d.layers {
+		r.Reset(d.pixels, d.pixels)
+		for _, seg := range layer.path {
+			switch seg.op {
+			case 'M':
+				r.MoveTo(px(seg.pts[0]))
+			case 'L':
+				r.LineTo(px(seg.pts[0]))
+			case 'C':
+				x1, y1 := px(seg.pts[0])
+				x2, y2 := px(seg.pts[1])
+				x3, y3 := px(seg.pts[2])
+				r.CubeTo(x1, y1, x2, y2, x3, y3)
+			case 'Z':
+				r.ClosePath()
+			}
+		}
+
+		var src image.Image = image.NewUniform(layer.paint.color)
+		if g := layer.paint.gradient; g != nil {
+			if gradients[g] == nil {
+				gradients[g] = utils.GenerateGradient(d.pixels, d.pixels, *g)
+			}
+			src = gradients[g]
+		}
+		r.Draw(img, bounds, src, image.Point{})
+	}
+
+	if d.logo != nil {
+		logo := d.logo
+		lb := logo.Bounds()
+		if module != qrModuleWidth {
+			logo = scaleImage(logo, max(lb.Dx()*module/qrModuleWidth, 1), max(lb.Dy()*module/qrModuleWidth, 1))
+			lb = logo.Bounds()
+		}
+		x := (d.pixels - lb.Dx()) / 2
+		y := (d.pixels - lb.Dy()) / 2
+		draw.Draw(img, image.Rect(x, y, x+lb.Dx(), y+lb.Dy()), logo, lb.Min, draw.Over)
+	}
+	return img
+}
+
+func scaleImage(img image.Image, w, h int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
+	return dst
+}
+
+// hexColor formats c as #rrggbb; alpha is written separately.
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// validShape reports whether shape is empty (square) or one of allowed.
+func validShape(shape string, allowed ...string) bool {
+	return shape == "" || slices.Contains(allowed, shape)
+}
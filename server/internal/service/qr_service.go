@@ -3,17 +3,123 @@ package service
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"image"
-	"image/draw"
+	"image/png"
 	"io"
+	"math"
+	"strings"
 
 	"github.com/yeqown/go-qrcode/v2"
-	"github.com/yeqown/go-qrcode/writer/standard"
 
 	"go-shortener-sqlc/internal/utils"
 )
 
+// QRFormat is an output format of WriteQR.
+type QRFormat string
+
+const (
+	QRFormatPNG QRFormat = "png"
+	QRFormatSVG QRFormat = "svg"
+	QRFormatPDF QRFormat = "pdf"
+	QRFormatEPS QRFormat = "eps"
+)
+
+// QRFormats lists the formats with their media types, PNG first as the default.
+var QRFormats = []struct {
+	Format      QRFormat
+	ContentType string
+}{
+	{QRFormatPNG, "image/png"},
+	{QRFormatSVG, "image/svg+xml"},
+	{QRFormatPDF, "application/pdf"},
+	{QRFormatEPS, "application/postscript"},
+}
+
+var (
+	ErrQRFormat          = errors.New("format must be one of: png, svg, pdf, eps")
+	ErrQRColor           = errors.New("colors must be hex such as #1A2B3C")
+	ErrQRErrorCorrection = errors.New("error_correction must be one of: L, M, Q, H")
+	ErrQRQuietZone       = errors.New("quiet_zone must be 0-16 modules")
+	ErrQRSize            = errors.New("size must be 64-4096 pixels")
+	ErrQRDPI             = errors.New("dpi must be 72-2400")
+	ErrQRLogoTooLarge    = errors.New("logo hides more of the code than error correction can recover; use a smaller logo_size")
+	ErrQRGradient        = errors.New("gradient_stops must be 2-16 hex colors, each with an optional position such as #FF0000 40%")
+	ErrQRGradientType    = errors.New("gradient_type must be linear or radial")
+	ErrQRGradientAngle   = errors.New("gradient_angle must be a number of degrees")
+	ErrQRGradientCenter  = errors.New("gradient_center_x and gradient_center_y must be 0-1")
+)
+
+// ParseQRFormat returns the format named s (case-insensitive); empty means PNG.
+func ParseQRFormat(s string) (QRFormat, error) {
+	if s == "" {
+		return QRFormatPNG, nil
+	}
+	for _, f := range QRFormats {
+		if strings.EqualFold(s, string(f.Format)) {
+			return f.Format, nil
+		}
+	}
+	return "", ErrQRFormat
+}
+
+// ContentType returns the media type of f.
+func (f QRFormat) ContentType() string {
+	for _, ff := range QRFormats {
+		if ff.Format == f {
+			return ff.ContentType
+		}
+	}
+	return "application/octet-stream"
+}
+
+// Gradient types (QROptions.GradientType).
+const (
+	QRGradientLinear = "linear"
+	QRGradientRadial = "radial"
+)
+
+// Layout of every output: modules are qrModuleWidth pixels on the design grid, which
+// the PNG fills 1:1 unless Size is set. Vector pages print it at qrDefaultDPI.
+const (
+	qrModuleWidth     = 40
+	qrDefaultQuiet    = 1 // modules; the QR spec asks for 4, kept at 1 for existing codes
+	qrMaxQuiet        = 16
+	qrDefaultDPI      = 300
+	qrMinSize         = 64
+	qrMaxSize         = 4096
+	qrMinDPI          = 72
+	qrMaxDPI          = 2400
+	qrMaxLogoSize     = 240
+	qrDefaultLogoSize = 100
+)
+
+// qrLevels are the error correction levels from lowest to highest. maxLogo is the share
+// of modules a logo may hide: about half of what the level restores, leaving the rest
+// for print defects and the partly covered codewords around the logo.
+var qrLevels = []struct {
+	name    string
+	option  qrcode.EncodeOption
+	maxLogo float64
+}{
+	{"L", qrcode.WithErrorCorrectionLevel(qrcode.ErrorCorrectionLow), 0.035},
+	{"M", qrcode.WithErrorCorrectionLevel(qrcode.ErrorCorrectionMedium), 0.075},
+	{"Q", qrcode.WithErrorCorrectionLevel(qrcode.ErrorCorrectionQuart), 0.125},
+	{"H", qrcode.WithErrorCorrectionLevel(qrcode.ErrorCorrectionHighest), 0.15},
+}
+
+func qrLevelIndex(name string) int {
+	for i, l := range qrLevels {
+		if l.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
 type QRService struct {
 	BaseURL string
 }
@@ -23,113 +129,314 @@ func NewQRService(baseURL string) *QRService {
 }
 
 type QROptions struct {
-	Logo         image.Image
-	LogoSize     int
-	BorderRadius int
-	FgColor      string
-	BgColor      string
+	Logo          image.Image
+	LogoSize      int // width in pixels of the design grid, scaled with Size
+	BorderRadius  int
+	FgColor       string
+	BgColor       string
 	GradientStart string
 	GradientEnd   string
+
+	// GradientStops colors the modules with two or more stops such as
+	// "#F00, #0F0 30%, #00F"; GradientStart and GradientEnd are a two-stop shorthand.
+	// Colors may have alpha (#RRGGBBAA), and BgColor may be "transparent".
+	GradientStops string
+	GradientType  string   // linear (default) or radial
+	GradientAngle *float64 // linear direction in degrees as in CSS; nil means 180, top to bottom
+	// GradientCenterX and GradientCenterY place the radial center as a share of the
+	// width and height; nil means the middle.
+	GradientCenterX *float64
+	GradientCenterY *float64
+
+	// ErrorCorrection is the lowest level to use (default Q); a logo steps it up until
+	// the modules it hides can be recovered.
+	ErrorCorrection string
+	// QuietZone is the margin in modules; nil means qrDefaultQuiet.
+	QuietZone *int
+	// Size is the exact output width and height in pixels; 0 keeps 40px per module.
+	Size int
+	// DPI is written to PNGs and sets the page size of PDF and EPS (default 300).
+	DPI int
+
+	// Shapes of data modules and of the finder patterns ("eyes") in the corners, which
+	// take FgColor or the gradient unless their own colors are set.
+	ModuleShape      string // square (default), circle, rounded, diamond
+	FinderOuterShape string // square (default), rounded, circle
+	FinderInnerShape string // square (default), rounded, circle
+	FinderOuterColor string
+	FinderInnerColor string
+}
+
+// qrSymbol is an encoded QR code laid out on the design grid.
+type qrSymbol struct {
+	qrc     *qrcode.QRCode
+	modules [][]bool // [y][x], true for dark modules
+	level   string
+	quiet   int         // modules
+	logo    image.Image // resized and rounded, nil for none
+}
+
+// size is the width and height on the design grid.
+func (q *qrSymbol) size() int {
+	return (len(q.modules) + 2*q.quiet) * qrModuleWidth
+}
+
+// logoCoverage is the share of modules that the centered logo overlaps.
+func (q *qrSymbol) logoCoverage() float64 {
+	n := len(q.modules)
+	b := q.logo.Bounds()
+	return float64(coveredModules(n, b.Dx())*coveredModules(n, b.Dy())) / float64(n*n)
+}
+
+// coveredModules counts the modules of a row of n that a centered span of px overlaps.
+func coveredModules(n, px int) int {
+	total := n * qrModuleWidth
+	if px >= total {
+		return n
+	}
+	start := (total - px) / 2
+	end := start + px
+	return (end+qrModuleWidth-1)/qrModuleWidth - start/qrModuleWidth
+}
+
+// encode builds the QR code of code at the lowest level from opts.ErrorCorrection up
+// that can recover what the logo hides. opts must be normalized.
+func (s *QRService) encode(ctx context.Context, code string, opts *QROptions) (*qrSymbol, error) {
+	fullURL := ShortURL(s.BaseURL, DomainFrom(ctx), code)
+	logo := opts.logo()
+
+	for _, l := range qrLevels[qrLevelIndex(opts.ErrorCorrection):] {
+		qrc, err := qrcode.NewWith(fullURL, l.option)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create QR object: %w", err)
+		}
+		var m qrMatrix
+		if err := qrc.Save(&m); err != nil {
+			return nil, fmt.Errorf("failed to save QR: %w", err)
+		}
+		sym := &qrSymbol{qrc: qrc, modules: m.modules, level: l.name, quiet: *opts.QuietZone, logo: logo}
+		if logo == nil || sym.logoCoverage() <= l.maxLogo {
+			return sym, nil
+		}
+	}
+	return nil, ErrQRLogoTooLarge
 }
 
 // GenerateQR generates a QR code image based on the short code and options.
+// The encoded URL uses the domain of ctx (see WithDomain).
 func (s *QRService) GenerateQR(ctx context.Context, code string, opts QROptions) (image.Image, error) {
-	fullURL := s.BaseURL + "/" + code
-	
-	// Defaults
-	if opts.LogoSize <= 0 { opts.LogoSize = 100 }
-	if opts.LogoSize > 240 { opts.LogoSize = 240 }
-	if opts.BorderRadius < 0 { opts.BorderRadius = 0 }
-	if opts.FgColor == "" { opts.FgColor = "#000000" }
-	if opts.BgColor == "" { opts.BgColor = "#FFFFFF" }
-
-	qrc, err := qrcode.New(fullURL)
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+	sym, err := s.encode(ctx, code, &opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create QR object: %w", err)
+		return nil, err
 	}
 
-	useGradient := opts.GradientStart != "" && opts.GradientEnd != ""
+	d, err := newQRDrawing(sym, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	if !useGradient {
-		// Standard generation
-		options := []standard.ImageOption{
-			standard.WithBgColorRGBHex(opts.BgColor),
-			standard.WithFgColorRGBHex(opts.FgColor),
-			standard.WithQRWidth(40),
+	// Fit whole pixels per module into Size and spread the remainder over the margins
+	module, offset := qrModuleWidth, image.Point{}
+	if opts.Size > 0 {
+		units := len(sym.modules) + 2*sym.quiet
+		module = opts.Size / units
+		if module < 1 {
+			return nil, ErrQRSize
 		}
+		rem := opts.Size - units*module
+		offset = image.Pt(rem/2, rem/2)
+	}
+	return d.raster(module, offset), nil
+}
 
-		if opts.Logo != nil {
-			logoProcessed := utils.ResizeImage(opts.Logo, opts.LogoSize)
-			if opts.BorderRadius > 0 {
-				logoProcessed = utils.ApplyBorderRadius(logoProcessed, opts.BorderRadius)
-			}
-			options = append(options, standard.WithLogoImage(logoProcessed))
+// WriteQR renders the QR code of code to w in format. SVG, PDF and EPS are vector
+// drawings of the same modules, colors, gradient and logo as the PNG.
+func (s *QRService) WriteQR(ctx context.Context, w io.Writer, code string, format QRFormat, opts QROptions) error {
+	if format == QRFormatPNG {
+		img, err := s.GenerateQR(ctx, code, opts)
+		if err != nil {
+			return err
 		}
-
-		// Write to buffer to get image
 		buf := new(bytes.Buffer)
-		wr := standard.NewWithWriter(nopCloser{buf}, options...)
-		if err = qrc.Save(wr); err != nil {
-			return nil, fmt.Errorf("failed to save QR: %w", err)
+		enc := png.Encoder{CompressionLevel: png.BestSpeed}
+		if err := enc.Encode(buf, img); err != nil {
+			return err
 		}
-		
-		img, _, err := image.Decode(buf)
-		return img, err
+		data := buf.Bytes()
+		if opts.DPI > 0 {
+			data = pngWithDPI(data, opts.DPI)
+		}
+		_, err = w.Write(data)
+		return err
 	}
 
-	// Gradient Generation
-	// 1. Generate Base QR (Black on White)
-	buf := new(bytes.Buffer)
-	baseOptions := []standard.ImageOption{
-		standard.WithBgColorRGBHex("#FFFFFF"),
-		standard.WithFgColorRGBHex("#000000"),
-		standard.WithQRWidth(40),
+	if err := opts.normalize(); err != nil {
+		return err
 	}
-	wr := standard.NewWithWriter(nopCloser{buf}, baseOptions...)
-	if err := qrc.Save(wr); err != nil {
-		return nil, fmt.Errorf("failed to generate base QR: %w", err)
+	sym, err := s.encode(ctx, code, &opts)
+	if err != nil {
+		return err
 	}
-
-	baseImg, _, err := image.Decode(buf)
+	d, err := newQRDrawing(sym, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode base QR: %w", err)
+		return err
 	}
 
-	// 2. Create Gradient
-	bounds := baseImg.Bounds()
-	gradImg, err := utils.GenerateLinearGradient(bounds.Dx(), bounds.Dy(), opts.GradientStart, opts.GradientEnd)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate gradient: %w", err)
+	switch format {
+	case QRFormatSVG:
+		return d.writeSVG(w)
+	case QRFormatPDF:
+		return d.writePDF(w)
+	case QRFormatEPS:
+		return d.writeEPS(w)
 	}
+	return ErrQRFormat
+}
 
-	// 3. Apply Gradient Mask
-	finalImg, err := utils.ApplyGradientMask(baseImg, gradImg, opts.BgColor)
-	if err != nil {
-		return nil, fmt.Errorf("failed to apply gradient: %w", err)
+// normalize applies defaults and limits, checks the layout and gradient options, and
+// rewrites colors as #RRGGBB (#RRGGBBAA with alpha) and the level in upper case.
+func (o *QROptions) normalize() error {
+	if o.LogoSize <= 0 {
+		o.LogoSize = qrDefaultLogoSize
+	}
+	if o.LogoSize > qrMaxLogoSize {
+		o.LogoSize = qrMaxLogoSize
+	}
+	if o.BorderRadius < 0 {
+		o.BorderRadius = 0
+	}
+	if o.FgColor == "" {
+		o.FgColor = "#000000"
+	}
+	if o.BgColor == "" {
+		o.BgColor = "#FFFFFF"
+	}
+	if strings.EqualFold(o.BgColor, "transparent") {
+		o.BgColor = "#FFFFFF00"
+	}
+	if (o.GradientStart == "") != (o.GradientEnd == "") {
+		o.GradientStart, o.GradientEnd = "", ""
+	}
+	for _, hex := range []*string{&o.FgColor, &o.BgColor, &o.GradientStart, &o.GradientEnd, &o.FinderOuterColor, &o.FinderInnerColor} {
+		if *hex == "" {
+			continue
+		}
+		c, err := utils.ParseHexColor(*hex)
+		if err != nil {
+			return ErrQRColor
+		}
+		*hex = fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+		if c.A != 0xff {
+			*hex += fmt.Sprintf("%02X", c.A)
+		}
 	}
 
-	// 4. Draw Logo if present
-	if opts.Logo != nil {
-		logoProcessed := utils.ResizeImage(opts.Logo, opts.LogoSize)
-		if opts.BorderRadius > 0 {
-			logoProcessed = utils.ApplyBorderRadius(logoProcessed, opts.BorderRadius)
+	if o.GradientStops == "" && o.GradientStart != "" {
+		o.GradientStops = o.GradientStart + ", " + o.GradientEnd
+	}
+	o.GradientType = strings.ToLower(o.GradientType)
+	if o.GradientType != "" && o.GradientType != QRGradientLinear && o.GradientType != QRGradientRadial {
+		return ErrQRGradientType
+	}
+	if a := o.GradientAngle; a != nil && (math.IsNaN(*a) || math.IsInf(*a, 0)) {
+		return ErrQRGradientAngle
+	}
+	for _, c := range []*float64{o.GradientCenterX, o.GradientCenterY} {
+		if c != nil && !(*c >= 0 && *c <= 1) {
+			return ErrQRGradientCenter
 		}
+	}
+	if _, err := o.gradient(); err != nil {
+		return err
+	}
 
-		logoBounds := logoProcessed.Bounds()
-		x := (bounds.Dx() - logoBounds.Dx()) / 2
-		y := (bounds.Dy() - logoBounds.Dy()) / 2
+	o.ErrorCorrection = strings.ToUpper(o.ErrorCorrection)
+	if o.ErrorCorrection == "" {
+		o.ErrorCorrection = "Q"
+	}
+	if qrLevelIndex(o.ErrorCorrection) < 0 {
+		return ErrQRErrorCorrection
+	}
+	if o.QuietZone == nil {
+		quiet := qrDefaultQuiet
+		o.QuietZone = &quiet
+	} else if *o.QuietZone < 0 || *o.QuietZone > qrMaxQuiet {
+		return ErrQRQuietZone
+	}
+	if o.Size != 0 && (o.Size < qrMinSize || o.Size > qrMaxSize) {
+		return ErrQRSize
+	}
+	if o.DPI != 0 && (o.DPI < qrMinDPI || o.DPI > qrMaxDPI) {
+		return ErrQRDPI
+	}
 
-		dst, ok := finalImg.(draw.Image)
-		if ok {
-			draw.Draw(dst, image.Rect(x, y, x+logoBounds.Dx(), y+logoBounds.Dy()), logoProcessed, logoBounds.Min, draw.Over)
-		}
+	o.ModuleShape = strings.ToLower(o.ModuleShape)
+	o.FinderOuterShape = strings.ToLower(o.FinderOuterShape)
+	o.FinderInnerShape = strings.ToLower(o.FinderInnerShape)
+	if !validShape(o.ModuleShape, QRShapeSquare, QRShapeCircle, QRShapeRounded, QRShapeDiamond) {
+		return ErrQRModuleShape
+	}
+	if !validShape(o.FinderOuterShape, QRShapeSquare, QRShapeRounded, QRShapeCircle) ||
+		!validShape(o.FinderInnerShape, QRShapeSquare, QRShapeRounded, QRShapeCircle) {
+		return ErrQRFinderShape
 	}
+	return nil
+}
 
-	return finalImg, nil
+// gradient returns the module gradient, nil for solid modules.
+func (o *QROptions) gradient() (*utils.Gradient, error) {
+	if o.GradientStops == "" {
+		return nil, nil
+	}
+	stops, err := utils.ParseGradientStops(o.GradientStops)
+	if err != nil {
+		return nil, ErrQRGradient
+	}
+	g := &utils.Gradient{Stops: stops, Radial: o.GradientType == QRGradientRadial, Angle: 180, CenterX: 0.5, CenterY: 0.5}
+	if o.GradientAngle != nil {
+		g.Angle = *o.GradientAngle
+	}
+	if o.GradientCenterX != nil {
+		g.CenterX = *o.GradientCenterX
+	}
+	if o.GradientCenterY != nil {
+		g.CenterY = *o.GradientCenterY
+	}
+	return g, nil
 }
 
-type nopCloser struct {
-	io.Writer
+// logo returns the resized and rounded logo, or nil.
+func (o *QROptions) logo() image.Image {
+	if o.Logo == nil {
+		return nil
+	}
+	logo := utils.ResizeImage(o.Logo, o.LogoSize)
+	if o.BorderRadius > 0 {
+		logo = utils.ApplyBorderRadius(logo, o.BorderRadius)
+	}
+	return logo
 }
 
-func (nopCloser) Close() error { return nil }
+// pngWithDPI adds a pHYs chunk with dpi after the IHDR chunk of an encoded PNG.
+func pngWithDPI(data []byte, dpi int) []byte {
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4 // signature, then length, type, data and CRC of IHDR
+	if len(data) < ihdrEnd {
+		return data
+	}
+	ppm := uint32(math.Round(float64(dpi) / 0.0254))
+	chunk := make([]byte, 0, 4+4+9+4)
+	chunk = binary.BigEndian.AppendUint32(chunk, 9)
+	chunk = append(chunk, "pHYs"...)
+	chunk = binary.BigEndian.AppendUint32(chunk, ppm)
+	chunk = binary.BigEndian.AppendUint32(chunk, ppm)
+	chunk = append(chunk, 1) // unit: meter
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	out := make([]byte, 0, len(data)+len(chunk))
+	out = append(out, data[:ihdrEnd]...)
+	out = append(out, chunk...)
+	return append(out, data[ihdrEnd:]...)
+}
@@ -0,0 +1,417 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func testLogo(w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	return img
+}
+
+func TestWriteQRFormats(t *testing.T) {
+	s := NewQRService("https://sho.rt")
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		format QRFormat
+		opts   QROptions
+		check  func(t *testing.T, out []byte)
+	}{
+		{
+			name:   "SVG",
+			format: QRFormatSVG,
+			opts:   QROptions{FgColor: "#112233", Logo: testLogo(120, 120)},
+			check: func(t *testing.T, out []byte) {
+				if err := xml.Unmarshal(out, new(struct{})); err != nil {
+					t.Fatalf("invalid XML: %v", err)
+				}
+				for _, want := range []string{`fill="#112233"`, `<image `, `data:image/png;base64,`} {
+					if !bytes.Contains(out, []byte(want)) {
+						t.Errorf("missing %s", want)
+					}
+				}
+			},
+		},
+		{
+			name:   "SVG Gradient",
+			format: QRFormatSVG,
+			opts:   QROptions{GradientStart: "#f00", GradientEnd: "#0000ff"},
+			check: func(t *testing.T, out []byte) {
+				for _, want := range []string{`stop-color="#ff0000"`, `stop-color="#0000ff"`, `fill="url(#g0)"`} {
+					if !bytes.Contains(out, []byte(want)) {
+						t.Errorf("missing %s", want)
+					}
+				}
+			},
+		},
+		{
+			name:   "PDF",
+			format: QRFormatPDF,
+			opts:   QROptions{GradientStart: "#ff0000", GradientEnd: "#0000ff", Logo: testLogo(100, 100)},
+			check: func(t *testing.T, out []byte) {
+				checkPDFXref(t, out)
+				for _, want := range []string{"/ShadingType 2", "/SMask", "/MediaBox [0 0 "} {
+					if !bytes.Contains(out, []byte(want)) {
+						t.Errorf("missing %s", want)
+					}
+				}
+			},
+		},
+		{
+			name:   "EPS",
+			format: QRFormatEPS,
+			opts:   QROptions{Logo: testLogo(80, 80)},
+			check: func(t *testing.T, out []byte) {
+				if !bytes.HasPrefix(out, []byte("%!PS-Adobe-3.0 EPSF-3.0\n%%BoundingBox: 0 0 ")) {
+					t.Errorf("missing EPS header: %.60q", out)
+				}
+				if !bytes.Contains(out, []byte("/ASCIIHexDecode filter >> image")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+					t.Error("missing logo image or trailer")
+				}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := s.WriteQR(ctx, &buf, "abc123", tc.format, tc.opts); err != nil {
+				t.Fatalf("WriteQR: %v", err)
+			}
+			tc.check(t, buf.Bytes())
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := s.WriteQR(ctx, &buf, "abc123", QRFormatSVG, QROptions{FgColor: "blue"}); !errors.Is(err, ErrQRColor) {
+		t.Errorf("invalid color: err = %v, want ErrQRColor", err)
+	}
+}
+
+// checkPDFXref verifies that every cross-reference entry points at its object.
+func checkPDFXref(t *testing.T, out []byte) {
+	t.Helper()
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	start, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(out[start:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", start)
+	}
+	lines := strings.Split(string(out[start:]), "\n")
+	var count int
+	fmt.Sscanf(lines[1], "0 %d", &count)
+	for num := 1; num < count; num++ {
+		off, _ := strconv.Atoi(lines[2+num][:10])
+		if want := fmt.Sprintf("%d 0 obj\n", num); !bytes.HasPrefix(out[off:], []byte(want)) {
+			t.Errorf("xref entry %d points at %.20q", num, out[off:])
+		}
+	}
+}
+
+func TestQRShapes(t *testing.T) {
+	s := NewQRService("https://sho.rt")
+	ctx := context.Background()
+	quiet := 2
+	border := quiet * qrModuleWidth
+	center := func(x, y int) (int, int) {
+		return border + x*qrModuleWidth + qrModuleWidth/2, border + y*qrModuleWidth + qrModuleWidth/2
+	}
+
+	// Every module shape covers the module center, so the code decodes the same
+	for _, shape := range []string{"", QRShapeSquare, QRShapeCircle, QRShapeRounded, QRShapeDiamond} {
+		t.Run("Modules "+shape, func(t *testing.T) {
+			opts := QROptions{QuietZone: &quiet, ModuleShape: shape}
+			img, err := s.GenerateQR(ctx, "abc123", opts)
+			if err != nil {
+				t.Fatalf("GenerateQR: %v", err)
+			}
+			opts.normalize()
+			sym, err := s.encode(ctx, "abc123", &opts)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != sym.size() {
+				t.Fatalf("PNG is %v, grid size %d", b, sym.size())
+			}
+			for y := range sym.modules {
+				for x := range sym.modules[y] {
+					dark := color.GrayModel.Convert(img.At(center(x, y))).(color.Gray).Y < 0x80
+					if dark != sym.modules[y][x] {
+						t.Fatalf("module (%d, %d): PNG dark = %v, want %v", x, y, dark, sym.modules[y][x])
+					}
+				}
+			}
+		})
+	}
+
+	opts := QROptions{
+		QuietZone:        &quiet,
+		FinderOuterShape: "circle",
+		FinderInnerShape: "rounded",
+		FinderOuterColor: "#ff0000",
+		FinderInnerColor: "#00ff00",
+	}
+	img, err := s.GenerateQR(ctx, "abc123", opts)
+	if err != nil {
+		t.Fatalf("GenerateQR: %v", err)
+	}
+	checks := []struct {
+		name   string
+		module image.Point
+		want   color.RGBA
+	}{
+		{"Ring", image.Pt(3, 0), color.RGBA{0xff, 0, 0, 0xff}},
+		{"Center", image.Pt(3, 3), color.RGBA{0, 0xff, 0, 0xff}},
+		{"Circle Corner", image.Pt(0, 0), color.RGBA{0xff, 0xff, 0xff, 0xff}},
+	}
+	for _, c := range checks {
+		if got := color.RGBAModel.Convert(img.At(center(c.module.X, c.module.Y))); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := s.WriteQR(ctx, &buf, "abc123", QRFormatSVG, opts); err != nil {
+		t.Fatalf("WriteQR: %v", err)
+	}
+	for _, want := range []string{`fill="#ff0000"`, `fill="#00ff00"`, `C`} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("SVG missing %s", want)
+		}
+	}
+
+	for _, bad := range []QROptions{{ModuleShape: "star"}, {FinderOuterShape: "diamond"}, {FinderInnerShape: "x"}} {
+		want := ErrQRFinderShape
+		if bad.ModuleShape != "" {
+			want = ErrQRModuleShape
+		}
+		if _, err := s.GenerateQR(ctx, "abc123", bad); !errors.Is(err, want) {
+			t.Errorf("%+v: err = %v, want %v", bad, err, want)
+		}
+	}
+}
+
+func TestParseQRFormat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want QRFormat
+		err  error
+	}{
+		{"", QRFormatPNG, nil},
+		{"SVG", QRFormatSVG, nil},
+		{"pdf", QRFormatPDF, nil},
+		{"eps", QRFormatEPS, nil},
+		{"gif", "", ErrQRFormat},
+	}
+	for _, tc := range tests {
+		got, err := ParseQRFormat(tc.in)
+		if got != tc.want || !errors.Is(err, tc.err) {
+			t.Errorf("ParseQRFormat(%q) = %q, %v, want %q, %v", tc.in, got, err, tc.want, tc.err)
+		}
+	}
+	if ct := QRFormatSVG.ContentType(); ct != "image/svg+xml" {
+		t.Errorf("SVG content type = %q", ct)
+	}
+}
+
+func TestQRErrorCorrection(t *testing.T) {
+	s := NewQRService("https://sho.rt")
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		opts     QROptions
+		expected string
+		err      error
+	}{
+		{"Default", QROptions{}, "Q", nil},
+		{"Requested", QROptions{ErrorCorrection: "l"}, "L", nil},
+		{"Small Logo Keeps Level", QROptions{ErrorCorrection: "M", Logo: testLogo(40, 40), LogoSize: 40}, "M", nil},
+		{"Logo Steps Up", QROptions{ErrorCorrection: "L", Logo: testLogo(240, 240), LogoSize: 240}, "Q", nil},
+		{"Unknown Level", QROptions{ErrorCorrection: "X"}, "", ErrQRErrorCorrection},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := tc.opts
+			err := opts.normalize()
+			var sym *qrSymbol
+			if err == nil {
+				sym, err = s.encode(ctx, "abc123", &opts)
+			}
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("err = %v, want %v", err, tc.err)
+			}
+			if err == nil && sym.level != tc.expected {
+				t.Errorf("level = %s, want %s", sym.level, tc.expected)
+			}
+		})
+	}
+
+	// A tall logo hides whole columns, more than any level can recover
+	if _, err := s.GenerateQR(ctx, "abc123", QROptions{Logo: testLogo(160, 1200), LogoSize: 160}); !errors.Is(err, ErrQRLogoTooLarge) {
+		t.Errorf("oversized logo: err = %v, want ErrQRLogoTooLarge", err)
+	}
+}
+
+func TestQROutputSize(t *testing.T) {
+	s := NewQRService("https://sho.rt")
+	ctx := context.Background()
+
+	for _, size := range []int{64, 300, 1000, 4096} {
+		img, err := s.GenerateQR(ctx, "abc123", QROptions{Size: size, Logo: testLogo(100, 100)})
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+			t.Errorf("size %d: got %dx%d", size, b.Dx(), b.Dy())
+		}
+	}
+
+	zero := 0
+	img, err := s.GenerateQR(ctx, "abc123", QROptions{QuietZone: &zero})
+	if err != nil {
+		t.Fatalf("GenerateQR: %v", err)
+	}
+	if c := color.GrayModel.Convert(img.At(0, 0)).(color.Gray); c.Y > 0x80 {
+		t.Error("corner is light without a quiet zone, want the finder pattern")
+	}
+
+	for _, opts := range []QROptions{{Size: 10}, {Size: 5000}, {DPI: 10}, {QuietZone: new(int)}} {
+		if opts.QuietZone != nil {
+			*opts.QuietZone = 17
+		}
+		if _, err := s.GenerateQR(ctx, "abc123", opts); err == nil {
+			t.Errorf("%+v: expected a validation error", opts)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := s.WriteQR(ctx, &buf, "abc123", QRFormatPNG, QROptions{Size: 600, DPI: 600}); err != nil {
+		t.Fatalf("WriteQR: %v", err)
+	}
+	if _, err := png.Decode(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("PNG with pHYs does not decode: %v", err)
+	}
+	if i := bytes.Index(buf.Bytes(), []byte("pHYs")); i < 0 || !bytes.Equal(buf.Bytes()[i+4:i+13], []byte{0, 0, 0x5c, 0x46, 0, 0, 0x5c, 0x46, 1}) {
+		t.Error("missing pHYs chunk for 600 DPI (23622 px/m)")
+	}
+
+	buf.Reset()
+	if err := s.WriteQR(ctx, &buf, "abc123", QRFormatPDF, QROptions{Size: 600, DPI: 600}); err != nil {
+		t.Fatalf("WriteQR: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("/MediaBox [0 0 72 72]")) {
+		t.Error("600px at 600 DPI should be a one inch page")
+	}
+}
+
+func TestQRGradients(t *testing.T) {
+	s := NewQRService("https://sho.rt")
+	ctx := context.Background()
+	zero := 0
+	angle := 90.0
+	center := 0.0
+
+	// Without a quiet zone the finder patterns sit in the corners and show the gradient
+	img, err := s.GenerateQR(ctx, "abc123", QROptions{QuietZone: &zero, GradientStops: "#ff0000, #0000ff", GradientAngle: &angle})
+	if err != nil {
+		t.Fatalf("GenerateQR: %v", err)
+	}
+	b := img.Bounds()
+	left := color.NRGBAModel.Convert(img.At(5, 5)).(color.NRGBA)
+	right := color.NRGBAModel.Convert(img.At(b.Max.X-5, 5)).(color.NRGBA)
+	if left.R < 0xe0 || right.B < 0xe0 {
+		t.Errorf("90 degree gradient: left %v, right %v, want red to blue", left, right)
+	}
+
+	img, err = s.GenerateQR(ctx, "abc123", QROptions{
+		QuietZone:       &zero,
+		BgColor:         "transparent",
+		GradientStops:   "#00ff00, #000000 40%, #0000ff80",
+		GradientType:    "radial",
+		GradientCenterX: &center,
+		GradientCenterY: &center,
+	})
+	if err != nil {
+		t.Fatalf("GenerateQR: %v", err)
+	}
+	b = img.Bounds()
+	if c := color.NRGBAModel.Convert(img.At(5, 5)).(color.NRGBA); c.G < 0xe0 {
+		t.Errorf("radial center = %v, want green", c)
+	}
+	// The separator next to a finder pattern is always light
+	if c := color.NRGBAModel.Convert(img.At(7*qrModuleWidth+qrModuleWidth/2, 5)).(color.NRGBA); c.A != 0 {
+		t.Errorf("separator = %v, want the transparent background", c)
+	}
+	if c := color.NRGBAModel.Convert(img.At(5, b.Max.Y-5)).(color.NRGBA); c.A > 0xe0 || c.A < 0x80 {
+		t.Errorf("far finder = %v, want the half transparent last stop", c)
+	}
+
+	opts := QROptions{BgColor: "transparent", GradientStops: "#f00, #0f0 30%, #00f8", GradientType: "Radial", FinderInnerColor: "#00000080"}
+	tests := []struct {
+		format QRFormat
+		want   []string
+		absent []string
+	}{
+		{QRFormatSVG, []string{`<radialGradient id="g0"`, `stop-opacity="0.5333"`, `offset="0.3"`, `fill="#000000" fill-opacity="0.502"`}, []string{"<rect"}},
+		{QRFormatPDF, []string{"/ShadingType 3", "/FunctionType 3", "/Bounds [0.3]", "/S /Luminosity", "/ca 0.502"}, []string{" re f\n"}},
+		{QRFormatEPS, []string{"/ShadingType 3", "shfill"}, []string{"rectfill"}},
+	}
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		if err := s.WriteQR(ctx, &buf, "abc123", tc.format, opts); err != nil {
+			t.Fatalf("%s: %v", tc.format, err)
+		}
+		out := buf.Bytes()
+		if tc.format == QRFormatPDF {
+			checkPDFXref(t, out)
+		}
+		for _, want := range tc.want {
+			if !bytes.Contains(out, []byte(want)) {
+				t.Errorf("%s: missing %s", tc.format, want)
+			}
+		}
+		for _, absent := range tc.absent {
+			if bytes.Contains(out, []byte(absent)) {
+				t.Errorf("%s: transparent background painted (%q)", tc.format, absent)
+			}
+		}
+	}
+
+	nan := math.NaN()
+	outside := 1.5
+	for _, tc := range []struct {
+		opts QROptions
+		err  error
+	}{
+		{QROptions{GradientStops: "#f00"}, ErrQRGradient},
+		{QROptions{GradientStops: "#f00, #00f 150%"}, ErrQRGradient},
+		{QROptions{GradientStops: "#f00, #00f", GradientType: "conic"}, ErrQRGradientType},
+		{QROptions{GradientStops: "#f00, #00f", GradientAngle: &nan}, ErrQRGradientAngle},
+		{QROptions{GradientStops: "#f00, #00f", GradientCenterX: &outside}, ErrQRGradientCenter},
+		{QROptions{BgColor: "#ffffff0"}, ErrQRColor},
+	} {
+		if _, err := s.GenerateQR(ctx, "abc123", tc.opts); !errors.Is(err, tc.err) {
+			t.Errorf("%+v: err = %v, want %v", tc.opts, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-shortener-sqlc/internal/cache"
+	"go-shortener-sqlc/internal/db"
+)
+
+const (
+	maxQRTemplateStops = 255 // gradient_stops column width
+
+	// Decoded template logos are kept per template, so public QR requests don't decode
+	// the upload each time. Entries are dropped when the template changes.
+	qrLogoCacheSize = 8
+	qrLogoCacheTTL  = 10 * time.Minute
+)
+
+// MaxQRLogoDimension is the largest logo width or height a QR code accepts.
+const MaxQRLogoDimension = 2048
+
+var (
+	ErrQRTemplateName     = errors.New("name must be 1-64 letters, digits, '-' or '_'")
+	ErrQRTemplateLogo     = errors.New("logo_image_id does not match an uploaded image")
+	ErrQRTemplateLogoSize = fmt.Errorf("logo image dimensions too large (max %dx%d)", MaxQRLogoDimension, MaxQRLogoDimension)
+	ErrQRTemplateNotFound = errors.New("QR template not found")
+	ErrQRTemplateConflict = errors.New("a QR template with this name already exists")
+)
+
+var qrTemplateName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// QRTemplate is a named QR code style. Its fields match the /{code}/qr form fields;
+// empty ones keep the defaults. The logo is the original upload of an images record.
+type QRTemplate struct {
+	ID               int32     `json:"id"`
+	Name             string    `json:"name"`
+	FgColor          string    `json:"fg_color"`
+	BgColor          string    `json:"bg_color"`
+	GradientStops    string    `json:"gradient_stops"`
+	GradientType     string    `json:"gradient_type"`
+	GradientAngle    *float64  `json:"gradient_angle"`
+	GradientCenterX  *float64  `json:"gradient_center_x"`
+	GradientCenterY  *float64  `json:"gradient_center_y"`
+	ModuleShape      string    `json:"module_shape"`
+	FinderOuterShape string    `json:"finder_outer_shape"`
+	FinderInnerShape string    `json:"finder_inner_shape"`
+	FinderOuterColor string    `json:"finder_outer_color"`
+	FinderInnerColor string    `json:"finder_inner_color"`
+	LogoImageID      string    `json:"logo_image_id"`
+	LogoSize         int       `json:"logo_size"`
+	BorderRadius     int       `json:"border_radius"`
+	ErrorCorrection  string    `json:"error_correction"`
+	QuietZone        *int      `json:"quiet_zone"`
+	Size             int       `json:"size"`
+	DPI              int       `json:"dpi"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// options returns the template as QR options without the logo.
+func (t *QRTemplate) options() QROptions {
+	return QROptions{
+		LogoSize:         t.LogoSize,
+		BorderRadius:     t.BorderRadius,
+		FgColor:          t.FgColor,
+		BgColor:          t.BgColor,
+		GradientStops:    t.GradientStops,
+		GradientType:     t.GradientType,
+		GradientAngle:    t.GradientAngle,
+		GradientCenterX:  t.GradientCenterX,
+		GradientCenterY:  t.GradientCenterY,
+		ErrorCorrection:  t.ErrorCorrection,
+		QuietZone:        t.QuietZone,
+		Size:             t.Size,
+		DPI:              t.DPI,
+		ModuleShape:      t.ModuleShape,
+		FinderOuterShape: t.FinderOuterShape,
+		FinderInnerShape: t.FinderInnerShape,
+		FinderOuterColor: t.FinderOuterColor,
+		FinderInnerColor: t.FinderInnerColor,
+	}
+}
+
+// normalize validates the template like a QR request and rewrites the fields that are
+// set into their canonical form. Empty fields stay empty so they follow the defaults.
+func (t *QRTemplate) normalize() error {
+	t.Name = strings.TrimSpace(t.Name)
+	if !qrTemplateName.MatchString(t.Name) {
+		return ErrQRTemplateName
+	}
+	t.LogoImageID = strings.TrimSpace(t.LogoImageID)
+	t.GradientStops = strings.TrimSpace(t.GradientStops)
+	if len(t.GradientStops) > maxQRTemplateStops {
+		return ErrQRGradient
+	}
+
+	opts := t.options()
+	if err := opts.normalize(); err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		field     *string
+		canonical string
+	}{
+		{&t.FgColor, opts.FgColor},
+		{&t.BgColor, opts.BgColor},
+		{&t.GradientType, opts.GradientType},
+		{&t.ModuleShape, opts.ModuleShape},
+		{&t.FinderOuterShape, opts.FinderOuterShape},
+		{&t.FinderInnerShape, opts.FinderInnerShape},
+		{&t.FinderOuterColor, opts.FinderOuterColor},
+		{&t.FinderInnerColor, opts.FinderInnerColor},
+		{&t.ErrorCorrection, opts.ErrorCorrection},
+	} {
+		if *f.field != "" {
+			*f.field = f.canonical
+		}
+	}
+	if t.LogoSize > 0 {
+		t.LogoSize = opts.LogoSize
+	} else {
+		t.LogoSize = 0
+	}
+	t.BorderRadius = opts.BorderRadius
+	return nil
+}
+
+type QRTemplateService struct {
+	q         *db.Queries
+	uploadDir string
+	logos     *cache.LRU[qrLogo] // keyed by template ID
+}
+
+// qrLogo is a decoded template logo and the image it was read from.
+type qrLogo struct {
+	imageID string
+	img     image.Image
+}
+
+// NewQRTemplateService creates the service; logos are read from the original image
+// uploads under uploadDir.
+func NewQRTemplateService(q *db.Queries, uploadDir string) *QRTemplateService {
+	return &QRTemplateService{q: q, uploadDir: uploadDir, logos: cache.NewLRU[qrLogo](qrLogoCacheSize)}
+}
+
+// List returns all templates by name.
+func (s *QRTemplateService) List(ctx context.Context) ([]QRTemplate, error) {
+	rows, err := s.q.ListQRTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]QRTemplate, len(rows))
+	for i, row := range rows {
+		templates[i] = newQRTemplate(row)
+	}
+	return templates, nil
+}
+
+// Get returns the template with id, or sql.ErrNoRows.
+func (s *QRTemplateService) Get(ctx context.Context, id int32) (*QRTemplate, error) {
+	row, err := s.q.GetQRTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	t := newQRTemplate(row)
+	return &t, nil
+}
+
+// Create stores a new template.
+func (s *QRTemplateService) Create(ctx context.Context, t QRTemplate) (*QRTemplate, error) {
+	if err := s.validate(ctx, &t); err != nil {
+		return nil, err
+	}
+	result, err := s.q.CreateQRTemplate(ctx, qrTemplateParams(t))
+	if err != nil {
+		if isDuplicateKey(err) {
+			return nil, ErrQRTemplateConflict
+		}
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, int32(id))
+}
+
+// Update replaces every field of the template with id.
+func (s *QRTemplateService) Update(ctx context.Context, id int32, t QRTemplate) (*QRTemplate, error) {
+	if err := s.validate(ctx, &t); err != nil {
+		return nil, err
+	}
+	if _, err := s.q.GetQRTemplate(ctx, id); err != nil {
+		return nil, err
+	}
+	p := qrTemplateParams(t)
+	err := s.q.UpdateQRTemplate(ctx, db.UpdateQRTemplateParams{
+		Name:             p.Name,
+		FgColor:          p.FgColor,
+		BgColor:          p.BgColor,
+		GradientStops:    p.GradientStops,
+		GradientType:     p.GradientType,
+		GradientAngle:    p.GradientAngle,
+		GradientCenterX:  p.GradientCenterX,
+		GradientCenterY:  p.GradientCenterY,
+		ModuleShape:      p.ModuleShape,
+		FinderOuterShape: p.FinderOuterShape,
+		FinderInnerShape: p.FinderInnerShape,
+		FinderOuterColor: p.FinderOuterColor,
+		FinderInnerColor: p.FinderInnerColor,
+		LogoImageID:      p.LogoImageID,
+		LogoSize:         p.LogoSize,
+		BorderRadius:     p.BorderRadius,
+		ErrorCorrection:  p.ErrorCorrection,
+		QuietZone:        p.QuietZone,
+		Size:             p.Size,
+		Dpi:              p.Dpi,
+		ID:               id,
+	})
+	if err != nil {
+		if isDuplicateKey(err) {
+			return nil, ErrQRTemplateConflict
+		}
+		return nil, err
+	}
+	s.logos.Delete(qrLogoKey(id))
+	return s.Get(ctx, id)
+}
+
+// Delete removes the template with id, or returns sql.ErrNoRows.
+func (s *QRTemplateService) Delete(ctx context.Context, id int32) error {
+	n, err := s.q.DeleteQRTemplate(ctx, id)
+	if err != nil {
+		return err
+	}
+	s.logos.Delete(qrLogoKey(id))
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Options returns the QR options of the template called name, with its logo loaded.
+func (s *QRTemplateService) Options(ctx context.Context, name string) (QROptions, error) {
+	row, err := s.q.GetQRTemplateByName(ctx, name)
+	if err == sql.ErrNoRows {
+		return QROptions{}, ErrQRTemplateNotFound
+	}
+	if err != nil {
+		return QROptions{}, err
+	}
+	t := newQRTemplate(row)
+	opts := t.options()
+	if t.LogoImageID == "" {
+		return opts, nil
+	}
+
+	// Other instances don't see Update, so a cached logo is only used for the same image
+	key := qrLogoKey(t.ID)
+	if logo, ok := s.logos.Get(key); ok && logo.imageID == t.LogoImageID {
+		opts.Logo = logo.img
+		return opts, nil
+	}
+	img, err := s.q.GetImage(ctx, t.LogoImageID)
+	if err != nil {
+		return QROptions{}, fmt.Errorf("failed to load logo of QR template %s: %w", name, err)
+	}
+	f, err := s.openLogo(img.Filename)
+	if err != nil {
+		return QROptions{}, fmt.Errorf("failed to open logo of QR template %s: %w", name, err)
+	}
+	defer f.Close()
+	if opts.Logo, _, err = image.Decode(f); err != nil {
+		return QROptions{}, fmt.Errorf("failed to decode logo of QR template %s: %w", name, err)
+	}
+	s.logos.Set(key, qrLogo{imageID: t.LogoImageID, img: opts.Logo}, qrLogoCacheTTL)
+	return opts, nil
+}
+
+// openLogo opens the original upload called filename, positioned at the start, after
+// checking that its dimensions are within MaxQRLogoDimension.
+func (s *QRTemplateService) openLogo(filename string) (*os.File, error) {
+	f, err := os.Open(filepath.Join(s.uploadDir, "original", filename))
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(f)
+	if err == nil && (cfg.Width > MaxQRLogoDimension || cfg.Height > MaxQRLogoDimension) {
+		err = ErrQRTemplateLogoSize
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// validate normalizes t and checks that its logo exists and is small enough.
+func (s *QRTemplateService) validate(ctx context.Context, t *QRTemplate) error {
+	if err := t.normalize(); err != nil {
+		return err
+	}
+	if t.LogoImageID == "" {
+		return nil
+	}
+	img, err := s.q.GetImage(ctx, t.LogoImageID)
+	if err == sql.ErrNoRows {
+		return ErrQRTemplateLogo
+	} else if err != nil {
+		return err
+	}
+	f, err := s.openLogo(img.Filename)
+	if errors.Is(err, ErrQRTemplateLogoSize) {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("failed to open logo image %s: %w", t.LogoImageID, err)
+	}
+	return f.Close()
+}
+
+func qrLogoKey(id int32) string {
+	return strconv.Itoa(int(id))
+}
+
+func qrTemplateParams(t QRTemplate) db.CreateQRTemplateParams {
+	p := db.CreateQRTemplateParams{
+		Name:             t.Name,
+		FgColor:          t.FgColor,
+		BgColor:          t.BgColor,
+		GradientStops:    t.GradientStops,
+		GradientType:     t.GradientType,
+		ModuleShape:      t.ModuleShape,
+		FinderOuterShape: t.FinderOuterShape,
+		FinderInnerShape: t.FinderInnerShape,
+		FinderOuterColor: t.FinderOuterColor,
+		FinderInnerColor: t.FinderInnerColor,
+		LogoImageID:      sql.NullString{String: t.LogoImageID, Valid: t.LogoImageID != ""},
+		LogoSize:         int32(t.LogoSize),
+		BorderRadius:     int32(t.BorderRadius),
+		ErrorCorrection:  t.ErrorCorrection,
+		Size:             int32(t.Size),
+		Dpi:              int32(t.DPI),
+	}
+	for _, f := range []struct {
+		value *float64
+		dst   *sql.NullFloat64
+	}{
+		{t.GradientAngle, &p.GradientAngle},
+		{t.GradientCenterX, &p.GradientCenterX},
+		{t.GradientCenterY, &p.GradientCenterY},
+	} {
+		if f.value != nil {
+			*f.dst = sql.NullFloat64{Float64: *f.value, Valid: true}
+		}
+	}
+	if t.QuietZone != nil {
+		p.QuietZone = sql.NullInt32{Int32: int32(*t.QuietZone), Valid: true}
+	}
+	return p
+}
+
+func newQRTemplate(row db.QrTemplate) QRTemplate {
+	t := QRTemplate{
+		ID:               row.ID,
+		Name:             row.Name,
+		FgColor:          row.FgColor,
+		BgColor:          row.BgColor,
+		GradientStops:    row.GradientStops,
+		GradientType:     row.GradientType,
+		ModuleShape:      row.ModuleShape,
+		FinderOuterShape: row.FinderOuterShape,
+		FinderInnerShape: row.FinderInnerShape,
+		FinderOuterColor: row.FinderOuterColor,
+		FinderInnerColor: row.FinderInnerColor,
+		LogoImageID:      row.LogoImageID.String,
+		LogoSize:         int(row.LogoSize),
+		BorderRadius:     int(row.BorderRadius),
+		ErrorCorrection:  row.ErrorCorrection,
+		Size:             int(row.Size),
+		DPI:              int(row.Dpi),
+		CreatedAt:        row.CreatedAt,
+		UpdatedAt:        row.UpdatedAt,
+	}
+	for _, f := range []struct {
+		value sql.NullFloat64
+		dst   **float64
+	}{
+		{row.GradientAngle, &t.GradientAngle},
+		{row.GradientCenterX, &t.GradientCenterX},
+		{row.GradientCenterY, &t.GradientCenterY},
+	} {
+		if f.value.Valid {
+			v := f.value.Float64
+			*f.dst = &v
+		}
+	}
+	if row.QuietZone.Valid {
+		quiet := int(row.QuietZone.Int32)
+		t.QuietZone = &quiet
+	}
+	return t
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"go-shortener-sqlc/internal/db"
+)
+
+func TestQRTemplateNormalize(t *testing.T) {
+	quiet := 20
+	tests := []struct {
+		name     string
+		template QRTemplate
+		expected QRTemplate
+		err      error
+	}{
+		{
+			name:     "Canonical",
+			template: QRTemplate{Name: " brand ", FgColor: "#abc", ModuleShape: "Circle", ErrorCorrection: "h", LogoSize: 500},
+			expected: QRTemplate{Name: "brand", FgColor: "#AABBCC", ModuleShape: "circle", ErrorCorrection: "H", LogoSize: qrMaxLogoSize},
+		},
+		{
+			name:     "Empty Fields Keep Defaults",
+			template: QRTemplate{Name: "plain", LogoSize: -1, BorderRadius: -4},
+			expected: QRTemplate{Name: "plain"},
+		},
+		{name: "Bad Name", template: QRTemplate{Name: "brand kit"}, err: ErrQRTemplateName},
+		{name: "Bad Color", template: QRTemplate{Name: "brand", BgColor: "white"}, err: ErrQRColor},
+		{name: "Bad Quiet Zone", template: QRTemplate{Name: "brand", QuietZone: &quiet}, err: ErrQRQuietZone},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.template
+			err := got.normalize()
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("normalize error = %v, want %v", err, tc.err)
+			}
+			if err == nil && got != tc.expected {
+				t.Errorf("normalize = %+v, want %+v", got, tc.expected)
+			}
+		})
+	}
+}
+
+var qrTemplateColumns = []string{
+	"id", "name", "fg_color", "bg_color", "gradient_stops", "gradient_type", "gradient_angle", "gradient_center_x", "gradient_center_y",
+	"module_shape", "finder_outer_shape", "finder_inner_shape", "finder_outer_color", "finder_inner_color",
+	"logo_image_id", "logo_size", "border_radius", "error_correction", "quiet_zone", "size", "dpi", "created_at", "updated_at",
+}
+
+func TestQRTemplateOptions(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "original"), 0755)
+	f, err := os.Create(filepath.Join(dir, "original", "logo.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(f, testLogo(60, 40))
+	f.Close()
+	f, err = os.Create(filepath.Join(dir, "original", "huge.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(f, testLogo(MaxQRLogoDimension+1, 1))
+	f.Close()
+
+	s := NewQRTemplateService(db.New(mockDB), dir)
+	ctx := context.Background()
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT (.+) FROM qr_templates").
+		WithArgs("brand").
+		WillReturnRows(sqlmock.NewRows(qrTemplateColumns).AddRow(
+			1, "brand", "#112233", "", "#FF0000, #0000FF", "radial", nil, 0.25, nil,
+			"rounded", "circle", "", "#E11D48", "", "img-1", 80, 0, "H", 2, 512, 0, now, now))
+	mock.ExpectQuery("SELECT (.+) FROM images").
+		WithArgs("img-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "filename", "original_name", "alt_text", "title", "mime_type", "size_bytes", "width", "height", "created_at", "updated_at"}).
+			AddRow("img-1", "logo.png", "logo.png", "", "", "image/png", 100, 60, 40, now, now))
+
+	opts, err := s.Options(ctx, "brand")
+	if err != nil {
+		t.Fatalf("Options: %v", err)
+	}
+	if opts.FgColor != "#112233" || opts.GradientType != "radial" || opts.ModuleShape != "rounded" || opts.FinderOuterColor != "#E11D48" {
+		t.Errorf("Options = %+v", opts)
+	}
+	if opts.GradientCenterX == nil || *opts.GradientCenterX != 0.25 || opts.GradientAngle != nil {
+		t.Errorf("gradient center %v, angle %v, want 0.25 and unset", opts.GradientCenterX, opts.GradientAngle)
+	}
+	if opts.QuietZone == nil || *opts.QuietZone != 2 || opts.Size != 512 || opts.LogoSize != 80 {
+		t.Errorf("layout = quiet %v, size %d, logo %d", opts.QuietZone, opts.Size, opts.LogoSize)
+	}
+	if opts.Logo == nil || opts.Logo.Bounds().Dx() != 60 {
+		t.Fatal("template logo was not loaded")
+	}
+	if _, err := NewQRService("https://sho.rt").GenerateQR(ctx, "abc123", opts); err != nil {
+		t.Errorf("GenerateQR with template options: %v", err)
+	}
+
+	// The decoded logo is reused until the template changes
+	mock.ExpectQuery("SELECT (.+) FROM qr_templates").
+		WithArgs("brand").
+		WillReturnRows(sqlmock.NewRows(qrTemplateColumns).AddRow(
+			1, "brand", "", "", "", "", nil, nil, nil, "", "", "", "", "", "img-1", 80, 0, "H", nil, 0, 0, now, now))
+	if opts, err := s.Options(ctx, "brand"); err != nil || opts.Logo == nil {
+		t.Errorf("cached logo: Options = %v, %v", opts.Logo, err)
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM qr_templates").
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+	if _, err := s.Options(ctx, "missing"); !errors.Is(err, ErrQRTemplateNotFound) {
+		t.Errorf("unknown template: err = %v, want ErrQRTemplateNotFound", err)
+	}
+
+	// Creating a template checks that its logo exists
+	mock.ExpectQuery("SELECT (.+) FROM images").
+		WithArgs("gone").
+		WillReturnError(sql.ErrNoRows)
+	if _, err := s.Create(ctx, QRTemplate{Name: "brand", LogoImageID: "gone"}); !errors.Is(err, ErrQRTemplateLogo) {
+		t.Errorf("missing logo: err = %v, want ErrQRTemplateLogo", err)
+	}
+
+	// Logos are held to the same dimension limit as uploaded ones
+	mock.ExpectQuery("SELECT (.+) FROM images").
+		WithArgs("img-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "filename", "original_name", "alt_text", "title", "mime_type", "size_bytes", "width", "height", "created_at", "updated_at"}).
+			AddRow("img-2", "huge.png", "huge.png", "", "", "image/png", 100, MaxQRLogoDimension+1, 1, now, now))
+	if _, err := s.Create(ctx, QRTemplate{Name: "huge", LogoImageID: "img-2"}); !errors.Is(err, ErrQRTemplateLogoSize) {
+		t.Errorf("huge logo: err = %v, want ErrQRTemplateLogoSize", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
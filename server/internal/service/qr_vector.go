@@ -0,0 +1,417 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/yeqown/go-qrcode/v2"
+
+	"go-shortener-sqlc/internal/utils"
+)
+
+// qrMatrix is a qrcode.Writer that keeps the module matrix instead of drawing it.
+type qrMatrix struct {
+	modules [][]bool // [y][x], true for dark modules
+}
+
+func (m *qrMatrix) Write(mat qrcode.Matrix) error {
+	m.modules = mat.Bitmap()
+	return nil
+}
+
+func (m *qrMatrix) Close() error { return nil }
+
+// writeSVG writes the drawing as one path per layer on a viewBox of the design grid.
+func (d *qrDrawing) writeSVG(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d">
+`, d.pixels, d.pixels, d.size, d.size)
+	if d.bg.A > 0 {
+		fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="%s"%s/>`+"\n", d.size, d.size, hexColor(d.bg), svgOpacity("fill-opacity", d.bg.A))
+	}
+
+	for i, layer := range d.layers {
+		fill := hexColor(layer.paint.color) + `"` + svgOpacity("fill-opacity", layer.paint.color.A)
+		if g := layer.paint.gradient; g != nil {
+			size := float64(d.size)
+			if g.Radial {
+				cx, cy, r := g.Circle(size, size)
+				fmt.Fprintf(bw, `<defs><radialGradient id="g%d" gradientUnits="userSpaceOnUse" cx="%s" cy="%s" r="%s">`,
+					i, pdfNum(cx), pdfNum(cy), pdfNum(r))
+			} else {
+				x0, y0, x1, y1 := g.Line(size, size)
+				fmt.Fprintf(bw, `<defs><linearGradient id="g%d" gradientUnits="userSpaceOnUse" x1="%s" y1="%s" x2="%s" y2="%s">`,
+					i, pdfNum(x0), pdfNum(y0), pdfNum(x1), pdfNum(y1))
+			}
+			for _, stop := range g.Stops {
+				fmt.Fprintf(bw, `<stop offset="%s" stop-color="%s"%s/>`, pdfNum(stop.Offset), hexColor(stop.Color), svgOpacity("stop-opacity", stop.Color.A))
+			}
+			if g.Radial {
+				bw.WriteString("</radialGradient></defs>\n")
+			} else {
+				bw.WriteString("</linearGradient></defs>\n")
+			}
+			fill = fmt.Sprintf(`url(#g%d)"`, i)
+		}
+		fmt.Fprintf(bw, `<path fill="%s d="`, fill)
+		for _, seg := range layer.path {
+			bw.WriteByte(seg.op)
+			for j, pt := range seg.pts {
+				if j > 0 {
+					bw.WriteByte(' ')
+				}
+				fmt.Fprintf(bw, "%s %s", pdfNum(pt.X), pdfNum(pt.Y))
+			}
+		}
+		bw.WriteString("\"/>\n")
+	}
+
+	if d.logo != nil {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, d.logo); err != nil {
+			return err
+		}
+		r := d.logoRect()
+		fmt.Fprintf(bw, `<image x="%d" y="%d" width="%d" height="%d" xlink:href="data:image/png;base64,%s"/>`+"\n",
+			r.Min.X, r.Min.Y, r.Dx(), r.Dy(), base64.StdEncoding.EncodeToString(buf.Bytes()))
+	}
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+// svgOpacity returns the attribute for alpha a, or nothing when opaque.
+func svgOpacity(attr string, a uint8) string {
+	if a == 0xff {
+		return ""
+	}
+	return fmt.Sprintf(` %s="%s"`, attr, pdfNum(float64(a)/255))
+}
+
+// writePath writes p with the path operators of PDF or, with ps, PostScript.
+func writePath(w io.Writer, p qrPath, ps bool) {
+	ops := map[byte]string{'M': "m", 'L': "l", 'C': "c", 'Z': "h"}
+	if ps {
+		ops = map[byte]string{'M': "moveto", 'L': "lineto", 'C': "curveto", 'Z': "closepath"}
+	}
+	for _, seg := range p {
+		for _, pt := range seg.pts {
+			fmt.Fprintf(w, "%s %s ", pdfNum(pt.X), pdfNum(pt.Y))
+		}
+		fmt.Fprintf(w, "%s\n", ops[seg.op])
+	}
+}
+
+// shading is a PDF and PostScript shading of g over the grid. value gives the color
+// components of a stop in colorSpace.
+func (d *qrDrawing) shading(g *utils.Gradient, colorSpace string, value func(color.NRGBA) string) string {
+	size := float64(d.size)
+	var geometry string
+	if g.Radial {
+		cx, cy, r := g.Circle(size, size)
+		geometry = fmt.Sprintf("/ShadingType 3 /Coords [%s %s 0 %s %s %s]", pdfNum(cx), pdfNum(cy), pdfNum(cx), pdfNum(cy), pdfNum(r))
+	} else {
+		x0, y0, x1, y1 := g.Line(size, size)
+		geometry = fmt.Sprintf("/ShadingType 2 /Coords [%s %s %s %s]", pdfNum(x0), pdfNum(y0), pdfNum(x1), pdfNum(y1))
+	}
+
+	// One interpolation per pair of stops, stitched together by their offsets
+	segment := func(a, b utils.GradientStop) string {
+		return fmt.Sprintf("<< /FunctionType 2 /Domain [0 1] /C0 [%s] /C1 [%s] /N 1 >>", value(a.Color), value(b.Color))
+	}
+	function := segment(g.Stops[0], g.Stops[1])
+	if n := len(g.Stops); n > 2 {
+		var functions, bounds, encode []string
+		for i := 1; i < n; i++ {
+			functions = append(functions, segment(g.Stops[i-1], g.Stops[i]))
+			encode = append(encode, "0 1")
+			if i < n-1 {
+				bounds = append(bounds, pdfNum(g.Stops[i].Offset))
+			}
+		}
+		function = fmt.Sprintf("<< /FunctionType 3 /Domain [0 1] /Functions [%s] /Bounds [%s] /Encode [%s] >>",
+			strings.Join(functions, " "), strings.Join(bounds, " "), strings.Join(encode, " "))
+	}
+	return fmt.Sprintf("<< %s /ColorSpace /%s /Extend [true true] /Function %s >>", geometry, colorSpace, function)
+}
+
+// opaque reports whether every stop of g is opaque.
+func opaque(g *utils.Gradient) bool {
+	for _, stop := range g.Stops {
+		if stop.Color.A != 0xff {
+			return false
+		}
+	}
+	return true
+}
+
+// writePDF writes a single page PDF 1.4 document. Alpha is a constant opacity for
+// solid colors and a luminosity soft mask of the stop alphas for gradients.
+func (d *qrDrawing) writePDF(w io.Writer) error {
+	var doc pdfDoc
+	catalog, pages, page, contents := doc.alloc(), doc.alloc(), doc.alloc(), doc.alloc()
+
+	var shadings, xobjects, states []string
+	alphas := make(map[uint8]string)
+	// setAlpha selects a graphics state with constant opacity a
+	setAlpha := func(content *bytes.Buffer, a uint8) {
+		if a == 0xff {
+			return
+		}
+		name, ok := alphas[a]
+		if !ok {
+			name = fmt.Sprintf("/GS%d", len(states))
+			gs := doc.alloc()
+			doc.object(gs, fmt.Sprintf("<< /Type /ExtGState /ca %s >>", pdfNum(float64(a)/255)))
+			states = append(states, fmt.Sprintf("%s %d 0 R", name, gs))
+			alphas[a] = name
+		}
+		fmt.Fprintf(content, "%s gs\n", name)
+	}
+
+	var content bytes.Buffer
+	pt := d.points()
+	scale := pt / float64(d.size)
+	// Draw on the grid with the origin at the top left, like the PNG
+	fmt.Fprintf(&content, "q\n%s 0 0 %s 0 %s cm\n", pdfNum(scale), pdfNum(-scale), pdfNum(pt))
+	if d.bg.A > 0 {
+		content.WriteString("q\n")
+		setAlpha(&content, d.bg.A)
+		fmt.Fprintf(&content, "%s rg 0 0 %d %d re f\nQ\n", pdfColor(d.bg), d.size, d.size)
+	}
+
+	for i, layer := range d.layers {
+		content.WriteString("q\n")
+		if g := layer.paint.gradient; g != nil {
+			sh := doc.alloc()
+			doc.object(sh, d.shading(g, "DeviceRGB", pdfColor))
+			shadings = append(shadings, fmt.Sprintf("/Sh%d %d 0 R", i, sh))
+			if !opaque(g) {
+				mask, form, gs := doc.alloc(), doc.alloc(), doc.alloc()
+				doc.object(mask, d.shading(g, "DeviceGray", func(c color.NRGBA) string { return pdfNum(float64(c.A) / 255) }))
+				formDict := fmt.Sprintf("/Type /XObject /Subtype /Form /BBox [0 0 %d %d] /Group << /S /Transparency /CS /DeviceGray >> "+
+					"/Resources << /Shading << /Sh0 %d 0 R >> >>", d.size, d.size, mask)
+				if err := doc.stream(form, formDict, []byte("/Sh0 sh\n")); err != nil {
+					return err
+				}
+				doc.object(gs, fmt.Sprintf("<< /Type /ExtGState /SMask << /Type /Mask /S /Luminosity /G %d 0 R >> >>", form))
+				name := fmt.Sprintf("/GS%d", len(states))
+				states = append(states, fmt.Sprintf("%s %d 0 R", name, gs))
+				fmt.Fprintf(&content, "%s gs\n", name)
+			}
+			writePath(&content, layer.path, false)
+			fmt.Fprintf(&content, "W n\n/Sh%d sh\nQ\n", i)
+			continue
+		}
+		setAlpha(&content, layer.paint.color.A)
+		fmt.Fprintf(&content, "%s rg\n", pdfColor(layer.paint.color))
+		writePath(&content, layer.path, false)
+		content.WriteString("f\nQ\n")
+	}
+
+	if d.logo != nil {
+		img := doc.alloc()
+		rgb, alpha := logoSamples(d.logo)
+		b := d.logo.Bounds()
+		dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8", b.Dx(), b.Dy())
+		if alpha != nil {
+			mask := doc.alloc()
+			if err := doc.stream(mask, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8", b.Dx(), b.Dy()), alpha); err != nil {
+				return err
+			}
+			dict += fmt.Sprintf(" /SMask %d 0 R", mask)
+		}
+		if err := doc.stream(img, dict, rgb); err != nil {
+			return err
+		}
+		xobjects = append(xobjects, fmt.Sprintf("/Im0 %d 0 R", img))
+		r := d.logoRect()
+		// Images fill the unit square; flip it back so the first row is on top
+		fmt.Fprintf(&content, "q %d 0 0 %d %d %d cm /Im0 Do Q\n", r.Dx(), -r.Dy(), r.Min.X, r.Max.Y)
+	}
+	content.WriteString("Q\n")
+
+	if err := doc.stream(contents, "", content.Bytes()); err != nil {
+		return err
+	}
+	var resources []string
+	if len(shadings) > 0 {
+		resources = append(resources, "/Shading << "+strings.Join(shadings, " ")+" >>")
+	}
+	if len(xobjects) > 0 {
+		resources = append(resources, "/XObject << "+strings.Join(xobjects, " ")+" >>")
+	}
+	if len(states) > 0 {
+		resources = append(resources, "/ExtGState << "+strings.Join(states, " ")+" >>")
+	}
+	doc.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << %s >> /Contents %d 0 R >>",
+		pages, pdfNum(pt), pdfNum(pt), strings.Join(resources, " "), contents))
+	doc.object(pages, fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", page))
+	doc.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	return doc.writeTo(w, catalog)
+}
+
+// points is the page size of PDF and EPS output: the output pixels printed at dpi.
+func (d *qrDrawing) points() float64 {
+	return float64(d.pixels) * 72 / float64(d.dpi)
+}
+
+// pdfDoc collects numbered PDF objects in any order and writes them with their
+// cross-reference table.
+type pdfDoc struct {
+	body    bytes.Buffer
+	offsets []int // by object number - 1, from the end of the header
+}
+
+const pdfHeader = "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"
+
+// alloc reserves the next object number.
+func (d *pdfDoc) alloc() int {
+	d.offsets = append(d.offsets, 0)
+	return len(d.offsets)
+}
+
+func (d *pdfDoc) object(num int, body string) {
+	d.offsets[num-1] = d.body.Len()
+	fmt.Fprintf(&d.body, "%d 0 obj\n%s\nendobj\n", num, body)
+}
+
+// stream writes a Flate-compressed stream object; dict holds any entries besides
+// /Length and /Filter.
+func (d *pdfDoc) stream(num int, dict string, data []byte) error {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	d.offsets[num-1] = d.body.Len()
+	fmt.Fprintf(&d.body, "%d 0 obj\n<< %s /Length %d /Filter /FlateDecode >>\nstream\n", num, dict, z.Len())
+	d.body.Write(z.Bytes())
+	d.body.WriteString("\nendstream\nendobj\n")
+	return nil
+}
+
+func (d *pdfDoc) writeTo(w io.Writer, root int) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(pdfHeader)
+	bw.Write(d.body.Bytes())
+	fmt.Fprintf(bw, "xref\n0 %d\n0000000000 65535 f \n", len(d.offsets)+1)
+	for _, off := range d.offsets {
+		fmt.Fprintf(bw, "%010d 00000 n \n", len(pdfHeader)+off)
+	}
+	fmt.Fprintf(bw, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(d.offsets)+1, root, len(pdfHeader)+d.body.Len())
+	return bw.Flush()
+}
+
+func pdfNum(f float64) string {
+	return strconv.FormatFloat(math.Round(f*1e4)/1e4, 'f', -1, 64)
+}
+
+func pdfColor(c color.NRGBA) string {
+	return fmt.Sprintf("%s %s %s", pdfNum(float64(c.R)/255), pdfNum(float64(c.G)/255), pdfNum(float64(c.B)/255))
+}
+
+// logoSamples returns the logo's RGB samples and, when any pixel is not opaque, its
+// alpha samples.
+func logoSamples(img image.Image) (rgb, alpha []byte) {
+	b := img.Bounds()
+	rgb = make([]byte, 0, 3*b.Dx()*b.Dy())
+	a := make([]byte, 0, b.Dx()*b.Dy())
+	opaque := true
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			rgb = append(rgb, c.R, c.G, c.B)
+			a = append(a, c.A)
+			opaque = opaque && c.A == 0xff
+		}
+	}
+	if opaque {
+		return rgb, nil
+	}
+	return rgb, a
+}
+
+// writeEPS writes Level 2 PostScript (Level 3 with a gradient). EPS has no alpha, so
+// colors and logo pixels are mixed with the background, or with white where it is
+// transparent; a transparent background is left unpainted.
+func (d *qrDrawing) writeEPS(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	pt := d.points()
+	level := 2
+	for _, layer := range d.layers {
+		if layer.paint.gradient != nil {
+			level = 3
+		}
+	}
+	fmt.Fprintf(bw, "%%!PS-Adobe-3.0 EPSF-3.0\n%%%%BoundingBox: 0 0 %d %d\n%%%%HiResBoundingBox: 0 0 %s %s\n",
+		int(math.Ceil(pt)), int(math.Ceil(pt)), pdfNum(pt), pdfNum(pt))
+	fmt.Fprintf(bw, "%%%%LanguageLevel: %d\n%%%%Pages: 1\n%%%%EndComments\n", level)
+
+	scale := pt / float64(d.size)
+	fmt.Fprintf(bw, "gsave\n0 %s translate %s %s scale\n", pdfNum(pt), pdfNum(scale), pdfNum(-scale))
+	under := flatten(d.bg, color.NRGBA{0xff, 0xff, 0xff, 0xff})
+	if d.bg.A > 0 {
+		fmt.Fprintf(bw, "%s setrgbcolor 0 0 %d %d rectfill\n", pdfColor(under), d.size, d.size)
+	}
+
+	for _, layer := range d.layers {
+		bw.WriteString("newpath\n")
+		writePath(bw, layer.path, true)
+		if g := layer.paint.gradient; g != nil {
+			shading := d.shading(g, "DeviceRGB", func(c color.NRGBA) string { return pdfColor(flatten(c, under)) })
+			fmt.Fprintf(bw, "gsave clip newpath\n%s shfill\ngrestore\n", shading)
+			continue
+		}
+		fmt.Fprintf(bw, "%s setrgbcolor fill\n", pdfColor(flatten(layer.paint.color, under)))
+	}
+
+	if d.logo != nil {
+		r := d.logoRect()
+		rgb, alpha := logoSamples(d.logo)
+		if alpha != nil {
+			for i, a := range alpha {
+				c := flatten(color.NRGBA{rgb[3*i], rgb[3*i+1], rgb[3*i+2], a}, under)
+				rgb[3*i], rgb[3*i+1], rgb[3*i+2] = c.R, c.G, c.B
+			}
+		}
+		// The y axis already points down, so the image matrix needs no flip
+		fmt.Fprintf(bw, "gsave\n%d %d translate %d %d scale\n/DeviceRGB setcolorspace\n", r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+		fmt.Fprintf(bw, "<< /ImageType 1 /Width %d /Height %d /BitsPerComponent 8 /Decode [0 1 0 1 0 1] "+
+			"/ImageMatrix [%d 0 0 %d 0 0] /DataSource currentfile /ASCIIHexDecode filter >> image\n",
+			r.Dx(), r.Dy(), r.Dx(), r.Dy())
+		const hexDigits = "0123456789abcdef"
+		for i, c := range rgb {
+			bw.WriteByte(hexDigits[c>>4])
+			bw.WriteByte(hexDigits[c&0x0f])
+			if i%36 == 35 {
+				bw.WriteByte('\n')
+			}
+		}
+		bw.WriteString(">\ngrestore\n")
+	}
+	bw.WriteString("grestore\nshowpage\n%%EOF\n")
+	return bw.Flush()
+}
+
+// flatten mixes c over the opaque color under.
+func flatten(c, under color.NRGBA) color.NRGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8((int(x)*int(c.A) + int(y)*(255-int(c.A)) + 127) / 255)
+	}
+	return color.NRGBA{mix(c.R, under.R), mix(c.G, under.G), mix(c.B, under.B), 0xff}
+}
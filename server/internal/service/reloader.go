@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// reloader keeps an in-memory copy of a table fresh: it loads it once, then again every
+// interval, so writes made on other instances show up without a restart. The owning
+// service calls now after its own writes to apply them at once.
+type reloader struct {
+	what   string // what is reloaded, for log messages
+	reload func(context.Context) error
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// startReloader runs reload, then starts the periodic loop. Call close on shutdown.
+func startReloader(what string, interval time.Duration, reload func(context.Context) error) *reloader {
+	r := &reloader{what: what, reload: reload, quit: make(chan struct{})}
+	if err := reload(context.Background()); err != nil {
+		slog.Error("Failed to load "+what, "error", err)
+	}
+
+	r.wg.Add(1)
+	go r.loop(interval)
+	return r
+}
+
+// close stops the loop.
+func (r *reloader) close() {
+	r.once.Do(func() { close(r.quit) })
+	r.wg.Wait()
+}
+
+func (r *reloader) loop(interval time.Duration) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.now(context.Background())
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+// now reloads immediately. Errors are logged rather than returned: the write that
+// triggered it is already committed, and the next tick retries.
+func (r *reloader) now(ctx context.Context) {
+	if err := r.reload(ctx); err != nil {
+		slog.Error("Failed to reload "+r.what, "error", err)
+	}
+}
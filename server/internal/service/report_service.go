@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-shortener-sqlc/internal/db"
+)
+
+const maxReportReason = 1000
+
+var (
+	ErrReportReason    = errors.New("reason must be at most 1000 characters")
+	ErrReportStatus    = errors.New("status must be one of: open, dismissed, disabled")
+	ErrAlreadyReported = errors.New("you have already reported this link")
+	ErrReportReviewed  = errors.New("report has already been reviewed")
+)
+
+// Report statuses. Disabling a link closes all of its open reports.
+const (
+	ReportOpen      = "open"
+	ReportDismissed = "dismissed"
+	ReportDisabled  = "disabled"
+)
+
+// LinkReport is an abuse report as shown to admins.
+type LinkReport struct {
+	ID           int32      `json:"id"`
+	Domain       string     `json:"domain"`
+	ShortCode    string     `json:"short_code"`
+	OriginalURL  string     `json:"original_url"`
+	LinkDisabled bool       `json:"link_disabled"`
+	Reason       string     `json:"reason"`
+	ReporterIP   string     `json:"reporter_ip"`
+	Status       string     `json:"status"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+}
+
+// LinkReportPage is a paginated list of reports.
+type LinkReportPage struct {
+	Items []LinkReport `json:"items"`
+	Total int64        `json:"total"`
+	Page  int          `json:"page"`
+	Limit int          `json:"limit"`
+}
+
+// ReportService queues "report this link" submissions for admin review.
+type ReportService struct {
+	q    *db.Queries
+	urls *URLService
+}
+
+func NewReportService(q *db.Queries, urls *URLService) *ReportService {
+	return &ReportService{q: q, urls: urls}
+}
+
+// Report queues a short code for review. Each IP can have one open report per link.
+func (s *ReportService) Report(ctx context.Context, code, reason string, ip net.IP) error {
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > maxReportReason {
+		return ErrReportReason
+	}
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return err
+	}
+
+	reporter := ""
+	if ip != nil {
+		reporter = ip.String()
+		n, err := s.q.CountOpenLinkReportsByIP(ctx, db.CountOpenLinkReportsByIPParams{UrlID: url.ID, ReporterIp: reporter})
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrAlreadyReported
+		}
+	}
+
+	return s.q.CreateLinkReport(ctx, db.CreateLinkReportParams{UrlID: url.ID, Reason: reason, ReporterIp: reporter})
+}
+
+// List returns a page of reports with the given status (default open), oldest first.
+func (s *ReportService) List(ctx context.Context, status string, page, limit int) (*LinkReportPage, error) {
+	if status == "" {
+		status = ReportOpen
+	}
+	if status != ReportOpen && status != ReportDismissed && status != ReportDisabled {
+		return nil, ErrReportStatus
+	}
+
+	rows, err := s.q.ListLinkReports(ctx, db.ListLinkReportsParams{
+		Status: db.LinkReportsStatus(status),
+		Limit:  int32(limit),
+		Offset: int32((page - 1) * limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.q.CountLinkReports(ctx, db.LinkReportsStatus(status))
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]LinkReport, len(rows))
+	for i, r := range rows {
+		items[i] = LinkReport{
+			ID:           r.ID,
+			Domain:       r.Domain,
+			ShortCode:    r.ShortCode,
+			OriginalURL:  r.OriginalUrl,
+			LinkDisabled: r.Disabled,
+			Reason:       r.Reason,
+			ReporterIP:   r.ReporterIp,
+			Status:       string(r.Status),
+			CreatedAt:    r.CreatedAt,
+		}
+		if r.ReviewedAt.Valid {
+			items[i].ReviewedAt = &r.ReviewedAt.Time
+		}
+	}
+	return &LinkReportPage{Items: items, Total: total, Page: page, Limit: limit}, nil
+}
+
+// Dismiss closes an open report without touching the link.
+func (s *ReportService) Dismiss(ctx context.Context, id int32) error {
+	if _, err := s.openReport(ctx, id); err != nil {
+		return err
+	}
+	_, err := s.q.SetLinkReportStatus(ctx, db.SetLinkReportStatusParams{
+		Status:     db.LinkReportsStatusDismissed,
+		ReviewedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:         id,
+	})
+	return err
+}
+
+// DisableLink disables the reported link and closes every open report of it.
+func (s *ReportService) DisableLink(ctx context.Context, id int32) error {
+	report, err := s.openReport(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.urls.SetDisabled(WithDomain(ctx, report.Domain), report.ShortCode, true); err != nil {
+		return err
+	}
+	return s.q.CloseLinkReportsForURL(ctx, db.CloseLinkReportsForURLParams{
+		Status:     db.LinkReportsStatusDisabled,
+		ReviewedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		UrlID:      report.UrlID,
+	})
+}
+
+func (s *ReportService) openReport(ctx context.Context, id int32) (db.GetLinkReportRow, error) {
+	report, err := s.q.GetLinkReport(ctx, id)
+	if err != nil {
+		return report, err
+	}
+	if report.Status != db.LinkReportsStatusOpen {
+		return report, ErrReportReviewed
+	}
+	return report, nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"go-shortener-sqlc/internal/cache"
+)
+
+const (
+	// negativeCacheTTL is how long an unknown code is remembered, so scanners trying
+	// random codes hit the cache instead of MySQL. New links drop the entry.
+	negativeCacheTTL = time.Minute
+	// localCacheTTL bounds how stale the in-process tier can be on other instances
+	// after a link is edited; the instance that made the change drops it at once.
+	localCacheTTL = 5 * time.Second
+)
+
+// missingEntry is the backend value of a cached unknown code.
+var missingEntry = []byte("-")
+
+// urlCache is the cache in front of the redirect lookup: an optional in-process LRU
+// of decoded entries for the hottest codes, then the cache backend (Redis shared by
+// all instances, or memory). Unknown codes are cached as misses for negativeCacheTTL,
+// and concurrent misses for one code share a single database lookup.
+type urlCache struct {
+	backend cache.Cache
+	local   *cache.LRU[*ResolvedURL] // nil disables the in-process tier
+	group   singleflight.Group
+
+	localHits, backendHits, negativeHits, misses, coalesced atomic.Int64
+}
+
+func newURLCache(backend cache.Cache) *urlCache {
+	return &urlCache{backend: backend}
+}
+
+// get looks key up in both tiers. found is true for hits; a nil resolved with found
+// set is a cached unknown code.
+func (c *urlCache) get(ctx context.Context, key string) (resolved *ResolvedURL, found bool) {
+	if c.local != nil {
+		if resolved, found = c.local.Get(key); found {
+			c.localHits.Add(1)
+			c.countNegative(resolved)
+			return resolved, true
+		}
+	}
+	data, err := c.backend.Get(ctx, key)
+	if err != nil {
+		return nil, false
+	}
+	if string(data) != string(missingEntry) {
+		resolved = new(ResolvedURL)
+		if json.Unmarshal(data, resolved) != nil {
+			return nil, false
+		}
+	}
+	c.backendHits.Add(1)
+	c.countNegative(resolved)
+	if c.local != nil {
+		// The backend knows the remaining TTL, but the local tier only ever holds entries briefly
+		c.local.Set(key, resolved, localCacheTTL)
+	}
+	return resolved, true
+}
+
+func (c *urlCache) countNegative(resolved *ResolvedURL) {
+	if resolved == nil {
+		c.negativeHits.Add(1)
+	}
+}
+
+// load runs fn for a missed key, sharing the result with concurrent callers of the
+// same key. fn runs without the first caller's cancellation so a disconnecting
+// visitor can't fail everyone waiting on it.
+func (c *urlCache) load(ctx context.Context, key string, fn func(context.Context) (any, error)) (any, error) {
+	ran := false
+	v, err, _ := c.group.Do(key, func() (any, error) {
+		ran = true
+		c.misses.Add(1)
+		return fn(context.WithoutCancel(ctx))
+	})
+	if !ran {
+		c.coalesced.Add(1)
+	}
+	return v, err
+}
+
+// set stores resolved in both tiers for ttl (ignore cache write errors).
+func (c *urlCache) set(ctx context.Context, key string, resolved *ResolvedURL, ttl time.Duration) {
+	if c.local != nil {
+		c.local.Set(key, resolved, min(ttl, localCacheTTL))
+	}
+	data, err := json.Marshal(resolved)
+	if err != nil {
+		return
+	}
+	c.backend.Set(ctx, key, data, ttl)
+}
+
+// setMissing remembers that key has no link.
+func (c *urlCache) setMissing(ctx context.Context, key string) {
+	if c.local != nil {
+		c.local.Set(key, nil, localCacheTTL)
+	}
+	c.backend.Set(ctx, key, missingEntry, negativeCacheTTL)
+}
+
+// del drops key from both tiers.
+func (c *urlCache) del(ctx context.Context, key string) {
+	if c.local != nil {
+		c.local.Delete(key)
+	}
+	if err := c.backend.Delete(ctx, key); err != nil {
+		slog.Warn("Failed to invalidate URL cache", "key", key, "error", err)
+	}
+}
+
+// CacheStats counts redirect lookups by where they were answered.
+type CacheStats struct {
+	LocalHits    int64 `json:"local_hits"`
+	BackendHits  int64 `json:"backend_hits"`  // Redis or memory, depending on CACHE_BACKEND
+	NegativeHits int64 `json:"negative_hits"` // hits on cached unknown codes, included above
+	Misses       int64 `json:"misses"`        // lookups that went to MySQL
+	Coalesced    int64 `json:"coalesced"`     // misses that waited for another request's lookup
+	LocalEntries int   `json:"local_entries"`
+
+	HitRatio      float64 `json:"hit_ratio"`       // share of lookups answered by either tier
+	LocalHitRatio float64 `json:"local_hit_ratio"` // share of lookups answered in-process
+}
+
+func (c *urlCache) stats() CacheStats {
+	st := CacheStats{
+		LocalHits:    c.localHits.Load(),
+		BackendHits:  c.backendHits.Load(),
+		NegativeHits: c.negativeHits.Load(),
+		Misses:       c.misses.Load(),
+		Coalesced:    c.coalesced.Load(),
+	}
+	if c.local != nil {
+		st.LocalEntries = c.local.Len()
+	}
+	if total := st.LocalHits + st.BackendHits + st.Misses + st.Coalesced; total > 0 {
+		st.HitRatio = float64(st.LocalHits+st.BackendHits) / float64(total)
+		st.LocalHitRatio = float64(st.LocalHits) / float64(total)
+	}
+	return st
+}
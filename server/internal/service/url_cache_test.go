@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"go-shortener-sqlc/internal/db"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func urlTestRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "short_code", "original_url", "url_hash", "is_custom",
+		"expires_at", "max_clicks", "click_count", "disabled", "user_id", "password_hash", "redirect_mode", "sticky", "forward_query", "domain", "created_at", "updated_at"})
+}
+
+func TestURLCacheLookups(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	s := NewURLService(mockDB, db.New(mockDB), nil, nil)
+	s.EnableLocalCache(10)
+	ctx := context.Background()
+
+	t.Run("Unknown Code Is Cached", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+			WithArgs("", "nope").
+			WillReturnError(sql.ErrNoRows)
+
+		for i := 0; i < 3; i++ {
+			if _, err := s.GetOriginalURL(ctx, "nope", Visitor{}); err != sql.ErrNoRows {
+				t.Fatalf("lookup #%d error = %v, want sql.ErrNoRows", i, err)
+			}
+		}
+	})
+
+	t.Run("Concurrent Misses Share One Query", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+			WithArgs("", "hot").
+			WillDelayFor(50 * time.Millisecond).
+			WillReturnRows(urlTestRows().AddRow(1, "hot", "https://example.com", "hash", false, nil, nil, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+		mock.ExpectQuery("SELECT (.+) FROM url_rules").WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery("SELECT (.+) FROM url_variants").WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resolved, err := s.GetOriginalURL(ctx, "hot", Visitor{})
+				if err != nil || resolved.OriginalURL != "https://example.com" {
+					t.Errorf("GetOriginalURL = %v, %v", resolved, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// Served from the local tier now
+		if _, err := s.GetOriginalURL(ctx, "hot", Visitor{}); err != nil {
+			t.Fatalf("cached lookup: %v", err)
+		}
+	})
+
+	t.Run("Invalidate", func(t *testing.T) {
+		s.invalidate(ctx, "hot")
+		mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+			WithArgs("", "hot").
+			WillReturnError(sql.ErrNoRows)
+		if _, err := s.GetOriginalURL(ctx, "hot", Visitor{}); err != sql.ErrNoRows {
+			t.Fatalf("lookup after invalidate error = %v, want sql.ErrNoRows", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	// One database lookup each for nope, hot and hot after the invalidation
+	st := s.CacheStats()
+	if st.Misses != 3 || st.NegativeHits != 2 || st.HitRatio <= 0 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
+
+func TestCreateDropsCachedMiss(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	s := NewURLService(mockDB, db.New(mockDB), nil, nil)
+	s.EnableLocalCache(10)
+	ctx := context.Background()
+	dest := "https://93.184.215.14/promo"
+
+	// Probed before it exists
+	mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+		WithArgs("", "promo").
+		WillReturnError(sql.ErrNoRows)
+	if _, err := s.GetOriginalURL(ctx, "promo", Visitor{}); err != sql.ErrNoRows {
+		t.Fatalf("probe error = %v, want sql.ErrNoRows", err)
+	}
+
+	// Click-limited links are never pre-cached
+	mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+		WithArgs("", "promo").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO urls").WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+		WithArgs("", "promo").
+		WillReturnRows(urlTestRows().AddRow(7, "promo", dest, "hash", true, nil, 5, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+	maxClicks := uint32(5)
+	if _, err := s.Shorten(ctx, ShortenParams{URL: dest, Alias: "promo", MaxClicks: &maxClicks}); err != nil {
+		t.Fatalf("Shorten: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM urls WHERE domain = (.+) AND short_code").
+		WithArgs("", "promo").
+		WillReturnRows(urlTestRows().AddRow(7, "promo", dest, "hash", true, nil, 5, 0, false, nil, nil, "302", false, false, "", time.Now(), time.Now()))
+	mock.ExpectExec("UPDATE urls SET click_count").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT (.+) FROM url_rules").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT (.+) FROM url_variants").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	resolved, err := s.GetOriginalURL(ctx, "promo", Visitor{})
+	if err != nil || resolved.OriginalURL != dest {
+		t.Fatalf("redirect after create = %v, %v", resolved, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
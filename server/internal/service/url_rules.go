@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-shortener-sqlc/internal/db"
+	"go-shortener-sqlc/internal/utils"
+)
+
+var (
+	ErrRuleNoConditions = errors.New("rule needs at least one condition: os, devices, languages, countries, starts_at or ends_at")
+	ErrRuleOS           = errors.New("os must be one of: iOS, Android, Windows, macOS, ChromeOS, Linux, Other")
+	ErrRuleDevice       = errors.New("devices must be one of: desktop, mobile, tablet, bot, unknown")
+	ErrRuleLanguage     = errors.New("languages must be language tags such as 'th' or 'en-US'")
+	ErrRuleCountry      = errors.New("countries must be ISO 3166-1 alpha-2 codes such as 'TH'")
+	ErrRuleTimeWindow   = errors.New("starts_at must be before ends_at")
+)
+
+var ruleOS = []string{"iOS", "Android", "Windows", "macOS", "ChromeOS", "Linux", "Other"}
+
+var ruleDevices = []string{utils.DeviceDesktop, utils.DeviceMobile, utils.DeviceTablet, utils.DeviceBot, utils.DeviceUnknown}
+
+var (
+	languagePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+	countryPattern  = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// RedirectRule sends matching visitors to Destination instead of the link's default URL.
+// All non-empty conditions must match; within one condition any listed value matches.
+// The same struct is used for the API and for the Redis cache entry.
+type RedirectRule struct {
+	ID          int32      `json:"id"`
+	Position    int32      `json:"position"`
+	Destination string     `json:"destination"`
+	OS          []string   `json:"os,omitempty"`
+	Devices     []string   `json:"devices,omitempty"`
+	Languages   []string   `json:"languages,omitempty"`
+	Countries   []string   `json:"countries,omitempty"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+}
+
+// Visitor is the request data redirect rules and variants are chosen by.
+type Visitor struct {
+	UserAgent      string
+	AcceptLanguage string
+	IP             net.IP
+	Variant        string // label from the sticky variant cookie, if any
+}
+
+// visitorAttrs are the visitor properties rules are matched against.
+type visitorAttrs struct {
+	os, device, language, country string
+	now                           time.Time
+}
+
+// matchRules returns the destination of the first matching rule.
+func (s *URLService) matchRules(rules []RedirectRule, visitor Visitor) (string, bool) {
+	if len(rules) == 0 {
+		return "", false
+	}
+
+	ua := utils.ParseUserAgent(visitor.UserAgent)
+	attrs := visitorAttrs{
+		os:       ua.OS,
+		device:   ua.Device,
+		language: preferredLanguage(visitor.AcceptLanguage),
+		now:      time.Now(),
+	}
+	if visitor.IP != nil {
+		attrs.country = s.geo.Country(visitor.IP)
+	}
+
+	for _, rule := range rules {
+		if rule.matches(attrs) {
+			return rule.Destination, true
+		}
+	}
+	return "", false
+}
+
+func (r RedirectRule) matches(v visitorAttrs) bool {
+	if r.StartsAt != nil && v.now.Before(*r.StartsAt) {
+		return false
+	}
+	if r.EndsAt != nil && !v.now.Before(*r.EndsAt) {
+		return false
+	}
+	if len(r.OS) > 0 && !containsFold(r.OS, v.os) {
+		return false
+	}
+	if len(r.Devices) > 0 && !containsFold(r.Devices, v.device) {
+		return false
+	}
+	if len(r.Languages) > 0 && !matchesLanguage(r.Languages, v.language) {
+		return false
+	}
+	if len(r.Countries) > 0 && !containsFold(r.Countries, v.country) {
+		return false
+	}
+	return true
+}
+
+// preferredLanguage returns the highest-weighted tag of an Accept-Language header.
+func preferredLanguage(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if val, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+	return best
+}
+
+// matchesLanguage reports whether tag equals one of langs or is a subtag of one ("en" matches "en-US").
+func matchesLanguage(langs []string, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	for _, lang := range langs {
+		if strings.EqualFold(tag, lang) || (len(tag) > len(lang) && strings.EqualFold(tag[:len(lang)], lang) && tag[len(lang)] == '-') {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Rule Management ---
+
+// normalize validates the rule and canonicalizes the condition values.
+func (r *RedirectRule) normalize() error {
+	if err := ValidateDestination(r.Destination); err != nil {
+		return err
+	}
+
+	var err error
+	if r.OS, err = canonicalList(r.OS, ruleOS, ErrRuleOS); err != nil {
+		return err
+	}
+	if r.Devices, err = canonicalList(r.Devices, ruleDevices, ErrRuleDevice); err != nil {
+		return err
+	}
+	for i, lang := range r.Languages {
+		lang = strings.TrimSpace(lang)
+		if !languagePattern.MatchString(lang) {
+			return ErrRuleLanguage
+		}
+		r.Languages[i] = lang
+	}
+	for i, country := range r.Countries {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if !countryPattern.MatchString(country) {
+			return ErrRuleCountry
+		}
+		r.Countries[i] = country
+	}
+	if r.StartsAt != nil && r.EndsAt != nil && !r.StartsAt.Before(*r.EndsAt) {
+		return ErrRuleTimeWindow
+	}
+
+	if len(r.OS) == 0 && len(r.Devices) == 0 && len(r.Languages) == 0 && len(r.Countries) == 0 &&
+		r.StartsAt == nil && r.EndsAt == nil {
+		return ErrRuleNoConditions
+	}
+	return nil
+}
+
+// canonicalList maps each value to its canonical spelling in allowed (case-insensitive).
+func canonicalList(values, allowed []string, errInvalid error) ([]string, error) {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		found := false
+		for _, a := range allowed {
+			if strings.EqualFold(v, a) {
+				out = append(out, a)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errInvalid
+		}
+	}
+	return out, nil
+}
+
+func newRedirectRule(r db.UrlRule) RedirectRule {
+	rule := RedirectRule{
+		ID:          r.ID,
+		Position:    r.Position,
+		Destination: r.Destination,
+		OS:          splitList(r.Os),
+		Devices:     splitList(r.Devices),
+		Languages:   splitList(r.Languages),
+		Countries:   splitList(r.Countries),
+	}
+	if r.StartsAt.Valid {
+		rule.StartsAt = &r.StartsAt.Time
+	}
+	if r.EndsAt.Valid {
+		rule.EndsAt = &r.EndsAt.Time
+	}
+	return rule
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func nullTimePtr(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+// loadRules returns the rules of a URL in evaluation order.
+func (s *URLService) loadRules(ctx context.Context, urlID int32) ([]RedirectRule, error) {
+	rows, err := s.q.ListURLRules(ctx, urlID)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]RedirectRule, len(rows))
+	for i, row := range rows {
+		rules[i] = newRedirectRule(row)
+	}
+	return rules, nil
+}
+
+// ListRules returns the redirect rules of a short code in evaluation order.
+func (s *URLService) ListRules(ctx context.Context, code string) ([]RedirectRule, error) {
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadRules(ctx, url.ID)
+}
+
+// CreateRule adds a redirect rule to a short code.
+func (s *URLService) CreateRule(ctx context.Context, code string, rule RedirectRule) (*RedirectRule, error) {
+	if err := rule.normalize(); err != nil {
+		return nil, err
+	}
+	if err := s.checkBlocked(rule.Destination); err != nil {
+		return nil, err
+	}
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.q.CreateURLRule(ctx, db.CreateURLRuleParams{
+		UrlID:       url.ID,
+		Position:    rule.Position,
+		Destination: rule.Destination,
+		Os:          strings.Join(rule.OS, ","),
+		Devices:     strings.Join(rule.Devices, ","),
+		Languages:   strings.Join(rule.Languages, ","),
+		Countries:   strings.Join(rule.Countries, ","),
+		StartsAt:    nullTimePtr(rule.StartsAt),
+		EndsAt:      nullTimePtr(rule.EndsAt),
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, code)
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = int32(id)
+	return &rule, nil
+}
+
+// UpdateRule replaces a rule of a short code. Returns sql.ErrNoRows if the rule belongs to another code.
+func (s *URLService) UpdateRule(ctx context.Context, code string, id int32, rule RedirectRule) (*RedirectRule, error) {
+	if err := rule.normalize(); err != nil {
+		return nil, err
+	}
+	if err := s.checkBlocked(rule.Destination); err != nil {
+		return nil, err
+	}
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.q.GetURLRule(ctx, db.GetURLRuleParams{ID: id, UrlID: url.ID}); err != nil {
+		return nil, err
+	}
+
+	err = s.q.UpdateURLRule(ctx, db.UpdateURLRuleParams{
+		Position:    rule.Position,
+		Destination: rule.Destination,
+		Os:          strings.Join(rule.OS, ","),
+		Devices:     strings.Join(rule.Devices, ","),
+		Languages:   strings.Join(rule.Languages, ","),
+		Countries:   strings.Join(rule.Countries, ","),
+		StartsAt:    nullTimePtr(rule.StartsAt),
+		EndsAt:      nullTimePtr(rule.EndsAt),
+		ID:          id,
+		UrlID:       url.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, code)
+
+	rule.ID = id
+	return &rule, nil
+}
+
+// DeleteRule removes a rule from a short code.
+func (s *URLService) DeleteRule(ctx context.Context, code string, id int32) error {
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return err
+	}
+	n, err := s.q.DeleteURLRule(ctx, db.DeleteURLRuleParams{ID: id, UrlID: url.ID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	s.invalidate(ctx, code)
+	return nil
+}
@@ -2,127 +2,975 @@ package service
 
 import (
 	"context"
-	"crypto/rand"
 	"crypto/sha256"
 	"database/sql"
-	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"log/slog"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
-	"github.com/redis/go-redis/v9"
+	"github.com/go-sql-driver/mysql"
 
+	"go-shortener-sqlc/internal/auth"
+	"go-shortener-sqlc/internal/cache"
 	"go-shortener-sqlc/internal/db"
+	"go-shortener-sqlc/internal/geoip"
+	"go-shortener-sqlc/internal/utils"
 )
 
 type URLService struct {
-	q   *db.Queries
-	rdb *redis.Client
+	conn     *sql.DB // for multi-statement writes (variants)
+	q        *db.Queries
+	cache    *urlCache
+	attempts *attemptLimiter // failed password attempts per link+IP
+	geo      geoip.Lookup    // country lookup for redirect rules
+
+	// Previews fetches destination metadata after create/update; nil disables fetching
+	Previews *PreviewService
+	// Health supplies the fallback destination of broken links; nil disables the fallback
+	Health *HealthService
+	// Blocklist rejects destinations on create/update and on redirect; nil allows everything
+	Blocklist *Blocklist
+	// Codes generates codes for links without an alias; nil uses random codes
+	Codes CodeGenerator
+	// Domains validates the branded domain picked at creation; nil only allows the default domain
+	Domains *DomainService
+	// BaseURL is the public URL of the default domain, used to build ShortURL in responses
+	BaseURL string
 }
 
-func NewURLService(q *db.Queries, rdb *redis.Client) *URLService {
-	return &URLService{q: q, rdb: rdb}
+// NewURLService creates the service. A nil cache disables caching and a nil geo
+// disables country lookups.
+func NewURLService(conn *sql.DB, q *db.Queries, c cache.Cache, geo geoip.Lookup) *URLService {
+	if c == nil {
+		c = cache.Nop{}
+	}
+	if geo == nil {
+		geo = geoip.Nop{}
+	}
+	return &URLService{
+		conn:     conn,
+		q:        q,
+		cache:    newURLCache(c),
+		geo:      geo,
+		attempts: newAttemptLimiter(c, "pwfail:", maxPasswordAttempts, passwordAttemptWindow),
+	}
 }
 
 const urlCacheTTL = 24 * time.Hour
 
+// EnableLocalCache puts an in-process LRU of size codes in front of the cache backend.
+func (s *URLService) EnableLocalCache(size int) {
+	if size > 0 {
+		s.cache.local = cache.NewLRU[*ResolvedURL](size)
+	}
+}
+
+// CacheStats reports how redirect lookups were answered since startup.
+func (s *URLService) CacheStats() CacheStats {
+	return s.cache.stats()
+}
+
+// maxCodeAttempts bounds how many generated codes Shorten tries before giving up.
+const maxCodeAttempts = 5
+
+const (
+	maxPasswordAttempts   = 5
+	passwordAttemptWindow = 15 * time.Minute
+)
+
+var (
+	ErrInvalidAlias  = errors.New("alias must be 3-20 characters: letters, digits, '-' or '_'")
+	ErrReservedAlias = errors.New("alias is reserved")
+	ErrAliasTaken    = errors.New("alias is already in use")
+
+	ErrURLRequired = errors.New("url is required")
+	ErrURLTooLong  = errors.New("url too long (max 2048 chars)")
+	ErrInvalidURL  = errors.New("invalid URL format: must start with http:// or https://")
+	ErrUnsafeURL   = errors.New("unsafe URL destination (private IPs not allowed)")
+
+	ErrExpiryInPast     = errors.New("expires_at must be in the future")
+	ErrInvalidMaxClicks = errors.New("max_clicks must be between 1 and 2147483647")
+	ErrLinkExpired      = errors.New("link has expired")
+	ErrLinkExhausted    = errors.New("link has reached its click limit")
+	ErrLinkDisabled     = errors.New("link has been disabled")
+
+	ErrInvalidRedirectMode = errors.New("redirect_mode must be one of: 301, 302, 307, 308, preview, meta")
+
+	ErrInvalidPassword  = errors.New("password must be 4-72 characters")
+	ErrPasswordRequired = errors.New("link is password protected")
+	ErrWrongPassword    = errors.New("incorrect password")
+	ErrTooManyAttempts  = errors.New("too many incorrect passwords, try again later")
+)
+
+const maxURLLength = 2048
+
+// ReservedAliases lists codes that would shadow a top-level route in the router,
+// or a static route under /api/admin/urls (e.g. "broken").
+// Keep in sync with api.Routes when adding new top-level paths.
+var ReservedAliases = map[string]bool{
+	"api":     true,
+	"healthz": true,
+	"uploads": true,
+	"shorten": true,
+	"admin":   true,
+	"static":  true,
+	"broken":  true,
+}
+
+// Redirect modes. The numeric modes are HTTP redirect statuses; RedirectPreview shows
+// the destination and waits for the visitor to continue, RedirectMeta serves an HTML
+// page that redirects via meta refresh/JS so page scripts (tracking pixels) can run.
+const (
+	RedirectMovedPermanently = "301"
+	RedirectFound            = "302"
+	RedirectTemporary        = "307"
+	RedirectPermanent        = "308"
+	RedirectPreview          = "preview"
+	RedirectMeta             = "meta"
+	DefaultRedirectMode      = RedirectFound
+)
+
+var redirectModes = map[string]bool{
+	RedirectMovedPermanently: true,
+	RedirectFound:            true,
+	RedirectTemporary:        true,
+	RedirectPermanent:        true,
+	RedirectPreview:          true,
+	RedirectMeta:             true,
+}
+
+// ValidateRedirectMode checks mode against the supported redirect modes.
+func ValidateRedirectMode(mode string) error {
+	if !redirectModes[mode] {
+		return ErrInvalidRedirectMode
+	}
+	return nil
+}
+
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{2,19}$`)
+
+// ShortenParams holds the caller-supplied options for a new short URL.
+type ShortenParams struct {
+	URL          string
+	Alias        string     // Optional custom short code
+	ExpiresAt    *time.Time // Optional absolute expiry
+	MaxClicks    *uint32    // Optional redirect limit
+	UserID       string     // Owner; empty for anonymous links
+	Password     string     // Optional; visitors must enter it before being redirected
+	RedirectMode string     // Optional; defaults to DefaultRedirectMode
+	Variants     []Variant  // Optional weighted destinations that replace URL on redirect
+	Sticky       bool       // Keep visitors on their first variant (only with Variants)
+	UTM          UTM        // Optional campaign tags merged into URL before hashing
+	ForwardQuery bool       // Append the short URL's query string to the destination on redirect
+	Domain       string     // Optional registered branded domain; empty for the default domain
+}
+
+// isPlain reports whether the link has no per-link options and may therefore be shared.
+func (p ShortenParams) isPlain() bool {
+	return p.Alias == "" && p.ExpiresAt == nil && p.MaxClicks == nil && p.Password == "" &&
+		(p.RedirectMode == "" || p.RedirectMode == DefaultRedirectMode) && len(p.Variants) == 0 && !p.ForwardQuery
+}
+
+// ValidateAlias checks a custom alias against the character/length policy and reserved words.
+func ValidateAlias(alias string) error {
+	if !aliasPattern.MatchString(alias) {
+		return ErrInvalidAlias
+	}
+	if ReservedAliases[strings.ToLower(alias)] {
+		return ErrReservedAlias
+	}
+	return nil
+}
+
+// ValidateDestination checks that rawURL is an absolute http(s) URL that does not
+// point at localhost or a private network (SSRF protection).
+func ValidateDestination(rawURL string) error {
+	if rawURL == "" {
+		return ErrURLRequired
+	}
+	if len(rawURL) > maxURLLength {
+		return ErrURLTooLong
+	}
+
+	parsedURL, err := url.ParseRequestURI(rawURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return ErrInvalidURL
+	}
+
+	if err := utils.ValidateTargetURL(rawURL); err != nil {
+		return ErrUnsafeURL
+	}
+	return nil
+}
+
+// validate checks the destination and the optional link settings.
+func (p ShortenParams) validate() error {
+	if err := ValidateDestination(p.URL); err != nil {
+		return err
+	}
+	if p.Alias != "" {
+		if err := ValidateAlias(p.Alias); err != nil {
+			return err
+		}
+	}
+	if p.ExpiresAt != nil && !p.ExpiresAt.After(time.Now()) {
+		return ErrExpiryInPast
+	}
+	// max_clicks is stored as a signed 32-bit value
+	if p.MaxClicks != nil && (*p.MaxClicks == 0 || *p.MaxClicks > math.MaxInt32) {
+		return ErrInvalidMaxClicks
+	}
+	// bcrypt ignores everything after 72 bytes
+	if p.Password != "" && (len(p.Password) < 4 || len(p.Password) > 72) {
+		return ErrInvalidPassword
+	}
+	if p.RedirectMode != "" {
+		if err := ValidateRedirectMode(p.RedirectMode); err != nil {
+			return err
+		}
+	}
+	if err := p.UTM.validate(); err != nil {
+		return err
+	}
+	return normalizeVariants(p.Variants)
+}
+
+// ShortenResult is the link Shorten created or, for a deduplicated plain link, reused.
+type ShortenResult struct {
+	URLDetails
+	Reused bool // an existing link was returned instead of a new one
+}
+
 // Shorten processes the logic to shorten a URL.
-// It checks for existing URLs (deduplication) and handles collision retries.
-func (s *URLService) Shorten(ctx context.Context, originalURL string) (string, error) {
+// Plain links are deduplicated by URL hash; a custom alias, expiry, click limit, password
+// or variant list always gets its own row, and links that gained redirect rules are never reused.
+func (s *URLService) Shorten(ctx context.Context, params ShortenParams) (*ShortenResult, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	domain, err := s.Domains.Canonical(params.Domain)
+	if err != nil {
+		return nil, err
+	}
+	params.Domain = domain
+	ctx = WithDomain(ctx, domain)
+
+	// Tagged URLs are hashed with their tags, so each campaign gets its own code
+	params.URL = MergeQuery(params.URL, params.UTM.values())
+	if len(params.URL) > maxURLLength {
+		return nil, ErrURLTooLong
+	}
+	dests := []string{params.URL}
+	for _, v := range params.Variants {
+		dests = append(dests, v.URL)
+	}
+	if err := s.checkBlocked(dests...); err != nil {
+		return nil, err
+	}
+
 	// 1. Calculate SHA-256 hash
-	hash := sha256.Sum256([]byte(originalURL))
-	urlHash := hex.EncodeToString(hash[:])
+	urlHash := hashURL(params.URL)
 
-	// 2. Check if URL already exists
-	existingURL, err := s.q.GetURLByHash(ctx, urlHash)
-	if err == nil {
-		return existingURL.ShortCode, nil
-	} else if err != sql.ErrNoRows {
-		return "", err
+	if params.Alias != "" {
+		return s.shortenWithAlias(ctx, params, urlHash)
 	}
 
-	// 3. Generate Short Code with Retry
-	var shortCode string
-	maxRetries := 5
-	for i := 0; i < maxRetries; i++ {
-		code, err := generateShortCode()
-		if err != nil {
+	// 2. Check if URL already exists (only plain links of the same owner are shared)
+	if params.isPlain() {
+		byHash := db.GetURLByHashParams{UrlHash: urlHash, UserID: nullString(params.UserID), Domain: params.Domain}
+		existingURL, err := s.q.GetURLByHash(ctx, byHash)
+		if err == nil {
+			return &ShortenResult{URLDetails: s.newURLDetails(existingURL), Reused: true}, nil
+		} else if err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	// 3. Generate a code and insert, moving on to the next code on a collision
+	codes := s.Codes
+	if codes == nil {
+		codes = defaultCodes
+	}
+	for i := 0; i < maxCodeAttempts; i++ {
+		code, err := codes.Next(ctx)
+		if errors.Is(err, ErrCodeSpaceExhausted) {
+			return nil, err
+		} else if err != nil {
 			slog.Error("Error generating short code", "error", err)
 			continue
 		}
+		if ReservedAliases[code] {
+			continue
+		}
 
-		// Check if code exists
-		_, err = s.q.GetURL(ctx, code)
+		// Random codes can repeat, so check before inserting
+		if !codes.Unique() {
+			_, err = getURL(ctx, s.q, code)
+			if err == nil {
+				continue // Collision
+			} else if err != sql.ErrNoRows {
+				return nil, err
+			}
+		}
+
+		// 4. Insert into database
+		err = s.createURL(ctx, code, urlHash, params)
 		if err == nil {
-			continue // Collision
-		} else if err != sql.ErrNoRows {
-			return "", err
+			return s.created(ctx, code)
+		}
+		// A duplicate key here is a short code clash (codes are unique per domain); the URL
+		// itself is not, so a concurrent identical request may have created a second link
+		if !isDuplicateKey(err) {
+			return nil, err
+		}
+	}
+
+	return nil, errors.New("failed to generate unique short code")
+}
+
+// shortenWithAlias stores the URL under the caller-chosen code, failing with ErrAliasTaken on conflict.
+func (s *URLService) shortenWithAlias(ctx context.Context, params ShortenParams, urlHash string) (*ShortenResult, error) {
+	_, err := getURL(ctx, s.q, params.Alias)
+	if err == nil {
+		return nil, ErrAliasTaken
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if err := s.createURL(ctx, params.Alias, urlHash, params); err != nil {
+		if isDuplicateKey(err) {
+			return nil, ErrAliasTaken
 		}
+		return nil, err
+	}
+
+	return s.created(ctx, params.Alias)
+}
+
+// created reads back a link Shorten just inserted, so the result carries the stored
+// timestamps. ctx must be scoped to the link's domain.
+func (s *URLService) created(ctx context.Context, code string) (*ShortenResult, error) {
+	u, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
+	}
+	return &ShortenResult{URLDetails: s.newURLDetails(u)}, nil
+}
+
+// createURL inserts the row, drops any cached miss for the code and pre-caches the link.
+func (s *URLService) createURL(ctx context.Context, code, urlHash string, params ShortenParams) error {
+	arg := db.CreateURLParams{
+		ShortCode:    code,
+		OriginalUrl:  params.URL,
+		UrlHash:      urlHash,
+		IsCustom:     params.Alias != "",
+		UserID:       nullString(params.UserID),
+		RedirectMode: db.UrlsRedirectMode(DefaultRedirectMode),
+		ForwardQuery: params.ForwardQuery,
+		Domain:       params.Domain,
+	}
+	if params.RedirectMode != "" {
+		arg.RedirectMode = db.UrlsRedirectMode(params.RedirectMode)
+	}
+	if params.ExpiresAt != nil {
+		arg.ExpiresAt = sql.NullTime{Time: *params.ExpiresAt, Valid: true}
+	}
+	if params.MaxClicks != nil {
+		arg.MaxClicks = sql.NullInt32{Int32: int32(*params.MaxClicks), Valid: true}
+	}
+	if params.Password != "" {
+		hash, err := auth.HashPassword(params.Password)
+		if err != nil {
+			return err
+		}
+		arg.PasswordHash = sql.NullString{String: hash, Valid: true}
+	}
+
+	var result sql.Result
+	var err error
+	if len(params.Variants) > 0 {
+		result, err = s.createURLWithVariants(ctx, arg, VariantSet{Sticky: params.Sticky, Variants: params.Variants})
+	} else {
+		result, err = s.q.CreateURL(ctx, arg)
+	}
+	if err != nil {
+		return err
+	}
+	// The code may have been probed before it existed and cached as unknown
+	s.invalidate(ctx, code)
+
+	// The row is stored either way; without its ID the link is only read from the DB
+	// on its first visit and gets no preview
+	if id, err := result.LastInsertId(); err != nil {
+		slog.Error("Failed to read ID of new link", "code", code, "error", err)
+	} else {
+		s.prepareNewURL(ctx, int32(id), code, params, arg)
+	}
+	return nil
+}
+
+// prepareNewURL queues the preview fetch of a link createURL just inserted and
+// pre-caches it (click-limited links are never cached).
+func (s *URLService) prepareNewURL(ctx context.Context, id int32, code string, params ShortenParams, arg db.CreateURLParams) {
+	// Title, description and favicon for the admin UI are fetched in the background
+	s.Previews.Enqueue(id, params.URL)
+
+	if arg.MaxClicks.Valid {
+		return
+	}
+	resolved := &ResolvedURL{
+		ID:           id,
+		OriginalURL:  params.URL,
+		RedirectMode: string(arg.RedirectMode),
+		Variants:     params.Variants,
+		Sticky:       params.Sticky && len(params.Variants) > 0,
+		ForwardQuery: params.ForwardQuery,
+	}
+	if arg.PasswordHash.Valid {
+		resolved = &ResolvedURL{ID: id, Protected: true}
+	}
+	s.cacheURL(ctx, code, resolved, arg.ExpiresAt)
+}
+
+// ResolvedURL is what the redirect path needs to know about a short code.
+// It is stored as JSON under the urlCacheKey cache key. Password-protected
+// links are cached with Protected set and no destination. Rules and variants are
+// cached with the link and evaluated per visitor: the first matching rule wins,
+// then a weighted variant, then OriginalURL.
+type ResolvedURL struct {
+	ID           int32          `json:"id"`
+	OriginalURL  string         `json:"original_url,omitempty"`
+	RedirectMode string         `json:"redirect_mode,omitempty"`
+	Protected    bool           `json:"protected,omitempty"`
+	Rules        []RedirectRule `json:"rules,omitempty"`
+	Variants     []Variant      `json:"variants,omitempty"`
+	Sticky       bool           `json:"sticky,omitempty"`
+	ForwardQuery bool           `json:"forward_query,omitempty"`
+	Fallback     string         `json:"fallback,omitempty"` // replaces OriginalURL while the destination is broken
+
+	// Variant is the label of the variant served to this visitor (set by GetOriginalURL, never cached)
+	Variant string `json:"-"`
+}
+
+// resolve loads the redirect rules and variants of url into a ResolvedURL.
+func (s *URLService) resolve(ctx context.Context, url db.Url) (*ResolvedURL, error) {
+	rules, err := s.loadRules(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+	variants, err := s.loadVariants(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+	fallback, err := s.Health.fallbackFor(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &ResolvedURL{
+		ID:           url.ID,
+		OriginalURL:  url.OriginalUrl,
+		RedirectMode: string(url.RedirectMode),
+		Rules:        rules,
+		Variants:     variants,
+		Sticky:       url.Sticky,
+		ForwardQuery: url.ForwardQuery,
+		Fallback:     fallback,
+	}, nil
+}
+
+// forVisitor returns a copy of resolved whose OriginalURL is the destination for visitor.
+func (s *URLService) forVisitor(resolved *ResolvedURL, visitor Visitor) *ResolvedURL {
+	out := *resolved
+	out.Rules, out.Variants = nil, nil
+
+	if dest, ok := s.matchRules(resolved.Rules, visitor); ok {
+		out.OriginalURL = dest
+		out.Sticky = false
+	} else if len(resolved.Variants) > 0 {
+		preferred := ""
+		if resolved.Sticky {
+			preferred = visitor.Variant
+		}
+		v := pickVariant(resolved.Variants, preferred)
+		out.OriginalURL, out.Variant = v.URL, v.Label
+	} else if resolved.Fallback != "" {
+		out.OriginalURL = resolved.Fallback
+	}
+	return &out
+}
+
+// visit picks the destination for visitor and refuses blocklisted ones, so links
+// stop redirecting as soon as their destination is blocked (cached entries included).
+func (s *URLService) visit(resolved *ResolvedURL, visitor Visitor) (*ResolvedURL, error) {
+	out := s.forVisitor(resolved, visitor)
+	if err := s.Blocklist.Check(out.OriginalURL); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-		shortCode = code
-		break
+// checkBlocked returns ErrURLBlocked if any destination is blocklisted.
+func (s *URLService) checkBlocked(dests ...string) error {
+	for _, dest := range dests {
+		if err := s.Blocklist.Check(dest); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// GetOriginalURL retrieves the destination of a short code for visitor.
+// Uses a cache-aside pattern (in-process LRU, then the cache backend): check the cache first,
+// fall back to the DB, then cache the result. Unknown codes are cached briefly too.
+// Redirect rules are evaluated in order; the link's original_url is the fallback,
+// unless the health checker marked it broken and a fallback URL is configured.
+// Returns ErrLinkExpired or ErrLinkExhausted once the link is no longer usable,
+// ErrPasswordRequired for protected links (use UnlockURL instead), and
+// ErrURLBlocked when the destination is blocklisted.
+func (s *URLService) GetOriginalURL(ctx context.Context, code string, visitor Visitor) (*ResolvedURL, error) {
+	key := urlCacheKey(ctx, code)
 
-	if shortCode == "" {
-		return "", errors.New("failed to generate unique short code")
+	// 1. Check the cache first (entries never outlive the link's expiry)
+	if resolved, found := s.cache.get(ctx, key); found {
+		if resolved == nil {
+			return nil, sql.ErrNoRows
+		}
+		if resolved.Protected {
+			return nil, ErrPasswordRequired
+		}
+		return s.visit(resolved, visitor) // Cache hit!
 	}
 
-	// 4. Insert into database
-	_, err = s.q.CreateURL(ctx, db.CreateURLParams{
-		ShortCode:   shortCode,
-		OriginalUrl: originalURL,
-		UrlHash:     urlHash,
+	// 2. Cache miss → query DB; concurrent misses for the code share one lookup
+	v, err := s.cache.load(ctx, key, func(ctx context.Context) (any, error) {
+		return s.lookup(ctx, code, key)
 	})
+	if err != nil {
+		return nil, err
+	}
+	found := v.(*lookupResult)
+
+	// 3. Protected links never reveal the destination here; the password is checked on every visit
+	if found.url.PasswordHash.Valid {
+		return nil, ErrPasswordRequired
+	}
+
+	// 4. Click-limited links must count every redirect, so they bypass the cache
+	if found.url.MaxClicks.Valid {
+		return s.consumeClick(ctx, found.url, visitor)
+	}
+	return s.visit(found.resolved, visitor)
+}
+
+// lookupResult is a link read from the DB on a cache miss. resolved is nil for
+// protected and click-limited links.
+type lookupResult struct {
+	url      db.Url
+	resolved *ResolvedURL
+}
+
+// lookup reads code from the DB and caches what the redirect path may reuse.
+func (s *URLService) lookup(ctx context.Context, code, key string) (*lookupResult, error) {
+	url, err := getURL(ctx, s.q, code)
+	if err == sql.ErrNoRows {
+		s.cache.setMissing(ctx, key)
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+	if err := checkUsable(url); err != nil {
+		return nil, err
+	}
+	// Click-limited links are never cached
+	if url.MaxClicks.Valid {
+		return &lookupResult{url: url}, nil
+	}
+	if url.PasswordHash.Valid {
+		s.cacheURL(ctx, code, &ResolvedURL{ID: url.ID, Protected: true}, url.ExpiresAt)
+		return &lookupResult{url: url}, nil
+	}
 
+	// Store in the cache with TTL (ignore cache write errors)
+	resolved, err := s.resolve(ctx, url)
 	if err != nil {
-		// Race condition Check
-		existingURL, retryErr := s.q.GetURLByHash(ctx, urlHash)
-		if retryErr == nil {
-			return existingURL.ShortCode, nil
+		return nil, err
+	}
+	s.cacheURL(ctx, code, resolved, url.ExpiresAt)
+	return &lookupResult{url: url, resolved: resolved}, nil
+}
+
+// UnlockURL checks the password of a protected link and returns its destination.
+// Failed attempts are limited per link and visitor IP; the DB is always
+// consulted, so a cached entry can never skip the check.
+func (s *URLService) UnlockURL(ctx context.Context, code, password string, visitor Visitor) (*ResolvedURL, error) {
+	// Same shape as urlCacheKey, so one code on two domains is limited separately
+	key := code + ":"
+	if domain := DomainFrom(ctx); domain != "" {
+		key = domain + "/" + key
+	}
+	if visitor.IP != nil {
+		key += visitor.IP.String()
+	}
+	if blocked, _ := s.attempts.Blocked(ctx, key); blocked {
+		return nil, ErrTooManyAttempts
+	}
+
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUsable(url); err != nil {
+		return nil, err
+	}
+
+	if url.PasswordHash.Valid {
+		if !auth.CheckPasswordHash(password, url.PasswordHash.String) {
+			s.attempts.Fail(ctx, key)
+			return nil, ErrWrongPassword
 		}
-		return "", err
+		s.attempts.Reset(ctx, key)
+	}
+
+	if url.MaxClicks.Valid {
+		return s.consumeClick(ctx, url, visitor)
 	}
+	resolved, err := s.resolve(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return s.visit(resolved, visitor)
+}
 
-	// 5. Pre-cache the new URL in Redis
-	if s.rdb != nil {
-		s.rdb.Set(ctx, "url:"+shortCode, originalURL, urlCacheTTL)
+// checkUsable rejects disabled and expired links.
+func checkUsable(url db.Url) error {
+	if url.Disabled {
+		return ErrLinkDisabled
 	}
+	if url.ExpiresAt.Valid && !time.Now().Before(url.ExpiresAt.Time) {
+		return ErrLinkExpired
+	}
+	return nil
+}
 
-	return shortCode, nil
+// consumeClick counts one redirect against the link's click limit.
+func (s *URLService) consumeClick(ctx context.Context, url db.Url, visitor Visitor) (*ResolvedURL, error) {
+	n, err := s.q.IncrementURLClicks(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrLinkExhausted
+	}
+	resolved, err := s.resolve(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return s.visit(resolved, visitor)
 }
 
-// GetOriginalURL retrieves the original URL for a given short code.
-// Uses Redis cache-aside pattern: check cache first, fallback to DB, then cache the result.
-func (s *URLService) GetOriginalURL(ctx context.Context, code string) (string, error) {
-	// 1. Check Redis cache first
-	if s.rdb != nil {
-		cached, err := s.rdb.Get(ctx, "url:"+code).Result()
-		if err == nil {
-			return cached, nil // Cache hit!
+// cacheURL stores the resolved URL in the cache (ignore cache write errors).
+// The TTL is clamped to the link's remaining lifetime so expired links stop redirecting.
+func (s *URLService) cacheURL(ctx context.Context, code string, resolved *ResolvedURL, expiresAt sql.NullTime) {
+	ttl := urlCacheTTL
+	if expiresAt.Valid {
+		remaining := time.Until(expiresAt.Time)
+		if remaining <= 0 {
+			return
 		}
+		if remaining < ttl {
+			ttl = remaining
+		}
+	}
+	s.cache.set(ctx, urlCacheKey(ctx, code), resolved, ttl)
+}
+
+// urlCacheKey is "url:{code}" on the default domain and "url:{domain}/{code}" on branded ones.
+func urlCacheKey(ctx context.Context, code string) string {
+	if domain := DomainFrom(ctx); domain != "" {
+		return "url:" + domain + "/" + code
+	}
+	return "url:" + code
+}
+
+// invalidate drops the cached redirect for code so the next request reads the DB.
+// Every write that changes how a link redirects must call it.
+func (s *URLService) invalidate(ctx context.Context, code string) {
+	s.cache.del(ctx, urlCacheKey(ctx, code))
+}
+
+// --- Admin Management ---
+
+// URLDetails is the API representation of a short URL.
+type URLDetails struct {
+	ID           int32             `json:"id"`
+	ShortCode    string            `json:"short_code"`
+	Domain       string            `json:"domain"`    // "" for the default domain
+	ShortURL     string            `json:"short_url"` // the public link on its own domain
+	OriginalURL  string            `json:"original_url"`
+	IsCustom     bool              `json:"is_custom"`
+	Disabled     bool              `json:"disabled"`
+	ExpiresAt    *time.Time        `json:"expires_at"`
+	MaxClicks    *int32            `json:"max_clicks"`
+	ClickCount   uint32            `json:"click_count"`
+	OwnerID      *string           `json:"owner_id"`
+	Protected    bool              `json:"password_protected"`
+	RedirectMode string            `json:"redirect_mode"`
+	ForwardQuery bool              `json:"forward_query"`
+	UTM          map[string]string `json:"utm,omitempty"` // utm_* tags of the destination, without the prefix
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+func (s *URLService) newURLDetails(u db.Url) URLDetails {
+	d := URLDetails{
+		ID:           u.ID,
+		ShortCode:    u.ShortCode,
+		Domain:       u.Domain,
+		ShortURL:     ShortURL(s.BaseURL, u.Domain, u.ShortCode),
+		OriginalURL:  u.OriginalUrl,
+		IsCustom:     u.IsCustom,
+		Disabled:     u.Disabled,
+		Protected:    u.PasswordHash.Valid,
+		RedirectMode: string(u.RedirectMode),
+		ForwardQuery: u.ForwardQuery,
+		UTM:          utmTags(u.OriginalUrl),
+		ClickCount:   u.ClickCount,
+		CreatedAt:    u.CreatedAt,
+		UpdatedAt:    u.UpdatedAt,
+	}
+	if u.ExpiresAt.Valid {
+		d.ExpiresAt = &u.ExpiresAt.Time
+	}
+	if u.MaxClicks.Valid {
+		d.MaxClicks = &u.MaxClicks.Int32
+	}
+	if u.UserID.Valid {
+		d.OwnerID = &u.UserID.String
 	}
+	return d
+}
+
+// URLPage is one page of a URL listing.
+type URLPage struct {
+	Items []URLDetails `json:"items"`
+	Total int64        `json:"total"`
+	Page  int          `json:"page"`
+	Limit int          `json:"limit"`
+}
+
+// ListURLs returns a page of URLs, newest first, optionally filtered by a
+// substring of the short code or destination.
+func (s *URLService) ListURLs(ctx context.Context, search string, page, limit int) (*URLPage, error) {
+	offset := int32((page - 1) * limit)
 
-	// 2. Cache miss → query DB
-	url, err := s.q.GetURL(ctx, code)
+	var (
+		rows  []db.Url
+		total int64
+		err   error
+	)
+	if search == "" {
+		rows, err = s.q.ListURLs(ctx, db.ListURLsParams{Limit: int32(limit), Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		total, err = s.q.CountURLs(ctx)
+	} else {
+		pattern := "%" + escapeLike(search) + "%"
+		rows, err = s.q.SearchURLs(ctx, db.SearchURLsParams{
+			ShortCode:   pattern,
+			OriginalUrl: pattern,
+			Limit:       int32(limit),
+			Offset:      offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+		total, err = s.q.CountSearchURLs(ctx, db.CountSearchURLsParams{ShortCode: pattern, OriginalUrl: pattern})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]URLDetails, len(rows))
+	for i, u := range rows {
+		items[i] = s.newURLDetails(u)
+	}
+	return &URLPage{Items: items, Total: total, Page: page, Limit: limit}, nil
+}
+
+// ListUserURLs returns a page of the URLs owned by userID, newest first.
+func (s *URLService) ListUserURLs(ctx context.Context, userID string, page, limit int) (*URLPage, error) {
+	owner := nullString(userID)
+	rows, err := s.q.ListURLsByUser(ctx, db.ListURLsByUserParams{
+		UserID: owner,
+		Limit:  int32(limit),
+		Offset: int32((page - 1) * limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.q.CountURLsByUser(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]URLDetails, len(rows))
+	for i, u := range rows {
+		items[i] = s.newURLDetails(u)
+	}
+	return &URLPage{Items: items, Total: total, Page: page, Limit: limit}, nil
+}
+
+// CheckOwner returns sql.ErrNoRows unless code exists and belongs to userID,
+// so other users' links are indistinguishable from missing ones.
+func (s *URLService) CheckOwner(ctx context.Context, code, userID string) error {
+	u, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return err
+	}
+	if !u.UserID.Valid || u.UserID.String != userID {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetURLDetails returns a single URL by short code.
+func (s *URLService) GetURLDetails(ctx context.Context, code string) (*URLDetails, error) {
+	u, err := getURL(ctx, s.q, code)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	d := s.newURLDetails(u)
+	return &d, nil
+}
 
-	// 3. Store in Redis with TTL (ignore cache write errors)
-	if s.rdb != nil {
-		s.rdb.Set(ctx, "url:"+code, url.OriginalUrl, urlCacheTTL)
+// UpdateDestination points an existing short code at a new URL. The health record
+// of the old destination is dropped, so a fixed link stops redirecting to the
+// fallback at once; the checker picks the link up first on its next run.
+func (s *URLService) UpdateDestination(ctx context.Context, code, newURL string) (*URLDetails, error) {
+	if err := ValidateDestination(newURL); err != nil {
+		return nil, err
+	}
+	if err := s.checkBlocked(newURL); err != nil {
+		return nil, err
+	}
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
 	}
 
-	return url.OriginalUrl, nil
+	err = s.q.UpdateURLDestination(ctx, db.UpdateURLDestinationParams{
+		OriginalUrl: newURL,
+		UrlHash:     hashURL(newURL),
+		ID:          url.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.q.DeleteURLHealth(ctx, url.ID); err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, code)
+	s.Previews.Enqueue(url.ID, newURL)
+
+	return s.GetURLDetails(ctx, code)
 }
 
-func generateShortCode() (string, error) {
-	b := make([]byte, 4)
-	_, err := rand.Read(b)
+// SetRedirectMode changes how visitors are sent to the destination.
+func (s *URLService) SetRedirectMode(ctx context.Context, code, mode string) (*URLDetails, error) {
+	if err := ValidateRedirectMode(mode); err != nil {
+		return nil, err
+	}
+	url, err := getURL(ctx, s.q, code)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return base64.URLEncoding.EncodeToString(b)[:6], nil
+
+	err = s.q.SetURLRedirectMode(ctx, db.SetURLRedirectModeParams{
+		RedirectMode: db.UrlsRedirectMode(mode),
+		ID:           url.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, code)
+
+	return s.GetURLDetails(ctx, code)
+}
+
+// SetForwardQuery turns query-string passthrough on or off for a short code.
+func (s *URLService) SetForwardQuery(ctx context.Context, code string, forward bool) (*URLDetails, error) {
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.q.SetURLForwardQuery(ctx, db.SetURLForwardQueryParams{ForwardQuery: forward, ID: url.ID})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, code)
+
+	return s.GetURLDetails(ctx, code)
+}
+
+// SetDisabled enables or disables redirects for a short code without deleting it.
+func (s *URLService) SetDisabled(ctx context.Context, code string, disabled bool) (*URLDetails, error) {
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.q.SetURLDisabled(ctx, db.SetURLDisabledParams{Disabled: disabled, ID: url.ID})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, code)
+
+	return s.GetURLDetails(ctx, code)
+}
+
+// DeleteURL permanently removes a short code and its click history.
+func (s *URLService) DeleteURL(ctx context.Context, code string) error {
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return err
+	}
+	if err := s.q.DeleteURL(ctx, url.ID); err != nil {
+		return err
+	}
+	s.invalidate(ctx, code)
+	return nil
+}
+
+// getURL looks up code on the domain of ctx (see WithDomain).
+func getURL(ctx context.Context, q *db.Queries, code string) (db.Url, error) {
+	return q.GetURL(ctx, db.GetURLParams{Domain: DomainFrom(ctx), ShortCode: code})
+}
+
+func hashURL(rawURL string) string {
+	hash := sha256.Sum256([]byte(rawURL))
+	return hex.EncodeToString(hash[:])
+}
+
+// nullString maps "" to SQL NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// escapeLike escapes LIKE wildcards so search input is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// isDuplicateKey reports whether err is a MySQL unique constraint violation.
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
 }
 
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"regexp"
+	"strings"
+
+	"go-shortener-sqlc/internal/db"
+)
+
+const (
+	maxVariants      = 10
+	maxVariantWeight = 10000
+)
+
+var (
+	ErrTooManyVariants = errors.New("at most 10 variants are allowed")
+	ErrVariantLabel    = errors.New("variant labels must be 1-32 characters (letters, digits, '-' or '_') and unique per link")
+	ErrVariantWeight   = errors.New("variant weights must be 0-10000 and at least one must be above zero")
+)
+
+var variantLabelPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Variant is one weighted destination of an A/B split or rotation link.
+// The label identifies it in click stats and in the sticky cookie.
+type Variant struct {
+	Label  string `json:"label"`
+	URL    string `json:"url"`
+	Weight uint32 `json:"weight"`
+}
+
+// VariantSet is the full list of destinations of a link.
+type VariantSet struct {
+	Sticky   bool      `json:"sticky"` // keep returning visitors on the variant they were first served
+	Variants []Variant `json:"variants"`
+}
+
+// normalizeVariants validates the variants and fills in missing labels ("A", "B", ...).
+func normalizeVariants(variants []Variant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	if len(variants) > maxVariants {
+		return ErrTooManyVariants
+	}
+
+	seen := make(map[string]bool, len(variants))
+	var total uint32
+	for i := range variants {
+		v := &variants[i]
+		if err := ValidateDestination(v.URL); err != nil {
+			return err
+		}
+		v.Label = strings.TrimSpace(v.Label)
+		if v.Label == "" {
+			v.Label = string(rune('A' + i))
+		}
+		if !variantLabelPattern.MatchString(v.Label) || seen[strings.ToLower(v.Label)] {
+			return ErrVariantLabel
+		}
+		seen[strings.ToLower(v.Label)] = true
+		if v.Weight > maxVariantWeight {
+			return ErrVariantWeight
+		}
+		total += v.Weight
+	}
+	if total == 0 {
+		return ErrVariantWeight
+	}
+	return nil
+}
+
+// pickVariant chooses a variant at random in proportion to its weight. A preferred
+// label (from the sticky cookie) wins as long as that variant is still active.
+func pickVariant(variants []Variant, preferred string) Variant {
+	var total uint32
+	for _, v := range variants {
+		if preferred != "" && v.Weight > 0 && v.Label == preferred {
+			return v
+		}
+		total += v.Weight
+	}
+
+	n := rand.Uint32N(total)
+	for _, v := range variants {
+		if n < v.Weight {
+			return v
+		}
+		n -= v.Weight
+	}
+	return variants[len(variants)-1]
+}
+
+// loadVariants returns the variants of a URL in position order.
+func (s *URLService) loadVariants(ctx context.Context, urlID int32) ([]Variant, error) {
+	rows, err := s.q.ListURLVariants(ctx, urlID)
+	if err != nil {
+		return nil, err
+	}
+	variants := make([]Variant, len(rows))
+	for i, row := range rows {
+		variants[i] = Variant{Label: row.Label, URL: row.Destination, Weight: row.Weight}
+	}
+	return variants, nil
+}
+
+// replaceVariants swaps the stored variants of a URL for set. Run it inside a transaction.
+func replaceVariants(ctx context.Context, q *db.Queries, urlID int32, set VariantSet) error {
+	if err := q.DeleteURLVariants(ctx, urlID); err != nil {
+		return err
+	}
+	for i, v := range set.Variants {
+		err := q.CreateURLVariant(ctx, db.CreateURLVariantParams{
+			UrlID:       urlID,
+			Position:    int32(i),
+			Label:       v.Label,
+			Destination: v.URL,
+			Weight:      v.Weight,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return q.SetURLSticky(ctx, db.SetURLStickyParams{Sticky: set.Sticky && len(set.Variants) > 0, ID: urlID})
+}
+
+// GetVariants returns the destination variants of a short code.
+func (s *URLService) GetVariants(ctx context.Context, code string) (*VariantSet, error) {
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
+	}
+	variants, err := s.loadVariants(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &VariantSet{Sticky: url.Sticky, Variants: variants}, nil
+}
+
+// SetVariants replaces the destination variants of a short code. An empty list
+// turns rotation off so original_url is used again.
+func (s *URLService) SetVariants(ctx context.Context, code string, set VariantSet) (*VariantSet, error) {
+	if err := normalizeVariants(set.Variants); err != nil {
+		return nil, err
+	}
+	for _, v := range set.Variants {
+		if err := s.checkBlocked(v.URL); err != nil {
+			return nil, err
+		}
+	}
+	url, err := getURL(ctx, s.q, code)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := replaceVariants(ctx, s.q.WithTx(tx), url.ID, set); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, code)
+
+	if set.Variants == nil {
+		set.Variants = []Variant{}
+	}
+	set.Sticky = set.Sticky && len(set.Variants) > 0
+	return &set, nil
+}
+
+// createURLWithVariants inserts the row and its variants in one transaction.
+func (s *URLService) createURLWithVariants(ctx context.Context, arg db.CreateURLParams, set VariantSet) (sql.Result, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	qtx := s.q.WithTx(tx)
+	result, err := qtx.CreateURL(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := replaceVariants(ctx, qtx, int32(id), set); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
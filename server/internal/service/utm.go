@@ -0,0 +1,90 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+const maxUTMLength = 200
+
+var ErrInvalidUTM = errors.New("utm fields must be at most 200 characters")
+
+// UTM holds the campaign tags Shorten merges into the destination URL.
+type UTM struct {
+	Source   string
+	Medium   string
+	Campaign string
+	Term     string
+	Content  string
+}
+
+// values returns the non-empty tags as utm_* query parameters.
+func (u UTM) values() url.Values {
+	v := url.Values{}
+	for key, val := range map[string]string{
+		"utm_source":   u.Source,
+		"utm_medium":   u.Medium,
+		"utm_campaign": u.Campaign,
+		"utm_term":     u.Term,
+		"utm_content":  u.Content,
+	} {
+		if val = strings.TrimSpace(val); val != "" {
+			v.Set(key, val)
+		}
+	}
+	return v
+}
+
+func (u UTM) validate() error {
+	for _, val := range []string{u.Source, u.Medium, u.Campaign, u.Term, u.Content} {
+		if len(val) > maxUTMLength {
+			return ErrInvalidUTM
+		}
+	}
+	return nil
+}
+
+// MergeQuery adds params to the query string of dest. Parameters already in dest
+// with the same name are replaced; everything else in dest is kept as written.
+func MergeQuery(dest string, params url.Values) string {
+	if len(params) == 0 {
+		return dest
+	}
+	u, err := url.Parse(dest)
+	if err != nil {
+		return dest
+	}
+
+	var kept []string
+	if u.RawQuery != "" {
+		for _, pair := range strings.Split(u.RawQuery, "&") {
+			key, _, _ := strings.Cut(pair, "=")
+			if name, err := url.QueryUnescape(key); err == nil && params.Has(name) {
+				continue
+			}
+			kept = append(kept, pair)
+		}
+	}
+	u.RawQuery = strings.Join(append(kept, params.Encode()), "&")
+	return u.String()
+}
+
+// utmTags returns the utm_* parameters of a destination, so links to the same page
+// with different campaign tags can be told apart in listings.
+func utmTags(dest string) map[string]string {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return nil
+	}
+	var tags map[string]string
+	for key, vals := range u.Query() {
+		if strings.HasPrefix(key, "utm_") && len(vals) > 0 {
+			if tags == nil {
+				tags = make(map[string]string)
+			}
+			tags[strings.TrimPrefix(key, "utm_")] = vals[0]
+		}
+	}
+	return tags
+}
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MaxGradientStops limits the colors of a gradient.
+const MaxGradientStops = 16
+
+var ErrInvalidGradient = errors.New("invalid gradient stops")
+
+// GradientStop is a color at Offset (0-1) along a gradient.
+type GradientStop struct {
+	Offset float64
+	Color  color.NRGBA
+}
+
+// Gradient is a linear or radial gradient over a box. Stop offsets never decrease,
+// the first is 0 and the last 1.
+type Gradient struct {
+	Stops  []GradientStop
+	Radial bool
+	// Angle is the direction of a linear gradient in degrees as in CSS: 0 points up,
+	// 90 right and 180 down.
+	Angle float64
+	// CenterX and CenterY place the center of a radial gradient as a share of the box.
+	CenterX, CenterY float64
+}
+
+// ParseGradientStops parses comma-separated hex colors, each optionally followed by a
+// position in percent ("#F00, #0F0 30%, #00F"). As in CSS, missing positions are spread
+// evenly between their neighbors and a position never goes back before an earlier one.
+func ParseGradientStops(s string) ([]GradientStop, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) < 2 || len(parts) > MaxGradientStops {
+		return nil, ErrInvalidGradient
+	}
+
+	stops := make([]GradientStop, len(parts))
+	set := make([]bool, len(parts))
+	for i, part := range parts {
+		fields := strings.Fields(part)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, ErrInvalidGradient
+		}
+		c, err := ParseHexColor(fields[0])
+		if err != nil {
+			return nil, ErrInvalidGradient
+		}
+		stops[i].Color = c
+		if len(fields) == 2 {
+			pct, ok := strings.CutSuffix(fields[1], "%")
+			v, err := strconv.ParseFloat(pct, 64)
+			if !ok || err != nil || v < 0 || v > 100 {
+				return nil, ErrInvalidGradient
+			}
+			stops[i].Offset, set[i] = v/100, true
+		}
+	}
+
+	last := len(stops) - 1
+	if !set[0] {
+		stops[0].Offset, set[0] = 0, true
+	}
+	if !set[last] {
+		stops[last].Offset, set[last] = 1, true
+	}
+	for i := 1; i <= last; i++ {
+		if set[i] {
+			stops[i].Offset = max(stops[i].Offset, stops[i-1].Offset)
+			continue
+		}
+		j := i
+		for !set[j] {
+			j++
+		}
+		from, to := stops[i-1].Offset, max(stops[j].Offset, stops[i-1].Offset)
+		for k := i; k < j; k++ {
+			stops[k].Offset = from + (to-from)*float64(k-i+1)/float64(j-i+1)
+		}
+		i = j - 1
+	}
+
+	// Pad with the end colors so the stops cover 0 to 1
+	if stops[0].Offset > 0 {
+		stops = append([]GradientStop{{0, stops[0].Color}}, stops...)
+	}
+	if end := stops[len(stops)-1]; end.Offset < 1 {
+		stops = append(stops, GradientStop{1, end.Color})
+	}
+	return stops, nil
+}
+
+// Line returns the start and end of a linear gradient over a w x h box. As in CSS, the
+// line runs through the center and is long enough for the corners to get the end colors.
+func (g Gradient) Line(w, h float64) (x0, y0, x1, y1 float64) {
+	sin, cos := math.Sincos(g.Angle * math.Pi / 180)
+	half := (math.Abs(w*sin) + math.Abs(h*cos)) / 2
+	dx, dy := sin*half, -cos*half
+	return w/2 - dx, h/2 - dy, w/2 + dx, h/2 + dy
+}
+
+// Circle returns the center and radius of a radial gradient over a w x h box. The
+// radius reaches the farthest corner.
+func (g Gradient) Circle(w, h float64) (cx, cy, r float64) {
+	cx, cy = g.CenterX*w, g.CenterY*h
+	r = max(math.Hypot(cx, cy), math.Hypot(w-cx, cy), math.Hypot(cx, h-cy), math.Hypot(w-cx, h-cy))
+	return cx, cy, r
+}
+
+// ColorAt returns the color at offset t, clamped to 0-1. Colors are interpolated
+// without premultiplied alpha, as SVG and PDF do.
+func (g Gradient) ColorAt(t float64) color.NRGBA {
+	stops := g.Stops
+	if t <= stops[0].Offset {
+		return stops[0].Color
+	}
+	for i := 1; i < len(stops); i++ {
+		a, b := stops[i-1], stops[i]
+		if t > b.Offset {
+			continue
+		}
+		f := (t - a.Offset) / (b.Offset - a.Offset)
+		mix := func(x, y uint8) uint8 {
+			return uint8(math.Round(float64(x)*(1-f) + float64(y)*f))
+		}
+		return color.NRGBA{mix(a.Color.R, b.Color.R), mix(a.Color.G, b.Color.G), mix(a.Color.B, b.Color.B), mix(a.Color.A, b.Color.A)}
+	}
+	return stops[len(stops)-1].Color
+}
+
+// GenerateGradient renders g over a w x h image.
+func GenerateGradient(w, h int, g Gradient) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	fw, fh := float64(w), float64(h)
+
+	// offset maps a pixel center to its position along the gradient
+	var offset func(x, y float64) float64
+	if g.Radial {
+		cx, cy, r := g.Circle(fw, fh)
+		offset = func(x, y float64) float64 {
+			if r == 0 {
+				return 1
+			}
+			return math.Hypot(x-cx, y-cy) / r
+		}
+	} else {
+		x0, y0, x1, y1 := g.Line(fw, fh)
+		dx, dy := x1-x0, y1-y0
+		l2 := dx*dx + dy*dy
+		offset = func(x, y float64) float64 {
+			return ((x-x0)*dx + (y-y0)*dy) / l2
+		}
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, g.ColorAt(offset(float64(x)+0.5, float64(y)+0.5)))
+		}
+	}
+	return img
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected color.NRGBA
+		valid    bool
+	}{
+		{"#1A2B3C", color.NRGBA{0x1a, 0x2b, 0x3c, 0xff}, true},
+		{"f00", color.NRGBA{0xff, 0, 0, 0xff}, true},
+		{"#1A2B3C80", color.NRGBA{0x1a, 0x2b, 0x3c, 0x80}, true},
+		{"#f008", color.NRGBA{0xff, 0, 0, 0x88}, true},
+		{"#12345", color.NRGBA{}, false},
+		{"#GGGGGG", color.NRGBA{}, false},
+	}
+	for _, tc := range tests {
+		got, err := ParseHexColor(tc.in)
+		if (err == nil) != tc.valid || (tc.valid && got != tc.expected) {
+			t.Errorf("ParseHexColor(%q) = %v, %v", tc.in, got, err)
+		}
+	}
+}
+
+func TestParseGradientStops(t *testing.T) {
+	tests := []struct {
+		in      string
+		offsets []float64
+	}{
+		{"#f00, #00f", []float64{0, 1}},
+		{"#f00, #0f0, #00f, #fff", []float64{0, 1.0 / 3, 2.0 / 3, 1}},
+		{"#f00, #0f0 80%, #00f", []float64{0, 0.8, 1}},
+		// Positions are padded out to 0 and 1 and never go back
+		{"#f00 20%, #0f0 10%, #00f 60%", []float64{0, 0.2, 0.2, 0.6, 1}},
+		{"#f00, #0f0 50%, #00f, #000, #fff 80%", []float64{0, 0.5, 0.6, 0.7, 0.8, 1}},
+	}
+	for _, tc := range tests {
+		stops, err := ParseGradientStops(tc.in)
+		if err != nil {
+			t.Fatalf("ParseGradientStops(%q): %v", tc.in, err)
+		}
+		if len(stops) != len(tc.offsets) {
+			t.Fatalf("ParseGradientStops(%q) = %v, want offsets %v", tc.in, stops, tc.offsets)
+		}
+		for i, s := range stops {
+			if math.Abs(s.Offset-tc.offsets[i]) > 1e-9 {
+				t.Errorf("ParseGradientStops(%q) offset %d = %v, want %v", tc.in, i, s.Offset, tc.offsets[i])
+			}
+		}
+	}
+
+	for _, bad := range []string{"", "#f00", "#f00, blue", "#f00, #00f 120%", "#f00, #00f 50", "#f00,, #00f"} {
+		if _, err := ParseGradientStops(bad); err != ErrInvalidGradient {
+			t.Errorf("ParseGradientStops(%q): err = %v, want ErrInvalidGradient", bad, err)
+		}
+	}
+}
+
+func TestGenerateGradient(t *testing.T) {
+	stops, _ := ParseGradientStops("#ff0000, #0000ff00")
+
+	// 90 degrees runs left to right
+	img := GenerateGradient(100, 10, Gradient{Stops: stops, Angle: 90})
+	if c := img.NRGBAAt(0, 5); c.R < 0xf0 || c.A < 0xf0 {
+		t.Errorf("left edge = %v, want opaque red", c)
+	}
+	if c := img.NRGBAAt(99, 5); c.B < 0xf0 || c.A > 0x10 {
+		t.Errorf("right edge = %v, want transparent blue", c)
+	}
+
+	// A radial gradient is the first color at its center and the last at the farthest corner
+	img = GenerateGradient(100, 100, Gradient{Stops: stops, Radial: true, CenterX: 0.25, CenterY: 0.25})
+	if c := img.NRGBAAt(25, 25); c.R < 0xf0 {
+		t.Errorf("center = %v, want red", c)
+	}
+	if c := img.NRGBAAt(99, 99); c.B < 0xf0 {
+		t.Errorf("far corner = %v, want blue", c)
+	}
+
+	// Corners get the end colors at any angle
+	x0, y0, x1, y1 := Gradient{Angle: 45}.Line(100, 100)
+	if math.Abs(x0) > 1e-9 || math.Abs(y0-100) > 1e-9 || math.Abs(x1-100) > 1e-9 || math.Abs(y1) > 1e-9 {
+		t.Errorf("45 degree line = (%v, %v) to (%v, %v), want bottom left to top right", x0, y0, x1, y1)
+	}
+}
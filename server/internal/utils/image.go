@@ -8,6 +8,7 @@ import (
 	"image/draw"
 	_ "image/jpeg"
 	_ "image/png"
+	"strings"
 
 	xdraw "golang.org/x/image/draw"
 )
@@ -99,103 +100,31 @@ func isInRoundedRect(x, y, w, h int, r float64) bool {
     return true
 }
 
-// GenerateLinearGradient creates a linear gradient image from startColor to endColor.
-func GenerateLinearGradient(w, h int, startHex, endHex string) (image.Image, error) {
-	startColor, err := ParseHexColor(startHex)
-	if err != nil {
-		return nil, err
-	}
-	endColor, err := ParseHexColor(endHex)
-	if err != nil {
-		return nil, err
-	}
-
-	img := image.NewRGBA(image.Rect(0, 0, w, h))
-
-	r1, g1, b1, _ := startColor.RGBA()
-	r2, g2, b2, _ := endColor.RGBA()
-
-	for y := 0; y < h; y++ {
-		// Calculate interpolation factor (0.0 to 1.0)
-		t := float64(y) / float64(h)
+// ParseHexColor parses #RGB, #RGBA, #RRGGBB or #RRGGBBAA; colors without alpha are opaque.
+func ParseHexColor(s string) (color.NRGBA, error) {
+	c := color.NRGBA{A: 255}
+	s = strings.TrimPrefix(s, "#")
 
-		// Interpolate
-		r := uint8((float64(r1)*(1-t) + float64(r2)*t) / 257)
-		g := uint8((float64(g1)*(1-t) + float64(g2)*t) / 257)
-		b := uint8((float64(b1)*(1-t) + float64(b2)*t) / 257)
-
-		col := color.RGBA{R: r, G: g, B: b, A: 255}
-
-		for x := 0; x < w; x++ {
-			img.Set(x, y, col)
+	// Expand #RGB and #RGBA
+	if len(s) == 3 || len(s) == 4 {
+		long := make([]byte, 0, 2*len(s))
+		for i := 0; i < len(s); i++ {
+			long = append(long, s[i], s[i])
 		}
+		s = string(long)
 	}
-	return img, nil
-}
 
-// ApplyGradientMask applies the gradient to the QR code's foreground (black pixels).
-func ApplyGradientMask(src image.Image, gradient image.Image, bgColorHex string) (image.Image, error) {
-	bounds := src.Bounds()
-	w, h := bounds.Dx(), bounds.Dy()
-	
-	// Resize gradient to match QR code size if needed
-	if gradient.Bounds().Dx() != w || gradient.Bounds().Dy() != h {
-		gradient = ResizeImage(gradient, w)
+	if len(s) != 6 && len(s) != 8 {
+		return c, ErrInvalidHex
 	}
 
-	bgColor, err := ParseHexColor(bgColorHex)
+	b, err := hex.DecodeString(s)
 	if err != nil {
-		return nil, err
-	}
-
-	out := image.NewRGBA(bounds)
-
-	for y := 0; y < h; y++ {
-		for x := 0; x < w; x++ {
-			originalColor := src.At(x, y)
-			r, g, b, _ := originalColor.RGBA()
-			// Improve darkness check: luminance or just sum. 
-			// 0xFFFF is max for 16-bit color chanel.
-			// Black is 0, 0, 0. White is FFFF, FFFF, FFFF.
-			isForeground := (r + g + b) < (3 * 0x8000) // Simple < 50% brightness check
-
-			if isForeground {
-				// Take color from gradient
-				out.Set(x, y, gradient.At(x, y))
-			} else {
-				// Set background color
-				out.Set(x, y, bgColor)
-			}
-		}
-	}
-
-	return out, nil
-}
-
-// ParseHexColor parses a hex string (#RRGGBB) to color.RGBA
-func ParseHexColor(s string) (color.RGBA, error) {
-	c := color.RGBA{A: 255}
-	if len(s) > 0 && s[0] == '#' {
-		s = s[1:]
-	}
-	
-	// Handle #RGB
-	if len(s) == 3 {
-		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
-	}
-
-	if len(s) != 6 {
 		return c, ErrInvalidHex
 	}
-
-	// hex.DecodeString is easier
-    b, err := hex.DecodeString(s)
-    if err != nil {
-        return c, err
-    }
-    c.R = b[0]
-    c.G = b[1]
-    c.B = b[2]
-
+	c.R, c.G, c.B = b[0], b[1], b[2]
+	if len(b) == 4 {
+		c.A = b[3]
+	}
 	return c, nil
 }
@@ -0,0 +1,102 @@
+package utils
+
+import "strings"
+
+// Device classes reported by ParseUserAgent.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown"
+)
+
+// UserAgentInfo is the coarse classification of a User-Agent header.
+type UserAgentInfo struct {
+	Browser string
+	OS      string
+	Device  string
+}
+
+var botMarkers = []string{"bot", "crawler", "spider", "slurp", "curl/", "wget/", "python-requests", "go-http-client", "headless"}
+
+// ParseUserAgent classifies a User-Agent string into browser, OS and device class.
+// It only checks well-known tokens; anything else is reported as "Other".
+func ParseUserAgent(ua string) UserAgentInfo {
+	if ua == "" {
+		return UserAgentInfo{Browser: "Other", OS: "Other", Device: DeviceUnknown}
+	}
+	l := strings.ToLower(ua)
+
+	info := UserAgentInfo{
+		Browser: parseBrowser(l),
+		OS:      parseOS(l),
+	}
+
+	switch {
+	case containsAny(l, botMarkers...):
+		info.Device = DeviceBot
+	case strings.Contains(l, "ipad") || strings.Contains(l, "tablet") ||
+		(strings.Contains(l, "android") && !strings.Contains(l, "mobile")):
+		info.Device = DeviceTablet
+	case strings.Contains(l, "mobi") || strings.Contains(l, "iphone") || strings.Contains(l, "ipod"):
+		info.Device = DeviceMobile
+	case info.OS != "Other":
+		info.Device = DeviceDesktop
+	default:
+		info.Device = DeviceUnknown
+	}
+	return info
+}
+
+// parseBrowser checks tokens in order: several browsers also advertise "Chrome" or "Safari".
+func parseBrowser(l string) string {
+	switch {
+	case strings.Contains(l, "edg/") || strings.Contains(l, "edge/"):
+		return "Edge"
+	case strings.Contains(l, "opr/") || strings.Contains(l, "opera"):
+		return "Opera"
+	case strings.Contains(l, "samsungbrowser"):
+		return "Samsung Internet"
+	case strings.Contains(l, "firefox/") || strings.Contains(l, "fxios/"):
+		return "Firefox"
+	case strings.Contains(l, "chrome/") || strings.Contains(l, "crios/"):
+		return "Chrome"
+	case strings.Contains(l, "safari/"):
+		return "Safari"
+	case strings.Contains(l, "msie") || strings.Contains(l, "trident/"):
+		return "Internet Explorer"
+	case containsAny(l, botMarkers...):
+		return "Bot"
+	default:
+		return "Other"
+	}
+}
+
+func parseOS(l string) string {
+	switch {
+	case strings.Contains(l, "iphone") || strings.Contains(l, "ipad") || strings.Contains(l, "ipod"):
+		return "iOS"
+	case strings.Contains(l, "android"):
+		return "Android"
+	case strings.Contains(l, "windows"):
+		return "Windows"
+	case strings.Contains(l, "mac os x") || strings.Contains(l, "macintosh"):
+		return "macOS"
+	case strings.Contains(l, "cros"):
+		return "ChromeOS"
+	case strings.Contains(l, "linux"):
+		return "Linux"
+	default:
+		return "Other"
+	}
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
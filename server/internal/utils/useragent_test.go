@@ -0,0 +1,61 @@
+package utils
+
+import "testing"
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		name     string
+		ua       string
+		expected UserAgentInfo
+	}{
+		{
+			name:     "Chrome on Windows",
+			ua:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected: UserAgentInfo{Browser: "Chrome", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			name:     "Edge on Windows",
+			ua:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
+			expected: UserAgentInfo{Browser: "Edge", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			name:     "Safari on iPhone",
+			ua:       "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+			expected: UserAgentInfo{Browser: "Safari", OS: "iOS", Device: DeviceMobile},
+		},
+		{
+			name:     "Safari on iPad",
+			ua:       "Mozilla/5.0 (iPad; CPU OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+			expected: UserAgentInfo{Browser: "Safari", OS: "iOS", Device: DeviceTablet},
+		},
+		{
+			name:     "Chrome on Android phone",
+			ua:       "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+			expected: UserAgentInfo{Browser: "Chrome", OS: "Android", Device: DeviceMobile},
+		},
+		{
+			name:     "Firefox on macOS",
+			ua:       "Mozilla/5.0 (Macintosh; Intel Mac OS X 14.0; rv:121.0) Gecko/20100101 Firefox/121.0",
+			expected: UserAgentInfo{Browser: "Firefox", OS: "macOS", Device: DeviceDesktop},
+		},
+		{
+			name:     "Googlebot",
+			ua:       "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			expected: UserAgentInfo{Browser: "Bot", OS: "Other", Device: DeviceBot},
+		},
+		{
+			name:     "Empty",
+			ua:       "",
+			expected: UserAgentInfo{Browser: "Other", OS: "Other", Device: DeviceUnknown},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ParseUserAgent(tc.ua)
+			if got != tc.expected {
+				t.Errorf("ParseUserAgent() = %+v, want %+v", got, tc.expected)
+			}
+		})
+	}
+}
@@ -1,17 +1,347 @@
 -- name: CreateURL :execresult
 INSERT INTO urls (
-  short_code, original_url, url_hash
+  short_code, original_url, url_hash, is_custom, expires_at, max_clicks, user_id, password_hash,
+  redirect_mode, forward_query, domain
 ) VALUES (
-  ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 );
 
 -- name: GetURL :one
 SELECT * FROM urls
-WHERE short_code = ? LIMIT 1;
+WHERE domain = ? AND short_code = ? LIMIT 1;
 
 -- name: GetURLByHash :one
 SELECT * FROM urls
-WHERE url_hash = ? LIMIT 1;
+WHERE url_hash = ? AND user_id <=> ? AND domain = ? AND is_custom = FALSE AND disabled = FALSE
+  AND expires_at IS NULL AND max_clicks IS NULL AND password_hash IS NULL
+  AND redirect_mode = '302' AND forward_query = FALSE
+  AND NOT EXISTS (SELECT 1 FROM url_variants v WHERE v.url_id = urls.id)
+  AND NOT EXISTS (SELECT 1 FROM url_rules r WHERE r.url_id = urls.id)
+LIMIT 1;
+
+-- name: IncrementURLClicks :execrows
+UPDATE urls
+SET click_count = click_count + 1
+WHERE id = ? AND (max_clicks IS NULL OR click_count < max_clicks);
+
+-- URL Admin Queries
+
+-- name: ListURLs :many
+SELECT * FROM urls
+ORDER BY created_at DESC
+LIMIT ? OFFSET ?;
+
+-- name: CountURLs :one
+SELECT COUNT(*) FROM urls;
+
+-- name: SearchURLs :many
+SELECT * FROM urls
+WHERE short_code LIKE ? OR original_url LIKE ?
+ORDER BY created_at DESC
+LIMIT ? OFFSET ?;
+
+-- name: CountSearchURLs :one
+SELECT COUNT(*) FROM urls
+WHERE short_code LIKE ? OR original_url LIKE ?;
+
+-- name: ListURLsByUser :many
+SELECT * FROM urls
+WHERE user_id = ?
+ORDER BY created_at DESC
+LIMIT ? OFFSET ?;
+
+-- name: CountURLsByUser :one
+SELECT COUNT(*) FROM urls
+WHERE user_id = ?;
+
+-- name: UpdateURLDestination :exec
+UPDATE urls
+SET original_url = ?, url_hash = ?
+WHERE id = ?;
+
+-- name: SetURLRedirectMode :exec
+UPDATE urls
+SET redirect_mode = ?
+WHERE id = ?;
+
+-- name: SetURLForwardQuery :exec
+UPDATE urls
+SET forward_query = ?
+WHERE id = ?;
+
+-- name: SetURLDisabled :exec
+UPDATE urls
+SET disabled = ?
+WHERE id = ?;
+
+-- name: DeleteURL :exec
+DELETE FROM urls
+WHERE id = ?;
+
+-- Domain Queries
+
+-- name: ListDomains :many
+SELECT * FROM domains
+ORDER BY host;
+
+-- name: GetDomain :one
+SELECT * FROM domains
+WHERE id = ? LIMIT 1;
+
+-- name: CreateDomain :execresult
+INSERT INTO domains (host)
+VALUES (?);
+
+-- name: DeleteDomain :execrows
+DELETE FROM domains
+WHERE id = ?;
+
+-- name: CountURLsByDomain :one
+SELECT COUNT(*) FROM urls
+WHERE domain = ?;
+
+-- Redirect Rule Queries
+
+-- name: ListURLRules :many
+SELECT * FROM url_rules
+WHERE url_id = ?
+ORDER BY position, id;
+
+-- name: GetURLRule :one
+SELECT * FROM url_rules
+WHERE id = ? AND url_id = ? LIMIT 1;
+
+-- name: CreateURLRule :execresult
+INSERT INTO url_rules (
+  url_id, position, destination, os, devices, languages, countries, starts_at, ends_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: UpdateURLRule :exec
+UPDATE url_rules
+SET position = ?, destination = ?, os = ?, devices = ?, languages = ?, countries = ?,
+  starts_at = ?, ends_at = ?
+WHERE id = ? AND url_id = ?;
+
+-- name: DeleteURLRule :execrows
+DELETE FROM url_rules
+WHERE id = ? AND url_id = ?;
+
+-- Destination Variant Queries
+
+-- name: ListURLVariants :many
+SELECT * FROM url_variants
+WHERE url_id = ?
+ORDER BY position, id;
+
+-- name: CreateURLVariant :exec
+INSERT INTO url_variants (url_id, position, label, destination, weight)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: DeleteURLVariants :exec
+DELETE FROM url_variants
+WHERE url_id = ?;
+
+-- name: SetURLSticky :exec
+UPDATE urls
+SET sticky = ?
+WHERE id = ?;
+
+-- Link Preview Queries
+
+-- name: GetURLPreview :one
+SELECT * FROM url_previews
+WHERE url_id = ? LIMIT 1;
+
+-- name: UpsertURLPreview :exec
+INSERT INTO url_previews (
+  url_id, status, title, description, image_url, favicon_url, error, fetched_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?
+)
+ON DUPLICATE KEY UPDATE
+  status = VALUES(status), title = VALUES(title), description = VALUES(description),
+  image_url = VALUES(image_url), favicon_url = VALUES(favicon_url), error = VALUES(error),
+  fetched_at = VALUES(fetched_at);
+
+-- Health Check Queries
+
+-- name: GetURLHealth :one
+SELECT * FROM url_health
+WHERE url_id = ? LIMIT 1;
+
+-- name: DeleteURLHealth :exec
+DELETE FROM url_health
+WHERE url_id = ?;
+
+-- name: ListURLsForHealthCheck :many
+SELECT u.id, u.domain, u.short_code, u.original_url, h.consecutive_failures FROM urls u
+LEFT JOIN url_health h ON h.url_id = u.id
+WHERE u.disabled = FALSE AND (u.expires_at IS NULL OR u.expires_at > NOW())
+  AND (h.last_checked_at IS NULL OR h.last_checked_at < sqlc.arg(checked_before))
+ORDER BY h.last_checked_at, u.id
+LIMIT ?;
+
+-- name: UpsertURLHealth :exec
+INSERT INTO url_health (
+  url_id, status_code, latency_ms, error, consecutive_failures, last_checked_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+)
+ON DUPLICATE KEY UPDATE
+  status_code = VALUES(status_code), latency_ms = VALUES(latency_ms), error = VALUES(error),
+  consecutive_failures = VALUES(consecutive_failures), last_checked_at = VALUES(last_checked_at);
+
+-- name: ListBrokenURLs :many
+SELECT u.domain, u.short_code, u.original_url, h.status_code, h.latency_ms, h.error, h.consecutive_failures, h.last_checked_at
+FROM url_health h
+JOIN urls u ON u.id = h.url_id
+WHERE h.consecutive_failures >= sqlc.arg(min_failures)
+ORDER BY h.consecutive_failures DESC, h.url_id
+LIMIT ? OFFSET ?;
+
+-- name: CountBrokenURLs :one
+SELECT COUNT(*) FROM url_health
+WHERE consecutive_failures >= ?;
+
+-- Short Code Sequence Queries
+
+-- name: AllocateCodeBlock :execresult
+UPDATE code_sequences SET next_value = LAST_INSERT_ID(next_value + sqlc.arg(size))
+WHERE name = ?;
+
+-- Blocklist Queries
+
+-- name: ListBlocklistEntries :many
+SELECT * FROM blocklist_entries
+ORDER BY id;
+
+-- name: GetBlocklistEntry :one
+SELECT * FROM blocklist_entries
+WHERE id = ? LIMIT 1;
+
+-- name: CreateBlocklistEntry :execresult
+INSERT INTO blocklist_entries (kind, pattern, reason)
+VALUES (?, ?, ?);
+
+-- name: UpdateBlocklistEntry :exec
+UPDATE blocklist_entries
+SET kind = ?, pattern = ?, reason = ?
+WHERE id = ?;
+
+-- name: DeleteBlocklistEntry :execrows
+DELETE FROM blocklist_entries
+WHERE id = ?;
+
+-- Link Report Queries
+
+-- name: CreateLinkReport :exec
+INSERT INTO link_reports (url_id, reason, reporter_ip)
+VALUES (?, ?, ?);
+
+-- name: CountOpenLinkReportsByIP :one
+SELECT COUNT(*) FROM link_reports
+WHERE url_id = ? AND reporter_ip = ? AND status = 'open';
+
+-- name: GetLinkReport :one
+SELECT r.id, r.url_id, u.domain, u.short_code, r.status FROM link_reports r
+JOIN urls u ON u.id = r.url_id
+WHERE r.id = ? LIMIT 1;
+
+-- name: ListLinkReports :many
+SELECT r.id, r.url_id, u.domain, u.short_code, u.original_url, u.disabled, r.reason, r.reporter_ip, r.status, r.created_at, r.reviewed_at
+FROM link_reports r
+JOIN urls u ON u.id = r.url_id
+WHERE r.status = ?
+ORDER BY r.created_at, r.id
+LIMIT ? OFFSET ?;
+
+-- name: CountLinkReports :one
+SELECT COUNT(*) FROM link_reports
+WHERE status = ?;
+
+-- name: SetLinkReportStatus :execrows
+UPDATE link_reports
+SET status = ?, reviewed_at = ?
+WHERE id = ?;
+
+-- name: CloseLinkReportsForURL :exec
+UPDATE link_reports
+SET status = ?, reviewed_at = ?
+WHERE url_id = ? AND status = 'open';
+
+-- Click Queries
+
+-- name: CreateClick :exec
+INSERT INTO clicks (
+  url_id, clicked_at, referrer, user_agent, browser, os, device, country, variant
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: CountClicks :one
+SELECT COUNT(*) FROM clicks
+WHERE url_id = ? AND clicked_at >= sqlc.arg(start_time) AND clicked_at < sqlc.arg(end_time);
+
+-- name: ListClicksByHour :many
+SELECT DATE_FORMAT(clicked_at, '%Y-%m-%d %H:00') AS bucket, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= sqlc.arg(start_time) AND clicked_at < sqlc.arg(end_time)
+GROUP BY bucket
+ORDER BY bucket;
+
+-- name: ListClicksByDay :many
+SELECT DATE_FORMAT(clicked_at, '%Y-%m-%d') AS bucket, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= sqlc.arg(start_time) AND clicked_at < sqlc.arg(end_time)
+GROUP BY bucket
+ORDER BY bucket;
+
+-- name: ListClicksByWeek :many
+SELECT DATE_FORMAT(DATE_SUB(DATE(clicked_at), INTERVAL WEEKDAY(clicked_at) DAY), '%Y-%m-%d') AS bucket, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= sqlc.arg(start_time) AND clicked_at < sqlc.arg(end_time)
+GROUP BY bucket
+ORDER BY bucket;
+
+-- name: ListClickBrowsers :many
+SELECT browser, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= sqlc.arg(start_time) AND clicked_at < sqlc.arg(end_time)
+GROUP BY browser
+ORDER BY clicks DESC
+LIMIT 10;
+
+-- name: ListClickOperatingSystems :many
+SELECT os, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= sqlc.arg(start_time) AND clicked_at < sqlc.arg(end_time)
+GROUP BY os
+ORDER BY clicks DESC
+LIMIT 10;
+
+-- name: ListClickDevices :many
+SELECT device, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= sqlc.arg(start_time) AND clicked_at < sqlc.arg(end_time)
+GROUP BY device
+ORDER BY clicks DESC;
+
+-- name: ListClickCountries :many
+SELECT country, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= sqlc.arg(start_time) AND clicked_at < sqlc.arg(end_time)
+GROUP BY country
+ORDER BY clicks DESC
+LIMIT 10;
+
+-- name: ListClickVariants :many
+SELECT variant, COUNT(*) AS clicks
+FROM clicks
+WHERE url_id = ? AND clicked_at >= sqlc.arg(start_time) AND clicked_at < sqlc.arg(end_time)
+GROUP BY variant
+ORDER BY variant;
 
 -- Blog Queries
 
@@ -164,6 +494,36 @@ UPDATE users
 SET password_hash = ?
 WHERE username = ?;
 
+-- API Key Queries
+
+-- name: CreateAPIKey :exec
+INSERT INTO api_keys (
+  id, user_id, name, prefix, key_hash, scopes
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+);
+
+-- name: GetAPIKeyByHash :one
+SELECT k.*, u.role FROM api_keys k
+JOIN users u ON u.id = k.user_id
+WHERE k.key_hash = ? AND k.revoked_at IS NULL
+LIMIT 1;
+
+-- name: ListAPIKeysByUser :many
+SELECT * FROM api_keys
+WHERE user_id = ?
+ORDER BY created_at DESC;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = ?
+WHERE id = ?;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = ? AND user_id = ? AND revoked_at IS NULL;
+
 -- Image Queries
 
 -- name: CreateImage :exec
@@ -186,3 +546,38 @@ WHERE id = ?;
 -- name: DeleteImage :exec
 DELETE FROM images
 WHERE id = ?;
+
+-- QR Template Queries
+
+-- name: ListQRTemplates :many
+SELECT * FROM qr_templates
+ORDER BY name;
+
+-- name: GetQRTemplate :one
+SELECT * FROM qr_templates
+WHERE id = ? LIMIT 1;
+
+-- name: GetQRTemplateByName :one
+SELECT * FROM qr_templates
+WHERE name = ? LIMIT 1;
+
+-- name: CreateQRTemplate :execresult
+INSERT INTO qr_templates (
+  name, fg_color, bg_color, gradient_stops, gradient_type, gradient_angle, gradient_center_x, gradient_center_y,
+  module_shape, finder_outer_shape, finder_inner_shape, finder_outer_color, finder_inner_color,
+  logo_image_id, logo_size, border_radius, error_correction, quiet_zone, size, dpi
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: UpdateQRTemplate :exec
+UPDATE qr_templates
+SET name = ?, fg_color = ?, bg_color = ?, gradient_stops = ?, gradient_type = ?, gradient_angle = ?,
+  gradient_center_x = ?, gradient_center_y = ?, module_shape = ?, finder_outer_shape = ?, finder_inner_shape = ?,
+  finder_outer_color = ?, finder_inner_color = ?, logo_image_id = ?, logo_size = ?, border_radius = ?,
+  error_correction = ?, quiet_zone = ?, size = ?, dpi = ?
+WHERE id = ?;
+
+-- name: DeleteQRTemplate :execrows
+DELETE FROM qr_templates
+WHERE id = ?;
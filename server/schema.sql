@@ -1,11 +1,174 @@
 CREATE TABLE urls (
   id INT AUTO_INCREMENT PRIMARY KEY,
-  short_code VARCHAR(20) NOT NULL UNIQUE,
+  short_code VARCHAR(20) NOT NULL,
   original_url TEXT NOT NULL,
-  url_hash CHAR(64) NOT NULL UNIQUE,
+  url_hash CHAR(64) NOT NULL,
+  is_custom BOOLEAN NOT NULL DEFAULT FALSE,
+  expires_at DATETIME,
+  max_clicks INT UNSIGNED,
+  -- click_count is only maintained for links with max_clicks
+  click_count INT UNSIGNED NOT NULL DEFAULT 0,
+  disabled BOOLEAN NOT NULL DEFAULT FALSE,
+  user_id CHAR(36),
+  password_hash VARCHAR(255), -- bcrypt; NULL for public links
+  -- HTTP status for plain redirects, or an HTML page: 'preview' (confirm first) / 'meta' (meta refresh + JS)
+  redirect_mode ENUM('301', '302', '307', '308', 'preview', 'meta') NOT NULL DEFAULT '302',
+  -- Keep returning visitors on the variant they were first served (cookie)
+  sticky BOOLEAN NOT NULL DEFAULT FALSE,
+  -- Append the short URL's query string (/abc123?utm_source=x) to the destination on redirect
+  forward_query BOOLEAN NOT NULL DEFAULT FALSE,
+  -- Branded host the code is served on (a domains.host); '' is the default BASE_URL host.
+  -- Codes are unique per domain.
+  domain VARCHAR(253) NOT NULL DEFAULT '',
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_urls_domain_code (domain, short_code)
+);
+
+-- url_hash is not unique: a custom alias may point at a URL that already has a random code,
+-- and whether a link may be shared changes after creation (rules, variants, edits). Dedup is a
+-- lookup before the insert, so two concurrent identical shortens can create two links.
+CREATE INDEX idx_urls_hash ON urls (url_hash);
+CREATE INDEX idx_urls_created ON urls (created_at);
+
+-- Branded Domains
+-- Extra hosts (e.g. go.brand.com) pointed at this server. A link picks one at creation;
+-- requests are matched to a domain by their Host header.
+
+CREATE TABLE domains (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  host VARCHAR(253) NOT NULL UNIQUE,
   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Click Analytics
+
+CREATE TABLE clicks (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  url_id INT NOT NULL,
+  clicked_at DATETIME NOT NULL,
+  referrer VARCHAR(2048) NOT NULL DEFAULT '',
+  user_agent VARCHAR(512) NOT NULL DEFAULT '',
+  browser VARCHAR(50) NOT NULL DEFAULT '',
+  os VARCHAR(50) NOT NULL DEFAULT '',
+  device VARCHAR(20) NOT NULL DEFAULT '',
+  country CHAR(2) NOT NULL DEFAULT '',
+  variant VARCHAR(32) NOT NULL DEFAULT '', -- url_variants.label served; '' without variants
+  FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_clicks_url_time ON clicks (url_id, clicked_at);
+
+-- Redirect Rules
+-- Evaluated in (position, id) order; the first rule whose conditions all match wins,
+-- otherwise urls.original_url is used. Condition columns hold comma-separated values
+-- and an empty column matches everything.
+
+CREATE TABLE url_rules (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  url_id INT NOT NULL,
+  position INT NOT NULL DEFAULT 0,
+  destination TEXT NOT NULL,
+  os VARCHAR(255) NOT NULL DEFAULT '',        -- e.g. 'iOS,Android' (utils.ParseUserAgent names)
+  devices VARCHAR(255) NOT NULL DEFAULT '',   -- e.g. 'mobile,tablet'
+  languages VARCHAR(255) NOT NULL DEFAULT '', -- Accept-Language tags, e.g. 'th,en-US'
+  countries VARCHAR(255) NOT NULL DEFAULT '', -- ISO 3166-1 alpha-2, e.g. 'TH,LA'
+  starts_at DATETIME,
+  ends_at DATETIME,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_url_rules_url ON url_rules (url_id, position);
+
+-- Destination Variants
+-- When a link has variants, each visit picks one at random in proportion to weight
+-- (A/B tests, rotation); urls.original_url is only used when there are none.
+
+CREATE TABLE url_variants (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  url_id INT NOT NULL,
+  position INT NOT NULL DEFAULT 0,
+  label VARCHAR(32) NOT NULL,
+  destination TEXT NOT NULL,
+  weight INT UNSIGNED NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_url_variants_label (url_id, label),
+  FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE
+);
+
+-- Link Previews
+-- Metadata of the destination page, fetched in the background after a link is created
+-- or its destination changes.
+
+CREATE TABLE url_previews (
+  url_id INT PRIMARY KEY,
+  status ENUM('pending', 'ok', 'failed') NOT NULL DEFAULT 'pending',
+  title VARCHAR(512) NOT NULL DEFAULT '',
+  description VARCHAR(1024) NOT NULL DEFAULT '',
+  image_url VARCHAR(2048) NOT NULL DEFAULT '',
+  favicon_url VARCHAR(2048) NOT NULL DEFAULT '',
+  error VARCHAR(255) NOT NULL DEFAULT '',
+  fetched_at DATETIME,
+  FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE
+);
+
+-- Destination Health
+-- Latest result of the background health checker per link. consecutive_failures
+-- resets to 0 on the first successful check.
+
+CREATE TABLE url_health (
+  url_id INT PRIMARY KEY,
+  status_code INT,
+  latency_ms INT NOT NULL DEFAULT 0,
+  error VARCHAR(255) NOT NULL DEFAULT '',
+  consecutive_failures INT NOT NULL DEFAULT 0,
+  last_checked_at DATETIME NOT NULL,
+  FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+  INDEX idx_url_health_failures (consecutive_failures),
+  INDEX idx_url_health_checked (last_checked_at)
+);
+
+-- Short Code Sequences
+-- Counters for the sequential code generator. Instances reserve blocks of values with
+-- a single UPDATE, so codes stay unique across instances without per-code lookups.
+
+CREATE TABLE code_sequences (
+  name VARCHAR(50) NOT NULL PRIMARY KEY,
+  next_value BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO code_sequences (name) VALUES ('urls');
+
+-- Blocklist
+-- Destinations that may not be shortened or redirected to. Entries from the local
+-- feed file (BLOCKLIST_FILE) are merged in memory and never stored here.
+
+CREATE TABLE blocklist_entries (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  -- 'host': exact host, 'suffix': the domain and all subdomains, 'regex': RE2 matched against the full URL
+  kind ENUM('host', 'suffix', 'regex') NOT NULL,
+  pattern VARCHAR(512) NOT NULL,
+  reason VARCHAR(255) NOT NULL DEFAULT '',
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_blocklist_pattern (kind, pattern)
+);
+
+-- Abuse reports from the public "report this link" endpoint, reviewed by admins.
+
+CREATE TABLE link_reports (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  url_id INT NOT NULL,
+  reason VARCHAR(1000) NOT NULL DEFAULT '',
+  reporter_ip VARCHAR(45) NOT NULL DEFAULT '',
+  status ENUM('open', 'dismissed', 'disabled') NOT NULL DEFAULT 'open',
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  reviewed_at DATETIME,
+  FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_link_reports_status ON link_reports (status, created_at);
+
 -- Blog System Tables
 
 CREATE TABLE categories (
@@ -59,6 +222,27 @@ CREATE TABLE users (
   updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 );
 
+-- Link ownership (users is created after urls)
+ALTER TABLE urls ADD CONSTRAINT fk_urls_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX idx_urls_user_created ON urls (user_id, created_at);
+
+-- API keys are random 32-byte tokens, so a SHA-256 digest is enough (no bcrypt per request).
+-- scopes is a comma-separated list, e.g. "urls:write,posts:write".
+CREATE TABLE api_keys (
+  id CHAR(36) NOT NULL PRIMARY KEY,
+  user_id CHAR(36) NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  prefix VARCHAR(16) NOT NULL,
+  key_hash CHAR(64) NOT NULL UNIQUE,
+  scopes VARCHAR(255) NOT NULL DEFAULT '',
+  last_used_at DATETIME,
+  revoked_at DATETIME,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_api_keys_user ON api_keys (user_id, created_at);
+
 -- Image System
 
 CREATE TABLE images (
@@ -74,3 +258,33 @@ CREATE TABLE images (
   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
   updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 );
+
+-- QR Code Templates: named styles for POST /{code}/qr with template=<name>. Empty strings,
+-- zeros and NULLs keep the QR defaults.
+CREATE TABLE qr_templates (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  name VARCHAR(64) NOT NULL UNIQUE,
+  fg_color VARCHAR(9) NOT NULL DEFAULT '',
+  bg_color VARCHAR(9) NOT NULL DEFAULT '',
+  gradient_stops VARCHAR(255) NOT NULL DEFAULT '',
+  gradient_type VARCHAR(10) NOT NULL DEFAULT '',
+  gradient_angle DOUBLE NULL,
+  gradient_center_x DOUBLE NULL,
+  gradient_center_y DOUBLE NULL,
+  module_shape VARCHAR(10) NOT NULL DEFAULT '',
+  finder_outer_shape VARCHAR(10) NOT NULL DEFAULT '',
+  finder_inner_shape VARCHAR(10) NOT NULL DEFAULT '',
+  finder_outer_color VARCHAR(9) NOT NULL DEFAULT '',
+  finder_inner_color VARCHAR(9) NOT NULL DEFAULT '',
+  -- The original upload of this image is the logo
+  logo_image_id CHAR(36) NULL,
+  logo_size INT NOT NULL DEFAULT 0,
+  border_radius INT NOT NULL DEFAULT 0,
+  error_correction CHAR(1) NOT NULL DEFAULT '',
+  quiet_zone INT NULL,
+  size INT NOT NULL DEFAULT 0,
+  dpi INT NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (logo_image_id) REFERENCES images(id) ON DELETE SET NULL
+);